@@ -14,8 +14,12 @@ import (
 )
 
 func main() {
+	fds := [3]*os.File{os.Stdin, os.Stdout, os.Stderr}
+	if exit, ok := shell.RunBundle(fds, os.Args); ok {
+		os.Exit(exit)
+	}
 	os.Exit(prog.Run(
-		[3]*os.File{os.Stdin, os.Stdout, os.Stderr}, os.Args,
+		fds, os.Args,
 		prog.Composite(
 			&pprof.Program{}, &buildinfo.Program{}, &daemon.Program{}, &lsp.Program{},
 			&shell.Program{ActivateDaemon: daemon.Activate})))
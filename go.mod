@@ -6,8 +6,11 @@ require (
 	github.com/mattn/go-isatty v0.0.20
 	github.com/sourcegraph/jsonrpc2 v0.2.0
 	go.etcd.io/bbolt v1.3.8
+	golang.org/x/crypto v0.18.0
+	golang.org/x/net v0.20.0
 	golang.org/x/sync v0.6.0
 	golang.org/x/sys v0.16.0
+	golang.org/x/text v0.14.0 // indirect
 	pkg.nimblebun.works/go-lsp v1.1.0
 )
 
@@ -129,14 +129,20 @@ func NewApp(spec AppSpec) App {
 	lp.HandleCb(a.handle)
 	lp.RedrawCb(a.redraw)
 
+	var dotHighlighter func(ui.Text, string, int) ui.Text
+	if hl, ok := a.Highlighter.(DotHighlighter); ok {
+		dotHighlighter = hl.HighlightDot
+	}
+
 	a.codeArea = tk.NewCodeArea(tk.CodeAreaSpec{
-		Bindings:    spec.CodeAreaBindings,
-		Highlighter: a.Highlighter.Get,
-		Prompt:      a.Prompt.Get,
-		RPrompt:     a.RPrompt.Get,
-		QuotePaste:  spec.QuotePaste,
-		OnSubmit:    a.CommitCode,
-		State:       spec.CodeAreaState,
+		Bindings:       spec.CodeAreaBindings,
+		Highlighter:    a.Highlighter.Get,
+		DotHighlighter: dotHighlighter,
+		Prompt:         a.Prompt.Get,
+		RPrompt:        a.RPrompt.Get,
+		QuotePaste:     spec.QuotePaste,
+		OnSubmit:       a.CommitCode,
+		State:          spec.CodeAreaState,
 
 		SimpleAbbreviations:    spec.SimpleAbbreviations,
 		CommandAbbreviations:   spec.CommandAbbreviations,
@@ -38,6 +38,15 @@ type Highlighter interface {
 	LateUpdates() <-chan struct{}
 }
 
+// DotHighlighter is an optional interface that a Highlighter can implement to
+// highlight parts of the code depending on the position of the dot, such as
+// matching brackets.
+type DotHighlighter interface {
+	// HighlightDot takes code that has been highlighted by Get and the
+	// position of the dot, and returns the code with further highlighting.
+	HighlightDot(styledCode ui.Text, code string, dot int) ui.Text
+}
+
 // A Highlighter implementation that always returns plain text.
 type dummyHighlighter struct{}
 
@@ -2,6 +2,7 @@ package modes
 
 import (
 	"errors"
+	"sort"
 	"strings"
 
 	"src.elv.sh/pkg/cli"
@@ -23,6 +24,9 @@ type CompletionSpec struct {
 	Replace  diag.Ranging
 	Items    []CompletionItem
 	Filter   FilterSpec
+	// Configuration for the preview pane, which is passed the ToInsert field
+	// of the selected candidate.
+	Preview PreviewSpec
 }
 
 // CompletionItem represents a completion item, also known as a candidate.
@@ -49,6 +53,7 @@ func NewCompletion(app cli.App, cfg CompletionSpec) (Completion, error) {
 	if len(cfg.Items) == 0 {
 		return nil, errNoCandidates
 	}
+	pv := newPreview(app, cfg.Preview)
 	w := tk.NewComboBox(tk.ComboBoxSpec{
 		CodeArea: tk.CodeAreaSpec{
 			Prompt:      modePrompt(" COMPLETING "+cfg.Name+" ", true),
@@ -63,16 +68,40 @@ func NewCompletion(app cli.App, cfg CompletionSpec) (Completion, error) {
 					s.Pending = tk.PendingCode{
 						From: cfg.Replace.From, To: cfg.Replace.To, Content: text}
 				})
+				pv.update(text)
 			},
 			OnAccept: func(it tk.Items, i int) {
 				codeArea.MutateState((*tk.CodeAreaState).ApplyPending)
 				app.PopAddon()
 			},
+			OnAcceptMarked: func(it tk.Items, marked []int) {
+				texts := make([]string, len(marked))
+				for i, j := range marked {
+					texts[i] = it.(completionItems)[j].ToInsert
+				}
+				codeArea.MutateState(func(s *tk.CodeAreaState) {
+					s.Pending = tk.PendingCode{
+						From: cfg.Replace.From, To: cfg.Replace.To,
+						Content: strings.Join(texts, " ")}
+					s.ApplyPending()
+				})
+				app.PopAddon()
+			},
 			ExtendStyle: true,
 		},
 		OnFilter: func(w tk.ComboBox, p string) {
-			w.ListBox().Reset(filterCompletionItems(cfg.Items, cfg.Filter.makePredicate(p)), 0)
+			var it completionItems
+			if scorer := cfg.Filter.makeScorer(p); scorer != nil {
+				it = rankCompletionItems(cfg.Items, scorer)
+			} else {
+				it = filterCompletionItems(cfg.Items, cfg.Filter.makePredicate(p))
+			}
+			if len(it) == 0 {
+				pv.clear()
+			}
+			w.ListBox().Reset(it, 0)
 		},
+		Preview: pv.widget(),
 	})
 	return completion{w, codeArea}, nil
 }
@@ -93,6 +122,36 @@ func filterCompletionItems(all []CompletionItem, p func(string) bool) completion
 	return filtered
 }
 
+// Like filterCompletionItems, but uses a scorer, sorts the items by descending
+// scores, and highlights the matched parts of ToShow. Ties are broken by the
+// original order.
+func rankCompletionItems(all []CompletionItem, scorer func(string) (int, []diag.Ranging, bool)) completionItems {
+	var ranked []CompletionItem
+	var scores []int
+	for _, candidate := range all {
+		if score, rs, ok := scorer(unstyle(candidate.ToShow)); ok {
+			candidate.ToShow = highlightMatches(candidate.ToShow, 0, rs)
+			ranked = append(ranked, candidate)
+			scores = append(scores, score)
+		}
+	}
+	sort.Stable(completionItemsByScore{ranked, scores})
+	return ranked
+}
+
+type completionItemsByScore struct {
+	items  []CompletionItem
+	scores []int
+}
+
+func (s completionItemsByScore) Len() int           { return len(s.items) }
+func (s completionItemsByScore) Less(i, j int) bool { return s.scores[i] > s.scores[j] }
+
+func (s completionItemsByScore) Swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+	s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+}
+
 func (it completionItems) Show(i int) ui.Text { return it[i].ToShow }
 func (it completionItems) Len() int           { return len(it) }
 
@@ -25,6 +25,31 @@ func TestCompletion_Filter(t *testing.T) {
 	)
 }
 
+func TestCompletion_Scorer(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	w, err := NewCompletion(f.App, CompletionSpec{
+		Name: "WORD", Replace: diag.Ranging{From: 0, To: 0},
+		Items: []CompletionItem{
+			{ToShow: ui.T("xxab"), ToInsert: "xxab"},
+			{ToShow: ui.T("abxx"), ToInsert: "abxx"},
+			{ToShow: ui.T("yy"), ToInsert: "yy"},
+		},
+		Filter: scoringFilterSpec,
+	})
+	startMode(f.App, w, err)
+	f.TTY.Inject(term.K('a'), term.K('b'))
+	f.TestTTY(t,
+		"abxx\n", Styles,
+		"____",
+		" COMPLETING WORD  ab", scoringStyles,
+		"*****************   ", term.DotHere, "\n",
+		"abxx  xxab", scoringStyles,
+		"YY++    yy",
+	)
+}
+
 func TestCompletion_Accept(t *testing.T) {
 	f := setupStartedCompletion(t)
 	defer f.Stop()
@@ -33,6 +58,16 @@ func TestCompletion_Accept(t *testing.T) {
 	f.TestTTY(t, "foo", term.DotHere)
 }
 
+func TestCompletion_AcceptMarked(t *testing.T) {
+	f := setupStartedCompletion(t)
+	defer f.Stop()
+
+	w := f.App.ActiveWidget().(Completion)
+	w.ListBox().ToggleMarkedAll()
+	f.TTY.Inject(term.K(ui.Enter))
+	f.TestTTY(t, "foo 'foo bar'", term.DotHere)
+}
+
 func TestCompletion_Dismiss(t *testing.T) {
 	f := setupStartedCompletion(t)
 	defer f.Stop()
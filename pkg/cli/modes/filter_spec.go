@@ -3,6 +3,7 @@ package modes
 import (
 	"strings"
 
+	"src.elv.sh/pkg/diag"
 	"src.elv.sh/pkg/ui"
 )
 
@@ -11,6 +12,17 @@ type FilterSpec struct {
 	// Called with the filter text to get the filter predicate. If nil, the
 	// predicate performs substring match.
 	Maker func(string) func(string) bool
+	// Called with the filter text to get a function that reports whether a
+	// string matches the filter, and the ranges of the matched parts. If nil,
+	// the function performs substring match.
+	Locator func(string) func(string) ([]diag.Ranging, bool)
+	// Called with the filter text to get a function that scores a string
+	// against the filter, returning the score (higher is better), the ranges
+	// of the matched parts, and whether the string matches at all. If either
+	// function is nil, items are filtered with the predicate from Maker.
+	// Otherwise, listing modes use it to filter and rank items, and highlight
+	// the matched parts.
+	Scorer func(string) func(string) (int, []diag.Ranging, bool)
 	// Highlighter for the filter. If nil, the filter will not be highlighted.
 	Highlighter func(string) (ui.Text, []ui.Text)
 }
@@ -21,3 +33,44 @@ func (f FilterSpec) makePredicate(p string) func(string) bool {
 	}
 	return f.Maker(p)
 }
+
+func (f FilterSpec) makeLocator(p string) func(string) ([]diag.Ranging, bool) {
+	if f.Locator == nil {
+		return func(s string) ([]diag.Ranging, bool) {
+			i := strings.Index(s, p)
+			if i == -1 {
+				return nil, false
+			}
+			return []diag.Ranging{{From: i, To: i + len(p)}}, true
+		}
+	}
+	return f.Locator(p)
+}
+
+// Returns the scoring function for the filter text, or nil if items should not
+// be ranked, either because there is no scorer or the filter text is empty.
+func (f FilterSpec) makeScorer(p string) func(string) (int, []diag.Ranging, bool) {
+	if f.Scorer == nil || p == "" {
+		return nil
+	}
+	return f.Scorer(p)
+}
+
+var stylingForFilterMatch = ui.Stylings(ui.Bold, ui.FgYellow)
+
+// Highlights the parts of t matched by a filter. The ranges are relative to
+// the part of t starting at offset.
+func highlightMatches(t ui.Text, offset int, rs []diag.Ranging) ui.Text {
+	if len(rs) == 0 {
+		return t
+	}
+	indices := make([]int, 0, 2*len(rs))
+	for _, r := range rs {
+		indices = append(indices, offset+r.From, offset+r.To)
+	}
+	parts := t.Partition(indices...)
+	for i := 1; i < len(parts); i += 2 {
+		parts[i] = ui.StyleText(parts[i], stylingForFilterMatch)
+	}
+	return ui.Concat(parts...)
+}
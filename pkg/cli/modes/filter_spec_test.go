@@ -0,0 +1,30 @@
+package modes
+
+import (
+	"strings"
+
+	"src.elv.sh/pkg/diag"
+	"src.elv.sh/pkg/ui"
+)
+
+// A FilterSpec with a scorer that matches substrings, scoring earlier matches
+// higher.
+var scoringFilterSpec = FilterSpec{
+	Scorer: func(p string) func(string) (int, []diag.Ranging, bool) {
+		return func(s string) (int, []diag.Ranging, bool) {
+			i := strings.Index(s, p)
+			if i == -1 {
+				return 0, nil, false
+			}
+			return -i, []diag.Ranging{{From: i, To: i + len(p)}}, true
+		}
+	},
+}
+
+var scoringStyles = ui.RuneStylesheet{
+	'*': ui.Stylings(ui.Bold, ui.FgWhite, ui.BgMagenta),
+	'+': ui.Inverse,
+	'#': ui.Stylings(ui.Inverse, ui.FgBlue),
+	'y': ui.Stylings(ui.Bold, ui.FgYellow),
+	'Y': ui.Stylings(ui.Bold, ui.FgYellow, ui.Inverse),
+}
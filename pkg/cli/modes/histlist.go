@@ -2,9 +2,11 @@ package modes
 
 import (
 	"fmt"
+	"sort"
 
 	"src.elv.sh/pkg/cli"
 	"src.elv.sh/pkg/cli/tk"
+	"src.elv.sh/pkg/diag"
 	"src.elv.sh/pkg/store/storedefs"
 	"src.elv.sh/pkg/ui"
 )
@@ -28,6 +30,9 @@ type HistlistSpec struct {
 	Filter FilterSpec
 	// RPrompt of the code area (first row of the widget).
 	CodeAreaRPrompt func() ui.Text
+	// Configuration for the preview pane, which is passed the text of the
+	// selected command.
+	Preview PreviewSpec
 }
 
 // NewHistlist creates a new histlist mode.
@@ -51,8 +56,9 @@ func NewHistlist(app cli.App, spec HistlistSpec) (Histlist, error) {
 	for i, cmd := range cmds {
 		last[cmd.Text] = i
 	}
-	cmdItems := histlistItems{cmds, last}
+	cmdItems := histlistItems{cmds, last, nil}
 
+	pv := newPreview(app, spec.Preview)
 	w := tk.NewComboBox(tk.ComboBoxSpec{
 		CodeArea: tk.CodeAreaSpec{
 			Prompt: func() ui.Text {
@@ -67,30 +73,56 @@ func NewHistlist(app cli.App, spec HistlistSpec) (Histlist, error) {
 		},
 		ListBox: tk.ListBoxSpec{
 			Bindings: spec.Bindings,
+			OnSelect: func(it tk.Items, i int) {
+				pv.update(it.(histlistItems).entries[i].Text)
+			},
 			OnAccept: func(it tk.Items, i int) {
-				text := it.(histlistItems).entries[i].Text
-				codeArea.MutateState(func(s *tk.CodeAreaState) {
-					buf := &s.Buffer
-					if buf.Content == "" {
-						buf.InsertAtDot(text)
-					} else {
-						buf.InsertAtDot("\n" + text)
-					}
-				})
+				insertHistlistEntries(codeArea, it.(histlistItems), i)
+				app.PopAddon()
+			},
+			OnAcceptMarked: func(it tk.Items, marked []int) {
+				insertHistlistEntries(codeArea, it.(histlistItems), marked...)
 				app.PopAddon()
 			},
 		},
 		OnFilter: func(w tk.ComboBox, p string) {
-			it := cmdItems.filter(spec.Filter.makePredicate(p), spec.Dedup())
+			var it histlistItems
+			if scorer := spec.Filter.makeScorer(p); scorer != nil {
+				it = cmdItems.rank(scorer, spec.Dedup())
+			} else {
+				it = cmdItems.filter(spec.Filter.makePredicate(p), spec.Dedup())
+			}
+			if it.Len() == 0 {
+				pv.clear()
+			}
 			w.ListBox().Reset(it, it.Len()-1)
 		},
+		Preview: pv.widget(),
 	})
 	return w, nil
 }
 
+// Inserts the entries with the given indices into the code area, each on its
+// own line.
+func insertHistlistEntries(codeArea tk.CodeArea, it histlistItems, indices ...int) {
+	codeArea.MutateState(func(s *tk.CodeAreaState) {
+		buf := &s.Buffer
+		for _, i := range indices {
+			text := it.entries[i].Text
+			if buf.Content == "" {
+				buf.InsertAtDot(text)
+			} else {
+				buf.InsertAtDot("\n" + text)
+			}
+		}
+	})
+}
+
 type histlistItems struct {
 	entries []storedefs.Cmd
 	last    map[string]int
+	// Parts of the entries to highlight. Only populated by rank.
+	highlights [][]diag.Ranging
 }
 
 func (it histlistItems) filter(p func(string) bool, dedup bool) histlistItems {
@@ -104,13 +136,48 @@ func (it histlistItems) filter(p func(string) bool, dedup bool) histlistItems {
 			filtered = append(filtered, entry)
 		}
 	}
-	return histlistItems{filtered, nil}
+	return histlistItems{filtered, nil, nil}
+}
+
+// Like filter, but uses a scorer, and sorts the entries by ascending scores,
+// so that the best match is at the bottom, which is selected by default. Ties
+// are broken by the original order.
+func (it histlistItems) rank(scorer func(string) (int, []diag.Ranging, bool), dedup bool) histlistItems {
+	var ranked []scoredCmd
+	for i, entry := range it.entries {
+		if dedup && it.last[entry.Text] != i {
+			continue
+		}
+		if score, rs, ok := scorer(entry.Text); ok {
+			ranked = append(ranked, scoredCmd{entry, score, rs})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score < ranked[j].score
+	})
+	entries := make([]storedefs.Cmd, len(ranked))
+	highlights := make([][]diag.Ranging, len(ranked))
+	for i, r := range ranked {
+		entries[i], highlights[i] = r.cmd, r.highlights
+	}
+	return histlistItems{entries, nil, highlights}
+}
+
+type scoredCmd struct {
+	cmd        storedefs.Cmd
+	score      int
+	highlights []diag.Ranging
 }
 
 func (it histlistItems) Show(i int) ui.Text {
 	entry := it.entries[i]
 	// TODO: The alignment of the index works up to 10000 entries.
-	return ui.T(fmt.Sprintf("%4d %s", entry.Seq, entry.Text))
+	seq := fmt.Sprintf("%4d ", entry.Seq)
+	t := ui.T(seq + entry.Text)
+	if it.highlights != nil {
+		t = highlightMatches(t, len(seq), it.highlights[i])
+	}
+	return t
 }
 
 func (it histlistItems) Len() int { return len(it.entries) }
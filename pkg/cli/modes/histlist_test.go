@@ -8,6 +8,7 @@ import (
 	. "src.elv.sh/pkg/cli/clitest"
 	"src.elv.sh/pkg/cli/histutil"
 	"src.elv.sh/pkg/cli/term"
+	"src.elv.sh/pkg/cli/tk"
 	"src.elv.sh/pkg/store/storedefs"
 	"src.elv.sh/pkg/ui"
 )
@@ -86,6 +87,21 @@ func TestHistlist(t *testing.T) {
 		"\n", "baz2", term.DotHere)
 }
 
+func TestHistlist_AcceptMarked(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	st := histutil.NewMemStore("foo", "bar", "baz")
+	startHistlist(f.App, HistlistSpec{AllCmds: st.AllCmds})
+	w := f.App.ActiveWidget().(Histlist)
+	w.ListBox().ToggleMarked()
+	w.ListBox().Select(func(tk.ListBoxState) int { return 0 })
+	w.ListBox().ToggleMarked()
+	f.TTY.Inject(term.K(ui.Enter))
+	// Marked entries are inserted in order, each on its own line.
+	f.TestTTY(t, "foo\n", "baz", term.DotHere)
+}
+
 func TestHistlist_Dedup(t *testing.T) {
 	f := Setup()
 	defer f.Stop()
@@ -152,6 +168,30 @@ func TestHistlist_CustomFilter(t *testing.T) {
 		"++++++++++++++++++++++++++++++++++++++++++++++++++")
 }
 
+func TestHistlist_Scorer(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	st := histutil.NewMemStore(
+		// 0     1      2       3
+		"abxx", "xxab", "xabx", "yy")
+
+	startHistlist(f.App, HistlistSpec{AllCmds: st.AllCmds, Filter: scoringFilterSpec})
+	f.TTY.Inject(term.K('a'), term.K('b'))
+	// Entries are sorted by ascending scores, so that the best match is at the
+	// bottom and selected.
+	f.TestTTY(t,
+		"\n",
+		" HISTORY (dedup on)  ab", scoringStyles,
+		"********************   ", term.DotHere, "\n",
+		"   1 xxab\n", scoringStyles,
+		"       yy",
+		"   2 xabx\n", scoringStyles,
+		"      yy",
+		"   0 abxx                                         ", scoringStyles,
+		"+++++YY+++++++++++++++++++++++++++++++++++++++++++")
+}
+
 func startHistlist(app cli.App, spec HistlistSpec) {
 	w, err := NewHistlist(app, spec)
 	startMode(app, w, err)
@@ -0,0 +1,219 @@
+package modes
+
+import (
+	"strings"
+
+	"src.elv.sh/pkg/cli"
+	"src.elv.sh/pkg/cli/histutil"
+	"src.elv.sh/pkg/cli/term"
+	"src.elv.sh/pkg/cli/tk"
+	"src.elv.sh/pkg/diag"
+	"src.elv.sh/pkg/store/storedefs"
+	"src.elv.sh/pkg/ui"
+)
+
+// Histsearch is a mode for searching history incrementally. The current match
+// is shown in place in the code area, with the matched parts highlighted.
+type Histsearch interface {
+	tk.Widget
+	// Search for the previous matching entry in history.
+	Prev() error
+	// Search for the next matching entry in history.
+	Next() error
+	// Update buffer with the current match. Always returns a nil error.
+	Accept() error
+	// Search again with the current query, starting from the newest entry.
+	// This should be called when the configuration of the filter has changed.
+	Refilter()
+}
+
+// HistsearchSpec specifies the configuration for the histsearch mode.
+type HistsearchSpec struct {
+	// Key bindings.
+	Bindings tk.Bindings
+	// History store to search.
+	Store histutil.Store
+	// Configuration for the filter. Only the Locator and Highlighter fields
+	// are used.
+	Filter FilterSpec
+	// Called to get the name of the matcher, which is shown in the mode line.
+	// If nil, no matcher name is shown.
+	MatcherName func() string
+	// RPrompt of the code area for the query.
+	CodeAreaRPrompt func() ui.Text
+}
+
+type histsearch struct {
+	app        cli.App
+	attachedTo tk.CodeArea
+	queryArea  tk.CodeArea
+	HistsearchSpec
+
+	cursor histutil.Cursor
+	// Distinct commands loaded from the cursor, from the newest to the oldest.
+	cmds []storedefs.Cmd
+	seen map[string]bool
+	// Index of the current match in cmds, or -1 if there is no match yet.
+	index int
+
+	lastQuery string
+	locate    func(string) ([]diag.Ranging, bool)
+	failing   bool
+}
+
+// NewHistsearch creates a new Histsearch mode.
+func NewHistsearch(app cli.App, spec HistsearchSpec) (Histsearch, error) {
+	codeArea, err := FocusedCodeArea(app)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Store == nil {
+		return nil, errNoHistoryStore
+	}
+	if spec.Bindings == nil {
+		spec.Bindings = tk.DummyBindings{}
+	}
+	w := &histsearch{app: app, attachedTo: codeArea, HistsearchSpec: spec}
+	w.queryArea = tk.NewCodeArea(tk.CodeAreaSpec{
+		Prompt:      w.prompt,
+		RPrompt:     spec.CodeAreaRPrompt,
+		Highlighter: spec.Filter.Highlighter,
+	})
+	w.reset()
+	return w, nil
+}
+
+func (w *histsearch) prompt() ui.Text {
+	var notes []string
+	if w.MatcherName != nil {
+		notes = append(notes, w.MatcherName())
+	}
+	if w.failing {
+		notes = append(notes, "no match")
+	}
+	content := " SEARCH "
+	if len(notes) > 0 {
+		content += "(" + strings.Join(notes, ", ") + ") "
+	}
+	return modeLine(content, true)
+}
+
+func (w *histsearch) Render(width, height int) *term.Buffer {
+	return w.queryArea.Render(width, height)
+}
+
+func (w *histsearch) MaxHeight(width, height int) int {
+	return w.queryArea.MaxHeight(width, height)
+}
+
+// Handle first lets the bindings handle the event, and then the code area for
+// the query. If neither handles the event, or if the event is Enter, the
+// current match is accepted and the event is passed to the code area the mode
+// is attached to.
+func (w *histsearch) Handle(event term.Event) bool {
+	if w.Bindings.Handle(w, event) {
+		return true
+	}
+	if event != term.K('\n') && w.queryArea.Handle(event) {
+		if query := w.query(); query != w.lastQuery {
+			w.lastQuery = query
+			w.Refilter()
+		}
+		return true
+	}
+	w.Accept()
+	return w.attachedTo.Handle(event)
+}
+
+func (w *histsearch) query() string {
+	return w.queryArea.CopyState().Buffer.Content
+}
+
+// Resets the search state, so that the next search starts from the newest
+// entry.
+func (w *histsearch) reset() {
+	w.cursor = w.Store.Cursor("")
+	w.cmds = nil
+	w.seen = make(map[string]bool)
+	w.index = -1
+	w.failing = false
+	w.locate = w.Filter.makeLocator(w.query())
+}
+
+func (w *histsearch) Refilter() {
+	w.reset()
+	if w.query() == "" {
+		w.Dismiss()
+		return
+	}
+	if w.Prev() != nil {
+		// Keep showing the previous match, but drop the highlights since they
+		// are no longer accurate.
+		w.attachedTo.MutateState(func(s *tk.CodeAreaState) {
+			s.Pending.Highlights = nil
+		})
+	}
+}
+
+func (w *histsearch) Prev() error {
+	return w.search(1)
+}
+
+func (w *histsearch) Next() error {
+	return w.search(-1)
+}
+
+func (w *histsearch) search(delta int) error {
+	for i := w.index + delta; i >= 0; i += delta {
+		cmd, err := w.cmd(i)
+		if err != nil {
+			if err == histutil.ErrEndOfHistory {
+				break
+			}
+			return err
+		}
+		if rs, ok := w.locate(cmd.Text); ok {
+			w.index = i
+			w.failing = false
+			w.attachedTo.MutateState(func(s *tk.CodeAreaState) {
+				s.Pending = tk.PendingCode{
+					From: 0, To: len(s.Buffer.Content),
+					Content: cmd.Text, Highlights: rs,
+				}
+			})
+			return nil
+		}
+	}
+	w.failing = true
+	return histutil.ErrEndOfHistory
+}
+
+// Returns the i-th newest distinct command, loading more commands from the
+// cursor as needed.
+func (w *histsearch) cmd(i int) (storedefs.Cmd, error) {
+	for len(w.cmds) <= i {
+		w.cursor.Prev()
+		cmd, err := w.cursor.Get()
+		if err != nil {
+			return storedefs.Cmd{}, err
+		}
+		if !w.seen[cmd.Text] {
+			w.seen[cmd.Text] = true
+			w.cmds = append(w.cmds, cmd)
+		}
+	}
+	return w.cmds[i], nil
+}
+
+func (w *histsearch) Dismiss() {
+	w.attachedTo.MutateState(func(s *tk.CodeAreaState) { s.Pending = tk.PendingCode{} })
+}
+
+func (w *histsearch) Accept() error {
+	w.attachedTo.MutateState(func(s *tk.CodeAreaState) {
+		s.Pending.Highlights = nil
+		s.ApplyPending()
+	})
+	w.app.PopAddon()
+	return nil
+}
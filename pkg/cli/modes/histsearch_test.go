@@ -0,0 +1,161 @@
+package modes
+
+import (
+	"testing"
+
+	"src.elv.sh/pkg/cli"
+	. "src.elv.sh/pkg/cli/clitest"
+	"src.elv.sh/pkg/cli/histutil"
+	"src.elv.sh/pkg/cli/term"
+	"src.elv.sh/pkg/cli/tk"
+	"src.elv.sh/pkg/diag"
+	"src.elv.sh/pkg/ui"
+)
+
+var histsearchStyles = ui.RuneStylesheet{
+	'_': ui.Underlined,
+	'+': ui.Stylings(ui.Underlined, ui.Inverse),
+	'*': ui.Stylings(ui.Bold, ui.FgWhite, ui.BgMagenta),
+}
+
+func TestHistsearch(t *testing.T) {
+	f := Setup(WithSpec(func(spec *cli.AppSpec) {
+		spec.CodeAreaState.Buffer = tk.CodeBuffer{Content: "x", Dot: 1}
+	}))
+	defer f.Stop()
+
+	getSpec := func() HistsearchSpec {
+		store := histutil.NewMemStore(
+			// 0       1        2         3       4
+			"ls -l", "echo a", "ls -a", "echo a", "make")
+		return HistsearchSpec{
+			Store: store,
+			Bindings: tk.MapBindings{
+				term.K('R', ui.Ctrl): func(w tk.Widget) { w.(Histsearch).Prev() },
+				term.K('S', ui.Ctrl): func(w tk.Widget) { w.(Histsearch).Next() },
+				term.K('[', ui.Ctrl): func(tk.Widget) { f.App.PopAddon() },
+			},
+		}
+	}
+
+	startHistsearch(f.App, getSpec())
+	f.TestTTY(t,
+		"x\n",
+		" SEARCH ", histsearchStyles,
+		"********", " ", term.DotHere,
+	)
+
+	f.TTY.Inject(term.K('l'), term.K('s'))
+	f.TestTTY(t,
+		"ls -a", histsearchStyles,
+		"++___", "\n",
+		" SEARCH ", histsearchStyles,
+		"********", " ls", term.DotHere,
+	)
+
+	// Ctrl-R goes to an earlier match.
+	f.TTY.Inject(term.K('R', ui.Ctrl))
+	f.TestTTY(t,
+		"ls -l", histsearchStyles,
+		"++___", "\n",
+		" SEARCH ", histsearchStyles,
+		"********", " ls", term.DotHere,
+	)
+
+	// Failing search is shown in the mode line.
+	f.TTY.Inject(term.K('R', ui.Ctrl))
+	f.TestTTY(t,
+		"ls -l", histsearchStyles,
+		"++___", "\n",
+		" SEARCH (no match) ", histsearchStyles,
+		"*******************", " ls", term.DotHere,
+	)
+
+	// Ctrl-S goes back to a later match.
+	f.TTY.Inject(term.K('S', ui.Ctrl))
+	f.TestTTY(t,
+		"ls -a", histsearchStyles,
+		"++___", "\n",
+		" SEARCH ", histsearchStyles,
+		"********", " ls", term.DotHere,
+	)
+
+	// Closing the mode restores the original buffer.
+	f.TTY.Inject(term.K('[', ui.Ctrl))
+	f.TestTTY(t, "x", term.DotHere)
+
+	// Start over; duplicate entries are only visited once, and unhandled
+	// events accept the match.
+	startHistsearch(f.App, getSpec())
+	f.TTY.Inject(term.K('e'))
+	f.TestTTY(t,
+		"make", histsearchStyles,
+		"___+", "\n",
+		" SEARCH ", histsearchStyles,
+		"********", " e", term.DotHere,
+	)
+	f.TTY.Inject(term.K('R', ui.Ctrl))
+	f.TestTTY(t,
+		"echo a", histsearchStyles,
+		"+_____", "\n",
+		" SEARCH ", histsearchStyles,
+		"********", " e", term.DotHere,
+	)
+	// The other "echo a" is skipped.
+	f.TTY.Inject(term.K('R', ui.Ctrl))
+	f.TestTTY(t,
+		"echo a", histsearchStyles,
+		"+_____", "\n",
+		" SEARCH (no match) ", histsearchStyles,
+		"*******************", " e", term.DotHere,
+	)
+	f.TTY.Inject(term.K(ui.Right))
+	f.TestTTY(t, "echo a", term.DotHere)
+}
+
+func TestHistsearch_Locator(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	startHistsearch(f.App, HistsearchSpec{
+		Store: histutil.NewMemStore("foo bar"),
+		Filter: FilterSpec{
+			Locator: func(q string) func(string) ([]diag.Ranging, bool) {
+				return func(s string) ([]diag.Ranging, bool) {
+					return []diag.Ranging{{From: 0, To: 1}, {From: 4, To: 5}}, true
+				}
+			},
+		},
+		MatcherName: func() string { return "fake" },
+	})
+	f.TTY.Inject(term.K('f'))
+	f.TestTTY(t,
+		"foo bar", histsearchStyles,
+		"+___+__", "\n",
+		" SEARCH (fake) ", histsearchStyles,
+		"***************", " f", term.DotHere,
+	)
+}
+
+func TestHistsearch_FocusedWidgetNotCodeArea(t *testing.T) {
+	testFocusedWidgetNotCodeArea(t, func(app cli.App) error {
+		store := histutil.NewMemStore("foo")
+		_, err := NewHistsearch(app, HistsearchSpec{Store: store})
+		return err
+	})
+}
+
+func TestHistsearch_NoStore(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	startHistsearch(f.App, HistsearchSpec{})
+	f.TestTTYNotes(t,
+		"error: no history store", Styles,
+		"!!!!!!")
+}
+
+func startHistsearch(app cli.App, spec HistsearchSpec) {
+	w, err := NewHistsearch(app, spec)
+	startMode(app, w, err)
+}
@@ -74,6 +74,13 @@ func NewLastcmd(app cli.App, cfg LastcmdSpec) (Lastcmd, error) {
 			OnAccept: func(it tk.Items, i int) {
 				accept(it.(lastcmdItems).entries[i].content)
 			},
+			OnAcceptMarked: func(it tk.Items, marked []int) {
+				words := make([]string, len(marked))
+				for i, j := range marked {
+					words[i] = it.(lastcmdItems).entries[j].content
+				}
+				accept(strings.Join(words, " "))
+			},
 		},
 		OnFilter: func(w tk.ComboBox, p string) {
 			items := filterLastcmdItems(entries, p)
@@ -28,8 +28,15 @@ type ListingSpec struct {
 	// If unspecified, the Accept function default to a function that does
 	// nothing other than returning false.
 	Accept func(string)
+	// A function to call when the user has accepted multiple marked items,
+	// with the ToAccept fields of the items. If unspecified, items can't be
+	// marked.
+	AcceptMarked func([]string)
 	// Whether to automatically accept when there is only one item.
 	AutoAccept bool
+	// Configuration for the preview pane, which is passed the ToAccept field
+	// of the selected item.
+	Preview PreviewSpec
 }
 
 // ListingItem is an item to show in the listing.
@@ -57,24 +64,44 @@ func NewListing(app cli.App, spec ListingSpec) (Listing, error) {
 		app.PopAddon()
 		spec.Accept(s)
 	}
+	var onAcceptMarked func(tk.Items, []int)
+	if spec.AcceptMarked != nil {
+		onAcceptMarked = func(it tk.Items, marked []int) {
+			toAccept := make([]string, len(marked))
+			for i, j := range marked {
+				toAccept[i] = it.(listingItems)[j].ToAccept
+			}
+			app.PopAddon()
+			spec.AcceptMarked(toAccept)
+		}
+	}
+	pv := newPreview(app, spec.Preview)
 	w := tk.NewComboBox(tk.ComboBoxSpec{
 		CodeArea: tk.CodeAreaSpec{
 			Prompt: modePrompt(spec.Caption, true),
 		},
 		ListBox: tk.ListBoxSpec{
 			Bindings: spec.Bindings,
+			OnSelect: func(it tk.Items, i int) {
+				pv.update(it.(listingItems)[i].ToAccept)
+			},
 			OnAccept: func(it tk.Items, i int) {
 				accept(it.(listingItems)[i].ToAccept)
 			},
-			ExtendStyle: true,
+			OnAcceptMarked: onAcceptMarked,
+			ExtendStyle:    true,
 		},
 		OnFilter: func(w tk.ComboBox, q string) {
 			it, selected := spec.GetItems(q)
+			if len(it) == 0 {
+				pv.clear()
+			}
 			w.ListBox().Reset(listingItems(it), selected)
 			if spec.AutoAccept && len(it) == 1 {
 				accept(it[0].ToAccept)
 			}
 		},
+		Preview: pv.widget(),
 	})
 	return w, nil
 }
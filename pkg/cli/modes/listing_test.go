@@ -1,6 +1,8 @@
 package modes
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"src.elv.sh/pkg/cli"
@@ -50,6 +52,83 @@ func TestListing_Accept_ClosingListing(t *testing.T) {
 	f.TestTTY(t, "foo", term.DotHere)
 }
 
+var markedStyles = ui.RuneStylesheet{
+	'*': ui.Stylings(ui.Bold, ui.FgWhite, ui.BgMagenta),
+	'#': ui.Stylings(ui.Inverse, ui.FgBlue),
+	'/': ui.FgBlue,
+	'_': ui.Underlined,
+	'M': ui.Stylings(ui.Underlined, ui.Inverse, ui.FgGreen),
+	'N': ui.Stylings(ui.Underlined, ui.Inverse, ui.FgBlue),
+}
+
+func TestListing_AcceptMarked(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	var accepted []string
+	startListing(f.App, ListingSpec{
+		GetItems:     fooAndGreenBar,
+		AcceptMarked: func(ss []string) { accepted = ss },
+	})
+	w := f.App.ActiveWidget().(Listing)
+	w.ListBox().Select(tk.Next)
+	w.ListBox().ToggleMarked()
+	f.TestTTY(t,
+		"\n",
+		" LISTING  ", Styles,
+		"********* ", term.DotHere, "\n",
+		"foo                                               \n",
+		"bar                                               ", markedStyles,
+		"MMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMMM",
+	)
+	f.TTY.Inject(term.K('\n'))
+	f.TestTTY(t /* nothing */)
+	if len(accepted) != 1 || accepted[0] != "bar" {
+		t.Errorf("AcceptMarked called with %v, want [bar]", accepted)
+	}
+}
+
+func TestListing_AcceptMarked_AcrossFilterChanges(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	var accepted []string
+	startListing(f.App, ListingSpec{
+		GetItems: func(q string) ([]ListingItem, int) {
+			var items []ListingItem
+			for _, s := range []string{"foo", "food", "bar"} {
+				if strings.HasPrefix(s, q) {
+					items = append(items, ListingItem{s, ui.T(s)})
+				}
+			}
+			return items, 0
+		},
+		AcceptMarked: func(ss []string) { accepted = ss },
+	})
+	w := f.App.ActiveWidget().(Listing)
+	w.ListBox().Select(tk.Next)
+	w.ListBox().Select(tk.Next)
+	w.ListBox().ToggleMarked()
+	// Filter the list down to foo and food, and mark food.
+	f.TTY.Inject(term.K('f'), term.K('o'))
+	f.TestTTY(t,
+		"\n",
+		" LISTING  fo", Styles,
+		"*********   ", term.DotHere, "\n",
+		"foo                                               \n", Styles,
+		"++++++++++++++++++++++++++++++++++++++++++++++++++",
+		"food                                              ",
+	)
+	w.ListBox().Select(tk.Next)
+	w.ListBox().ToggleMarked()
+	// Clear the filter; both bar and food are still marked.
+	f.TTY.Inject(term.K(ui.Backspace), term.K(ui.Backspace), term.K('\n'))
+	f.TestTTY(t /* nothing */)
+	if want := []string{"food", "bar"}; !reflect.DeepEqual(accepted, want) {
+		t.Errorf("AcceptMarked called with %v, want %v", accepted, want)
+	}
+}
+
 func TestListing_Accept_DefaultNop(t *testing.T) {
 	f := Setup()
 	defer f.Stop()
@@ -6,10 +6,12 @@ import (
 	"math"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"src.elv.sh/pkg/cli"
 	"src.elv.sh/pkg/cli/tk"
+	"src.elv.sh/pkg/diag"
 	"src.elv.sh/pkg/fsutil"
 	"src.elv.sh/pkg/store/storedefs"
 	"src.elv.sh/pkg/ui"
@@ -37,6 +39,9 @@ type LocationSpec struct {
 	IterateWorkspaces LocationWSIterator
 	// Configuration for the filter.
 	Filter FilterSpec
+	// Configuration for the preview pane, which is passed the path of the
+	// selected directory.
+	Preview PreviewSpec
 }
 
 // LocationStore defines the interface for interacting with the directory history.
@@ -89,8 +94,16 @@ func NewLocation(app cli.App, cfg LocationSpec) (Location, error) {
 		}
 	}
 
-	l := locationList{dirs}
+	l := locationList{dirs, nil}
+	// Expands a path relative to the workspace kind to an absolute path.
+	expandWorkspace := func(path string) string {
+		if strings.HasPrefix(path, wsKind) {
+			return wsRoot + path[len(wsKind):]
+		}
+		return path
+	}
 
+	pv := newPreview(app, cfg.Preview)
 	w := tk.NewComboBox(tk.ComboBoxSpec{
 		CodeArea: tk.CodeAreaSpec{
 			Prompt:      modePrompt(" LOCATION ", true),
@@ -98,11 +111,11 @@ func NewLocation(app cli.App, cfg LocationSpec) (Location, error) {
 		},
 		ListBox: tk.ListBoxSpec{
 			Bindings: cfg.Bindings,
+			OnSelect: func(it tk.Items, i int) {
+				pv.update(expandWorkspace(it.(locationList).dirs[i].Path))
+			},
 			OnAccept: func(it tk.Items, i int) {
-				path := it.(locationList).dirs[i].Path
-				if strings.HasPrefix(path, wsKind) {
-					path = wsRoot + path[len(wsKind):]
-				}
+				path := expandWorkspace(it.(locationList).dirs[i].Path)
 				err := cfg.Store.Chdir(path)
 				if err != nil {
 					app.Notify(ErrorText(err))
@@ -111,8 +124,18 @@ func NewLocation(app cli.App, cfg LocationSpec) (Location, error) {
 			},
 		},
 		OnFilter: func(w tk.ComboBox, p string) {
-			w.ListBox().Reset(l.filter(cfg.Filter.makePredicate(p)), 0)
+			var it locationList
+			if scorer := cfg.Filter.makeScorer(p); scorer != nil {
+				it = l.rank(scorer)
+			} else {
+				it = l.filter(cfg.Filter.makePredicate(p))
+			}
+			if it.Len() == 0 {
+				pv.clear()
+			}
+			w.ListBox().Reset(it, 0)
 		},
+		Preview: pv.widget(),
 	})
 	return w, nil
 }
@@ -152,6 +175,8 @@ func (ws LocationWSIterator) Parse(path string) (kind, root string) {
 
 type locationList struct {
 	dirs []storedefs.Dir
+	// Parts of the paths to highlight. Only populated by rank.
+	highlights [][]diag.Ranging
 }
 
 func (l locationList) filter(p func(string) bool) locationList {
@@ -161,12 +186,41 @@ func (l locationList) filter(p func(string) bool) locationList {
 			filteredDirs = append(filteredDirs, dir)
 		}
 	}
-	return locationList{filteredDirs}
+	return locationList{filteredDirs, nil}
+}
+
+// Like filter, but uses a scorer, and sorts the directories by descending
+// scores. Ties are broken by the original order.
+func (l locationList) rank(scorer func(string) (int, []diag.Ranging, bool)) locationList {
+	type scoredDir struct {
+		dir        storedefs.Dir
+		score      int
+		highlights []diag.Ranging
+	}
+	var ranked []scoredDir
+	for _, dir := range l.dirs {
+		if score, rs, ok := scorer(fsutil.TildeAbbr(dir.Path)); ok {
+			ranked = append(ranked, scoredDir{dir, score, rs})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	dirs := make([]storedefs.Dir, len(ranked))
+	highlights := make([][]diag.Ranging, len(ranked))
+	for i, r := range ranked {
+		dirs[i], highlights[i] = r.dir, r.highlights
+	}
+	return locationList{dirs, highlights}
 }
 
 func (l locationList) Show(i int) ui.Text {
-	return ui.T(fmt.Sprintf("%s %s",
-		showScore(l.dirs[i].Score), fsutil.TildeAbbr(l.dirs[i].Path)))
+	score := showScore(l.dirs[i].Score) + " "
+	t := ui.T(score + fsutil.TildeAbbr(l.dirs[i].Path))
+	if l.highlights != nil {
+		t = highlightMatches(t, len(score), l.highlights[i])
+	}
+	return t
 }
 
 func (l locationList) Len() int { return len(l.dirs) }
@@ -141,6 +141,33 @@ func TestLocation_Hidden(t *testing.T) {
 	f.TTY.TestBuffer(t, wantBuf)
 }
 
+func TestLocation_Scorer(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	dirs := []storedefs.Dir{
+		{Path: "/xxab", Score: 200},
+		{Path: "/abxx", Score: 100},
+		{Path: "/yy", Score: 50},
+	}
+	startLocation(f.App, LocationSpec{
+		Store:  locationStore{storedDirs: dirs},
+		Filter: scoringFilterSpec,
+	})
+	f.TTY.Inject(term.K('a'), term.K('b'))
+	// Directories are sorted by the scores from the scorer, and the matched
+	// parts are highlighted.
+	f.TestTTY(t,
+		"\n",
+		" LOCATION  ab", scoringStyles,
+		"**********   ", term.DotHere, "\n",
+		"100 /abxx                                         \n", scoringStyles,
+		"+++++YY+++++++++++++++++++++++++++++++++++++++++++",
+		"200 /xxab", scoringStyles,
+		"       yy",
+	)
+}
+
 func TestLocation_Pinned(t *testing.T) {
 	f := Setup()
 	defer f.Stop()
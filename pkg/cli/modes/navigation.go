@@ -10,6 +10,7 @@ import (
 	"src.elv.sh/pkg/cli"
 	"src.elv.sh/pkg/cli/term"
 	"src.elv.sh/pkg/cli/tk"
+	"src.elv.sh/pkg/diag"
 	"src.elv.sh/pkg/ui"
 )
 
@@ -32,6 +33,12 @@ type Navigation interface {
 	// MutateShowHidden changes whether hidden files - files whose names start
 	// with ".", should be shown.
 	MutateShowHidden(f func(bool) bool)
+	// ToggleMarked toggles whether the currently selected file is marked.
+	// Marks are cleared when ascending or descending.
+	ToggleMarked()
+	// MarkedNames returns the names of marked files in the current directory,
+	// sorted.
+	MarkedNames() []string
 }
 
 // NavigationSpec specifieis the configuration for the navigation mode.
@@ -52,6 +59,9 @@ type NavigationSpec struct {
 type navigationState struct {
 	Filtering  bool
 	ShowHidden bool
+	// Names of marked files in the current directory. The map must not be
+	// mutated in place, since it may be shared with copies of the state.
+	Marked map[string]bool
 }
 
 type navigation struct {
@@ -126,6 +136,7 @@ func (w *navigation) ascend() {
 			s.Buffer = tk.CodeBuffer{}
 		})
 		w.lastFilter = ""
+		w.MutateState(func(s *navigationState) { s.Marked = nil })
 		updateState(w, currentName)
 	}
 }
@@ -151,6 +162,7 @@ func (w *navigation) descend() {
 			s.Buffer = tk.CodeBuffer{}
 		})
 		w.lastFilter = ""
+		w.MutateState(func(s *navigationState) { s.Marked = nil })
 		updateState(w, "")
 	}
 }
@@ -209,11 +221,41 @@ func (w *navigation) SelectedName() string {
 	return ""
 }
 
+func (w *navigation) ToggleMarked() {
+	name := w.SelectedName()
+	if name == "" {
+		return
+	}
+	w.MutateState(func(s *navigationState) {
+		marked := make(map[string]bool, len(s.Marked)+1)
+		for name := range s.Marked {
+			marked[name] = true
+		}
+		if marked[name] {
+			delete(marked, name)
+		} else {
+			marked[name] = true
+		}
+		s.Marked = marked
+	})
+	updateState(w, name)
+}
+
+func (w *navigation) MarkedNames() []string {
+	var names []string
+	for name := range w.CopyState().Marked {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func updateState(w *navigation, selectName string) {
 	colView := w.colView
 	cursor := w.Cursor
 	filter := w.lastFilter
-	showHidden := w.CopyState().ShowHidden
+	state := w.CopyState()
+	showHidden := state.ShowHidden
 
 	var parentCol, currentCol tk.Widget
 
@@ -237,6 +279,8 @@ func updateState(w *navigation, selectName string) {
 		currentCol = makeColInner(
 			current,
 			w.Filter.makePredicate(filter),
+			w.Filter.makeScorer(filter),
+			state.Marked,
 			showHidden,
 			func(it tk.Items, i int) {
 				previewCol := makeCol(it.(fileItems)[i], showHidden)
@@ -291,10 +335,13 @@ func tryToSelectName(w tk.Widget, name string) {
 }
 
 func makeCol(f NavigationFile, showHidden bool) tk.Widget {
-	return makeColInner(f, func(string) bool { return true }, showHidden, nil)
+	return makeColInner(f, func(string) bool { return true }, nil, nil, showHidden, nil)
 }
 
-func makeColInner(f NavigationFile, filter func(string) bool, showHidden bool, onSelect func(tk.Items, int)) tk.Widget {
+// Makes a column for the file. If scorer is non-nil, it is used instead of
+// filter, and files are ranked by their scores instead of sorted by names.
+// Files whose names are in marked are shown as marked.
+func makeColInner(f NavigationFile, filter func(string) bool, scorer func(string) (int, []diag.Ranging, bool), marked map[string]bool, showHidden bool, onSelect func(tk.Items, int)) tk.Widget {
 	files, content, err := f.Read()
 	if err != nil {
 		return makeErrCol(err)
@@ -302,20 +349,45 @@ func makeColInner(f NavigationFile, filter func(string) bool, showHidden bool, o
 
 	if files != nil {
 		var filtered []NavigationFile
+		var scores []int
 		for _, file := range files {
 			name := file.Name()
 			hidden := len(name) > 0 && name[0] == '.'
-			if filter(name) && (showHidden || !hidden) {
+			if !showHidden && hidden {
+				continue
+			}
+			if scorer != nil {
+				if score, rs, ok := scorer(name); ok {
+					filtered = append(filtered, highlightedFile{file, rs})
+					scores = append(scores, score)
+				}
+			} else if filter(name) {
 				filtered = append(filtered, file)
 			}
 		}
 		files = filtered
-		sort.Slice(files, func(i, j int) bool {
-			return files[i].Name() < files[j].Name()
-		})
+		if scorer != nil {
+			sort.Sort(filesByScore{files, scores})
+		} else {
+			sort.Slice(files, func(i, j int) bool {
+				return files[i].Name() < files[j].Name()
+			})
+		}
+		// Don't extend the style of the first and last segments into the
+		// padding when they may be highlighted.
+		items := fileItems(files)
+		var markedKeys map[string]bool
+		for i, file := range files {
+			if marked[file.Name()] {
+				if markedKeys == nil {
+					markedKeys = make(map[string]bool)
+				}
+				markedKeys[tk.ItemKey(items, i)] = true
+			}
+		}
 		return tk.NewListBox(tk.ListBoxSpec{
-			Padding: 1, ExtendStyle: true, OnSelect: onSelect,
-			State: tk.ListBoxState{Items: fileItems(files)},
+			Padding: 1, ExtendStyle: scorer == nil, OnSelect: onSelect,
+			State: tk.ListBoxState{Items: items, Marked: markedKeys},
 		})
 	}
 
@@ -330,6 +402,37 @@ func makeErrCol(err error) tk.Widget {
 	return tk.Label{Content: ui.T(err.Error(), ui.FgRed)}
 }
 
+// Sorts files by descending scores, breaking ties by names.
+type filesByScore struct {
+	files  []NavigationFile
+	scores []int
+}
+
+func (fs filesByScore) Len() int { return len(fs.files) }
+
+func (fs filesByScore) Less(i, j int) bool {
+	if fs.scores[i] != fs.scores[j] {
+		return fs.scores[i] > fs.scores[j]
+	}
+	return fs.files[i].Name() < fs.files[j].Name()
+}
+
+func (fs filesByScore) Swap(i, j int) {
+	fs.files[i], fs.files[j] = fs.files[j], fs.files[i]
+	fs.scores[i], fs.scores[j] = fs.scores[j], fs.scores[i]
+}
+
+// A NavigationFile with the parts of its name matched by the filter
+// highlighted.
+type highlightedFile struct {
+	NavigationFile
+	highlights []diag.Ranging
+}
+
+func (f highlightedFile) ShowName() ui.Text {
+	return highlightMatches(f.NavigationFile.ShowName(), 0, f.highlights)
+}
+
 type fileItems []NavigationFile
 
 func (it fileItems) Show(i int) ui.Text {
@@ -2,6 +2,7 @@ package modes
 
 import (
 	"errors"
+	"reflect"
 	"testing"
 
 	"src.elv.sh/pkg/cli"
@@ -361,6 +362,60 @@ func testNavigation(t *testing.T, c NavigationCursor) {
 	f.TTY.TestBuffer(t, d3NoneBuf)
 }
 
+func TestNavigation_Marking(t *testing.T) {
+	f := setupNav(t)
+	defer f.Stop()
+
+	w := startNavigation(f.App, NavigationSpec{Cursor: getTestCursor()})
+	w.ToggleMarked()
+	w.Select(tk.Next)
+	w.Select(tk.Next)
+	w.ToggleMarked()
+	f.TestTTY(t,
+		"", term.DotHere, "\n",
+		" NAVIGATING  \n", Styles,
+		"************ ",
+		" a    d1            \n", markedStyles,
+		"     ______________",
+		" d    d2           \n", markedStyles,
+		"#### //////////////",
+		" f    d3           ", markedStyles,
+		"     NNNNNNNNNNNNNN",
+	)
+	if names := w.MarkedNames(); !reflect.DeepEqual(names, []string{"d1", "d3"}) {
+		t.Errorf("MarkedNames() = %v, want [d1 d3]", names)
+	}
+
+	// Marks are cleared when descending.
+	w.Descend()
+	if names := w.MarkedNames(); names != nil {
+		t.Errorf("MarkedNames() = %v, want nil", names)
+	}
+}
+
+func TestNavigation_Scorer(t *testing.T) {
+	f := setupNav(t)
+	defer f.Stop()
+
+	cursor := &testCursor{
+		root: testutil.Dir{"d": testutil.Dir{"xab": "", "abx": "", "yy": ""}},
+		pwd:  []string{"d"}}
+	w := startNavigation(f.App, NavigationSpec{Cursor: cursor, Filter: scoringFilterSpec})
+	w.MutateFiltering(func(bool) bool { return true })
+	f.TTY.Inject(term.K('a'), term.K('b'))
+	// Files are sorted by the scores from the scorer instead of names, and the
+	// matched parts are highlighted.
+	f.TestTTY(t,
+		"\n",
+		" NAVIGATING  ab", scoringStyles,
+		"************   ", term.DotHere, "\n",
+		" d    abx           \n", scoringStyles,
+		"#### +YY+++++++++++",
+		"      xab", scoringStyles,
+		"       yy",
+	)
+}
+
 func TestNewNavigation_FocusedWidgetNotCodeArea(t *testing.T) {
 	testFocusedWidgetNotCodeArea(t, func(app cli.App) error {
 		_, err := NewNavigation(app, NavigationSpec{})
@@ -0,0 +1,159 @@
+package modes
+
+import (
+	"sync"
+	"time"
+
+	"src.elv.sh/pkg/cli"
+	"src.elv.sh/pkg/cli/term"
+	"src.elv.sh/pkg/cli/tk"
+	"src.elv.sh/pkg/ui"
+)
+
+// PreviewSpec specifies the configuration of the preview pane of a listing
+// mode.
+type PreviewSpec struct {
+	// Computes the preview of an item. It is called asynchronously, so it may
+	// take a long time without blocking the UI. If nil, no preview pane is
+	// shown.
+	Compute func(item string) ui.Text
+	// Threshold for a preview to be considered as stale. Defaults to 200ms.
+	StaleThreshold func() time.Duration
+	// Function to transform stale previews. Defaults to dimming the text.
+	StaleTransform func(ui.Text) ui.Text
+}
+
+func defaultPreviewStaleTransform(t ui.Text) ui.Text {
+	return ui.StyleText(t, ui.Dim)
+}
+
+const defaultPreviewStaleThreshold = 200 * time.Millisecond
+
+// A widget that shows the preview of the selected item, which is computed
+// asynchronously.
+//
+// When the selection changes, the preview of the previous item is kept until
+// the new one is ready; if that takes longer than the stale threshold, it is
+// shown with the stale transform applied. At most one computation is run at a
+// time; requests made while a computation is running are coalesced so that
+// only the latest one is run afterwards.
+//
+// All methods are safe to call on a nil *preview, which represents the absence
+// of a preview pane.
+type preview struct {
+	app  cli.App
+	spec PreviewSpec
+
+	mutex sync.Mutex
+	// Current content.
+	content ui.Text
+	// Sequence number of the latest request, and that of the request whose
+	// result is the current content. The content is outdated when they differ.
+	seq, shownSeq int
+	// When the latest request was made.
+	requested time.Time
+	// Whether a computation is running.
+	busy bool
+	// Whether there is a request waiting for the running computation to
+	// finish, and its item.
+	pending     bool
+	pendingItem string
+}
+
+func newPreview(app cli.App, spec PreviewSpec) *preview {
+	if spec.Compute == nil {
+		return nil
+	}
+	if spec.StaleThreshold == nil {
+		spec.StaleThreshold = func() time.Duration { return defaultPreviewStaleThreshold }
+	}
+	if spec.StaleTransform == nil {
+		spec.StaleTransform = defaultPreviewStaleTransform
+	}
+	return &preview{app: app, spec: spec}
+}
+
+// Returns the preview as a widget, or nil if there is no preview pane.
+func (p *preview) widget() tk.Widget {
+	if p == nil {
+		return nil
+	}
+	return p
+}
+
+// Requests the preview to be updated for the given item.
+func (p *preview) update(item string) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.seq++
+	p.requested = time.Now()
+	if p.busy {
+		p.pending, p.pendingItem = true, item
+	} else {
+		p.busy = true
+		go p.compute(item, p.seq)
+	}
+	threshold := p.spec.StaleThreshold()
+	time.AfterFunc(threshold, p.app.Redraw)
+}
+
+// Clears the preview, discarding the results of any outstanding request.
+func (p *preview) clear() {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.seq++
+	p.shownSeq = p.seq
+	p.content = nil
+	p.pending = false
+}
+
+func (p *preview) compute(item string, seq int) {
+	for {
+		content := p.spec.Compute(item)
+		p.mutex.Lock()
+		if seq == p.seq {
+			p.content, p.shownSeq = content, seq
+		}
+		if !p.pending {
+			p.busy = false
+			p.mutex.Unlock()
+			break
+		}
+		item, seq = p.pendingItem, p.seq
+		p.pending = false
+		p.mutex.Unlock()
+	}
+	p.app.Redraw()
+}
+
+func (p *preview) label() tk.Label {
+	p.mutex.Lock()
+	content := p.content
+	stale := p.shownSeq != p.seq &&
+		time.Since(p.requested) >= p.spec.StaleThreshold()
+	p.mutex.Unlock()
+	if stale {
+		content = p.spec.StaleTransform(content)
+	}
+	return tk.Label{Content: content}
+}
+
+func (p *preview) Render(width, height int) *term.Buffer {
+	return p.label().Render(width, height)
+}
+
+func (p *preview) MaxHeight(width, height int) int {
+	l := p.label()
+	if len(l.Content) == 0 {
+		return 0
+	}
+	return l.MaxHeight(width, height)
+}
+
+func (p *preview) Handle(term.Event) bool { return false }
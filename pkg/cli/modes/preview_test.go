@@ -0,0 +1,130 @@
+package modes
+
+import (
+	"testing"
+	"time"
+
+	. "src.elv.sh/pkg/cli/clitest"
+	"src.elv.sh/pkg/cli/term"
+	"src.elv.sh/pkg/cli/tk"
+	"src.elv.sh/pkg/ui"
+)
+
+var previewStyles = ui.RuneStylesheet{
+	'*': ui.Stylings(ui.Bold, ui.FgWhite, ui.BgMagenta),
+	'+': ui.Inverse,
+	'v': ui.FgGreen,
+	'#': ui.Stylings(ui.Inverse, ui.FgGreen),
+	'-': ui.Dim,
+}
+
+func previewOf(item string) ui.Text { return ui.T("preview of " + item) }
+
+func TestPreview_ShowsPreviewOfSelectedItem(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	startListing(f.App, ListingSpec{
+		GetItems: fooAndGreenBar,
+		Preview:  PreviewSpec{Compute: previewOf},
+	})
+	f.TestTTY(t,
+		"\n",
+		" LISTING  ", Styles,
+		"********* ", term.DotHere, "\n",
+		"foo                      preview of foo\n", previewStyles,
+		"++++++++++++++++++++++++",
+		"bar                     ", previewStyles,
+		"vvvvvvvvvvvvvvvvvvvvvvvv",
+	)
+
+	f.App.ActiveWidget().(Listing).ListBox().Select(tk.Next)
+	f.TestTTY(t,
+		"\n",
+		" LISTING  ", Styles,
+		"********* ", term.DotHere, "\n",
+		"foo                      preview of bar\n",
+		"bar                     ", previewStyles,
+		"########################",
+	)
+}
+
+func TestPreview_ShowsStalePreview(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	unblock := make(chan struct{})
+	startListing(f.App, ListingSpec{
+		GetItems: fooAndGreenBar,
+		Preview: PreviewSpec{
+			Compute: func(item string) ui.Text {
+				if item == "bar" {
+					<-unblock
+				}
+				return previewOf(item)
+			},
+			StaleThreshold: func() time.Duration { return 0 },
+		},
+	})
+	f.TestTTY(t,
+		"\n",
+		" LISTING  ", Styles,
+		"********* ", term.DotHere, "\n",
+		"foo                      preview of foo\n", previewStyles,
+		"++++++++++++++++++++++++",
+		"bar                     ", previewStyles,
+		"vvvvvvvvvvvvvvvvvvvvvvvv",
+	)
+
+	f.App.ActiveWidget().(Listing).ListBox().Select(tk.Next)
+	f.TestTTY(t,
+		"\n",
+		" LISTING  ", Styles,
+		"********* ", term.DotHere, "\n",
+		"foo                      preview of foo\n", previewStyles,
+		"                         --------------",
+		"bar                     ", previewStyles,
+		"########################",
+	)
+
+	close(unblock)
+	f.TestTTY(t,
+		"\n",
+		" LISTING  ", Styles,
+		"********* ", term.DotHere, "\n",
+		"foo                      preview of bar\n",
+		"bar                     ", previewStyles,
+		"########################",
+	)
+}
+
+func TestPreview_ClearedWhenNoItemMatches(t *testing.T) {
+	f := Setup()
+	defer f.Stop()
+
+	startListing(f.App, ListingSpec{
+		GetItems: func(q string) ([]ListingItem, int) {
+			if q != "" {
+				return nil, -1
+			}
+			return fooAndGreenBar(q)
+		},
+		Preview: PreviewSpec{Compute: previewOf},
+	})
+	f.TestTTY(t,
+		"\n",
+		" LISTING  ", Styles,
+		"********* ", term.DotHere, "\n",
+		"foo                      preview of foo\n", previewStyles,
+		"++++++++++++++++++++++++",
+		"bar                     ", previewStyles,
+		"vvvvvvvvvvvvvvvvvvvvvvvv",
+	)
+
+	f.TTY.Inject(term.K('x'))
+	f.TestTTY(t,
+		"\n",
+		" LISTING  x", Styles,
+		"*********  ", term.DotHere,
+	)
+}
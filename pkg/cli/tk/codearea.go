@@ -9,6 +9,7 @@ import (
 	"unicode/utf8"
 
 	"src.elv.sh/pkg/cli/term"
+	"src.elv.sh/pkg/diag"
 	"src.elv.sh/pkg/parse"
 	"src.elv.sh/pkg/ui"
 )
@@ -32,6 +33,9 @@ type CodeAreaSpec struct {
 	// found, such as errors and autofixes. If this function is not given, the
 	// Widget does not highlight the code nor show any tips.
 	Highlighter func(code string) (ui.Text, []ui.Text)
+	// A function that further highlights the code returned by Highlighter,
+	// depending on the position of the dot. Optional.
+	DotHighlighter func(styledCode ui.Text, code string, dot int) ui.Text
 	// Prompt callback.
 	Prompt func() ui.Text
 	// Right-prompt callback.
@@ -80,6 +84,10 @@ type PendingCode struct {
 	To int
 	// The content of the pending code.
 	Content string
+	// Parts of Content to highlight, such as matches of an incremental search,
+	// as byte ranges relative to Content. The ranges must be sorted and must
+	// not overlap.
+	Highlights []diag.Ranging
 }
 
 // ApplyPending applies pending code to the code buffer, and resets pending code.
@@ -120,6 +128,9 @@ func NewCodeArea(spec CodeAreaSpec) CodeArea {
 	if spec.Highlighter == nil {
 		spec.Highlighter = func(s string) (ui.Text, []ui.Text) { return ui.T(s), nil }
 	}
+	if spec.DotHighlighter == nil {
+		spec.DotHighlighter = func(t ui.Text, _ string, _ int) ui.Text { return t }
+	}
 	if spec.Prompt == nil {
 		spec.Prompt = func() ui.Text { return nil }
 	}
@@ -2,6 +2,7 @@ package tk
 
 import (
 	"src.elv.sh/pkg/cli/term"
+	"src.elv.sh/pkg/diag"
 	"src.elv.sh/pkg/ui"
 	"src.elv.sh/pkg/wcwidth"
 )
@@ -15,12 +16,16 @@ type view struct {
 	tips    []ui.Text
 }
 
-var stylingForPending = ui.Underlined
+var (
+	stylingForPending          = ui.Underlined
+	stylingForPendingHighlight = ui.Inverse
+)
 
 func getView(w *codeArea) *view {
 	s := w.CopyState()
 	code, pFrom, pTo := patchPending(s.Buffer, s.Pending)
 	styledCode, errors := w.Highlighter(code.Content)
+	styledCode = w.DotHighlighter(styledCode, code.Content, code.Dot)
 	if s.HideTips {
 		errors = nil
 	}
@@ -28,6 +33,9 @@ func getView(w *codeArea) *view {
 		// Apply stylingForPending to [pFrom, pTo)
 		parts := styledCode.Partition(pFrom, pTo)
 		pending := ui.StyleText(parts[1], stylingForPending)
+		if len(s.Pending.Highlights) > 0 {
+			pending = highlightRanges(pending, s.Pending.Highlights)
+		}
 		styledCode = ui.Concat(parts[0], pending, parts[2])
 	}
 
@@ -39,6 +47,19 @@ func getView(w *codeArea) *view {
 	return &view{w.Prompt(), rprompt, styledCode, code.Dot, errors}
 }
 
+// Applies stylingForPendingHighlight to the given ranges of t.
+func highlightRanges(t ui.Text, rs []diag.Ranging) ui.Text {
+	indices := make([]int, 0, 2*len(rs))
+	for _, r := range rs {
+		indices = append(indices, r.From, r.To)
+	}
+	parts := t.Partition(indices...)
+	for i := 1; i < len(parts); i += 2 {
+		parts[i] = ui.StyleText(parts[i], stylingForPendingHighlight)
+	}
+	return ui.Concat(parts...)
+}
+
 func patchPending(c CodeBuffer, p PendingCode) (CodeBuffer, int, int) {
 	if p.From > p.To || p.From < 0 || p.To > len(c.Content) {
 		// Invalid Pending.
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"src.elv.sh/pkg/cli/term"
+	"src.elv.sh/pkg/diag"
 	"src.elv.sh/pkg/tt"
 	"src.elv.sh/pkg/ui"
 )
@@ -89,6 +90,21 @@ var codeAreaRenderTests = []renderTest{
 		Width: 10, Height: 24,
 		Want: bb(10).WriteStringSGR("code", "1").SetDotHere(),
 	},
+	{
+		Name: "code highlighted depending on the dot",
+		Given: NewCodeArea(CodeAreaSpec{
+			Highlighter: func(code string) (ui.Text, []ui.Text) {
+				return ui.T(code, ui.Bold), nil
+			},
+			DotHighlighter: func(t ui.Text, code string, dot int) ui.Text {
+				parts := t.Partition(dot, dot+1)
+				return ui.Concat(parts[0], ui.StyleText(parts[1], ui.Underlined), parts[2])
+			},
+			State: CodeAreaState{Buffer: CodeBuffer{Content: "code", Dot: 1}}}),
+		Width: 10, Height: 24,
+		Want: bb(10).WriteStringSGR("c", "1").
+			SetDotHere().WriteStringSGR("o", "1;4").WriteStringSGR("de", "1"),
+	},
 	{
 		Name: "tips",
 		Given: NewCodeArea(CodeAreaSpec{
@@ -150,6 +166,17 @@ var codeAreaRenderTests = []renderTest{
 		Want: bb(10).Write("c").SetDotHere().Write("o").
 			WriteStringSGR("x", "4").Write("e"),
 	},
+	{
+		Name: "pending code with highlights",
+		Given: NewCodeArea(CodeAreaSpec{State: CodeAreaState{
+			Buffer: CodeBuffer{Content: "", Dot: 0},
+			Pending: PendingCode{From: 0, To: 0, Content: "code",
+				Highlights: []diag.Ranging{{From: 1, To: 3}}},
+		}}),
+		Width: 10, Height: 24,
+		Want: bb(10).WriteStringSGR("c", "4").WriteStringSGR("od", "4;7").
+			WriteStringSGR("e", "4").SetDotHere(),
+	},
 	{
 		Name: "ignore invalid pending code 1",
 		Given: NewCodeArea(CodeAreaSpec{State: CodeAreaState{
@@ -507,9 +534,9 @@ func TestCodeAreaState_ApplyPending(t *testing.T) {
 		return s
 	}
 	tt.Test(t, applyPending,
-		Args(CodeAreaState{Buffer: CodeBuffer{}, Pending: PendingCode{0, 0, "ls"}}).
+		Args(CodeAreaState{Buffer: CodeBuffer{}, Pending: PendingCode{From: 0, To: 0, Content: "ls"}}).
 			Rets(CodeAreaState{Buffer: CodeBuffer{Content: "ls", Dot: 2}, Pending: PendingCode{}}),
-		Args(CodeAreaState{Buffer: CodeBuffer{"x", 1}, Pending: PendingCode{0, 0, "ls"}}).
+		Args(CodeAreaState{Buffer: CodeBuffer{"x", 1}, Pending: PendingCode{From: 0, To: 0, Content: "ls"}}).
 			Rets(CodeAreaState{Buffer: CodeBuffer{Content: "lsx", Dot: 3}, Pending: PendingCode{}}),
 		// No-op when Pending is empty.
 		Args(CodeAreaState{Buffer: CodeBuffer{"x", 1}}).
@@ -20,6 +20,10 @@ type ComboBoxSpec struct {
 	CodeArea CodeAreaSpec
 	ListBox  ListBoxSpec
 	OnFilter func(ComboBox, string)
+	// If not nil, a widget shown to the right of the listbox, typically used
+	// for previewing the selected item. The listbox and the preview share the
+	// width equally. The preview never receives events.
+	Preview Widget
 }
 
 type comboBox struct {
@@ -27,6 +31,10 @@ type comboBox struct {
 	listBox  ListBox
 	OnFilter func(ComboBox, string)
 
+	// The widget rendered below the codearea; either the listbox itself, or a
+	// ColView containing the listbox and the preview.
+	listArea Widget
+
 	// Last filter value.
 	lastFilter string
 }
@@ -41,20 +49,26 @@ func NewComboBox(spec ComboBoxSpec) ComboBox {
 		listBox:  NewListBox(spec.ListBox),
 		OnFilter: spec.OnFilter,
 	}
+	w.listArea = w.listBox
+	if spec.Preview != nil {
+		w.listArea = NewColView(ColViewSpec{
+			State: ColViewState{Columns: []Widget{w.listBox, spec.Preview}}})
+	}
 	w.OnFilter(w, "")
 	return w
 }
 
-// Render renders the codearea and the listbox below it.
+// Render renders the codearea and the listbox below it, with the preview (if
+// any) to the right of the listbox.
 func (w *comboBox) Render(width, height int) *term.Buffer {
 	buf := w.codeArea.Render(width, height)
-	bufListBox := w.listBox.Render(width, height-len(buf.Lines))
-	buf.Extend(bufListBox, false)
+	bufListArea := w.listArea.Render(width, height-len(buf.Lines))
+	buf.Extend(bufListArea, false)
 	return buf
 }
 
 func (w *comboBox) MaxHeight(width, height int) int {
-	return w.codeArea.MaxHeight(width, height) + w.listBox.MaxHeight(width, height)
+	return w.codeArea.MaxHeight(width, height) + w.listArea.MaxHeight(width, height)
 }
 
 // Handle first lets the listbox handle the event, and if it is unhandled, lets
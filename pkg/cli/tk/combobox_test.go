@@ -38,6 +38,23 @@ var comboBoxRenderTests = []renderTest{
 			Newline().Write("item 0    ", ui.Inverse).
 			Newline().Write("item 1"),
 	},
+	{
+		Name: "rendering preview to the right of listbox",
+		Given: NewComboBox(ComboBoxSpec{
+			CodeArea: CodeAreaSpec{
+				State: CodeAreaState{
+					Buffer: CodeBuffer{Content: "filter", Dot: 6}}},
+			ListBox: ListBoxSpec{
+				State: ListBoxState{Items: TestItems{NItems: 2}}},
+			Preview: Label{ui.T("preview\nof item", ui.FgBlue)}}),
+		Width: 21, Height: 24,
+		Want: term.NewBufferBuilder(21).
+			Write("filter").SetDotHere().
+			Newline().Write("item 0    ", ui.Inverse).
+			Write(" ").Write("preview", ui.FgBlue).
+			Newline().Write("item 1    ").
+			Write(" ").Write("of item", ui.FgBlue),
+	},
 }
 
 func TestComboBox_Render(t *testing.T) {
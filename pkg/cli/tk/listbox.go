@@ -15,14 +15,21 @@ type ListBox interface {
 	CopyState() ListBoxState
 	// Reset resets the state of the widget with the given items and index of
 	// the selected item. It triggers the OnSelect callback if the index is
-	// valid.
+	// valid. Marks are kept, and apply to the new items by their keys.
 	Reset(it Items, selected int)
 	// Select changes the selection by calling f with the current state, and
 	// using the return value as the new selection index. It triggers the
 	// OnSelect callback if the selected index has changed and is valid.
 	Select(f func(ListBoxState) int)
-	// Accept accepts the currently selected item.
+	// Accept accepts the currently selected item, or all marked items among
+	// the current items if there are any and OnAcceptMarked is set.
 	Accept()
+	// ToggleMarked toggles whether the currently selected item is marked. It
+	// does nothing if OnAcceptMarked is not set.
+	ToggleMarked()
+	// ToggleMarkedAll marks all current items, or unmarks them if all of them
+	// are already marked. It does nothing if OnAcceptMarked is not set.
+	ToggleMarkedAll()
 }
 
 // ListBoxSpec specifies the configuration and initial state for ListBox.
@@ -35,6 +42,10 @@ type ListBoxSpec struct {
 	OnSelect func(it Items, i int)
 	// A function called on the accept event.
 	OnAccept func(it Items, i int)
+	// A function called on the accept event when some items are marked,
+	// instead of OnAccept, with the indices of marked items in ascending
+	// order. If nil, items can't be marked.
+	OnAcceptMarked func(it Items, marked []int)
 	// Whether the listbox should be rendered in a horizontal layout. Note that
 	// in the horizontal layout, items must have only one line.
 	Horizontal bool
@@ -76,7 +87,10 @@ func NewListBox(spec ListBoxSpec) ListBox {
 	return &listBox{ListBoxSpec: spec}
 }
 
-var stylingForSelected = ui.Inverse
+var (
+	stylingForSelected = ui.Inverse
+	stylingForMarked   = ui.Underlined
+)
 
 func (w *listBox) Render(width, height int) *term.Buffer {
 	if w.Horizontal {
@@ -137,6 +151,7 @@ func (w *listBox) renderHorizontal(width, height int) *term.Buffer {
 		selectedRow := -1
 		// Render the column starting from i.
 		col := make([]ui.Text, 0, colHeight)
+		marked := make([]bool, 0, colHeight)
 		for j := i; j < i+colHeight && j < n; j++ {
 			last = j
 			item := items.Show(j)
@@ -144,6 +159,7 @@ func (w *listBox) renderHorizontal(width, height int) *term.Buffer {
 				selectedRow = j - i
 			}
 			col = append(col, item)
+			marked = append(marked, state.IsMarked(j))
 		}
 
 		colWidth := maxWidth(items, w.Padding, i, i+colHeight)
@@ -153,7 +169,7 @@ func (w *listBox) renderHorizontal(width, height int) *term.Buffer {
 		}
 
 		colBuf := croppedLines{
-			lines: col, padding: w.Padding,
+			lines: col, marked: marked, padding: w.Padding,
 			selectFrom: selectedRow, selectTo: selectedRow + 1,
 			extendStyle: w.ExtendStyle}.Render(colWidth, colHeight)
 		buf.ExtendRight(colBuf)
@@ -194,6 +210,7 @@ func (w *listBox) renderVertical(width, height int) *term.Buffer {
 	items, selected, first := state.Items, state.Selected, state.First
 	n := items.Len()
 	allLines := []ui.Text{}
+	var marked []bool
 	hasCropped := firstCrop > 0
 
 	var i, selectFrom, selectTo int
@@ -214,10 +231,13 @@ func (w *listBox) renderVertical(width, height int) *term.Buffer {
 			hasCropped = true
 		}
 		allLines = append(allLines, lines...)
+		for range lines {
+			marked = append(marked, state.IsMarked(i))
+		}
 	}
 
 	var rd Renderer = croppedLines{
-		lines: allLines, padding: w.Padding,
+		lines: allLines, marked: marked, padding: w.Padding,
 		selectFrom: selectFrom, selectTo: selectTo, extendStyle: w.ExtendStyle}
 	if first > 0 || i < n || hasCropped {
 		rd = VScrollbarContainer{
@@ -229,7 +249,9 @@ func (w *listBox) renderVertical(width, height int) *term.Buffer {
 }
 
 type croppedLines struct {
-	lines       []ui.Text
+	lines []ui.Text
+	// Whether each line belongs to a marked item. May be shorter than lines.
+	marked      []bool
 	padding     int
 	selectFrom  int
 	selectTo    int
@@ -248,6 +270,10 @@ func (c croppedLines) Render(width, height int) *term.Buffer {
 		selected := c.selectFrom <= i && i < c.selectTo
 		extendStyle := c.extendStyle && len(line) > 0
 
+		if i < len(c.marked) && c.marked[i] {
+			line = ui.StyleText(line, stylingForMarked)
+		}
+
 		left := leftSpacing.Clone()
 		if extendStyle && len(left) > 0 {
 			left[0].Style = line[0].Style
@@ -295,7 +321,9 @@ func (w *listBox) CopyState() ListBoxState {
 }
 
 func (w *listBox) Reset(it Items, selected int) {
-	w.mutate(func(s *ListBoxState) { *s = ListBoxState{Items: it, Selected: selected} })
+	w.mutate(func(s *ListBoxState) {
+		*s = ListBoxState{Items: it, Selected: selected, Marked: s.Marked}
+	})
 	if 0 <= selected && selected < it.Len() {
 		w.OnSelect(it, selected)
 	}
@@ -410,11 +438,75 @@ func fixIndex(i, n int) int {
 
 func (w *listBox) Accept() {
 	state := w.CopyState()
+	if w.OnAcceptMarked != nil {
+		if marked := state.MarkedIndices(); len(marked) > 0 {
+			w.OnAcceptMarked(state.Items, marked)
+			return
+		}
+	}
 	if 0 <= state.Selected && state.Selected < state.Items.Len() {
 		w.OnAccept(state.Items, state.Selected)
 	}
 }
 
+func (w *listBox) ToggleMarked() {
+	if w.OnAcceptMarked == nil {
+		return
+	}
+	w.mutate(func(s *ListBoxState) {
+		if s.Items == nil || s.Selected < 0 || s.Selected >= s.Items.Len() {
+			return
+		}
+		key := ItemKey(s.Items, s.Selected)
+		s.Marked = copyMarked(s.Marked, func(marked map[string]bool) {
+			if marked[key] {
+				delete(marked, key)
+			} else {
+				marked[key] = true
+			}
+		})
+	})
+}
+
+func (w *listBox) ToggleMarkedAll() {
+	if w.OnAcceptMarked == nil {
+		return
+	}
+	w.mutate(func(s *ListBoxState) {
+		if s.Items == nil {
+			return
+		}
+		n := s.Items.Len()
+		// Only the current items are affected; marks on items that are not
+		// shown, for example because of the filter, are kept.
+		mark := len(s.MarkedIndices()) < n
+		s.Marked = copyMarked(s.Marked, func(marked map[string]bool) {
+			for i := 0; i < n; i++ {
+				if mark {
+					marked[ItemKey(s.Items, i)] = true
+				} else {
+					delete(marked, ItemKey(s.Items, i))
+				}
+			}
+		})
+	})
+}
+
+// Returns a copy of marked modified by f, or nil if the result is empty.
+func copyMarked(marked map[string]bool, f func(map[string]bool)) map[string]bool {
+	newMarked := make(map[string]bool, len(marked))
+	for key, m := range marked {
+		if m {
+			newMarked[key] = true
+		}
+	}
+	f(newMarked)
+	if len(newMarked) == 0 {
+		return nil
+	}
+	return newMarked
+}
+
 func (w *listBox) mutate(f func(s *ListBoxState)) {
 	w.StateMutex.Lock()
 	defer w.StateMutex.Unlock()
@@ -2,6 +2,7 @@ package tk
 
 import (
 	"fmt"
+	"strings"
 
 	"src.elv.sh/pkg/ui"
 )
@@ -17,6 +18,42 @@ type ListBoxState struct {
 	// horizontal layout. Stored in the state for commands to move the cursor by
 	// page (for vertical layout) or column (for horizontal layout).
 	ContentHeight int
+	// Marked items, keyed by their text as returned by ItemKey. Marked items
+	// can be accepted together. Since the keys don't depend on the position of
+	// items, marks survive when the items are replaced, for example when the
+	// filter changes. A nil map means that no items are marked; the map must
+	// not be mutated in place, since it may be shared with copies of the state.
+	Marked map[string]bool
+}
+
+// IsMarked returns whether the item at the given index is marked.
+func (s ListBoxState) IsMarked(i int) bool {
+	return s.Marked != nil && s.Marked[ItemKey(s.Items, i)]
+}
+
+// MarkedIndices returns the indices of marked items among the current items,
+// in ascending order.
+func (s ListBoxState) MarkedIndices() []int {
+	if s.Items == nil || len(s.Marked) == 0 {
+		return nil
+	}
+	var indices []int
+	for i := 0; i < s.Items.Len(); i++ {
+		if s.IsMarked(i) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// ItemKey returns the key identifying the item at the given index, which is
+// its text without styles. Items with the same text are marked together.
+func ItemKey(it Items, i int) string {
+	var sb strings.Builder
+	for _, seg := range it.Show(i) {
+		sb.WriteString(seg.Text)
+	}
+	return sb.String()
 }
 
 // Items is an interface for accessing multiple items.
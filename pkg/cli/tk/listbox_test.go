@@ -1,6 +1,7 @@
 package tk
 
 import (
+	"reflect"
 	"testing"
 
 	"src.elv.sh/pkg/cli/term"
@@ -30,6 +31,18 @@ var listBoxRenderVerticalTests = []renderTest{
 			Write("item 0    ", ui.Inverse).
 			Newline().Write("item 1"),
 	},
+	{
+		Name: "marked items",
+		Given: NewListBox(ListBoxSpec{State: ListBoxState{
+			Items: TestItems{NItems: 3}, Selected: 0,
+			Marked: map[string]bool{"item 0": true, "item 2": true}}}),
+		Width: 10, Height: 3,
+		Want: bb(10).
+			Write("item 0", ui.Underlined, ui.Inverse).
+			Write("    ", ui.Inverse).
+			Newline().Write("item 1").
+			Newline().Write("item 2", ui.Underlined),
+	},
 	{
 		Name:  "long lines cropped",
 		Given: NewListBox(ListBoxSpec{State: ListBoxState{Items: TestItems{NItems: 2}, Selected: 0}}),
@@ -157,6 +170,19 @@ var listBoxRenderHorizontalTests = []renderTest{
 		Width: 10, Height: 3,
 		Want: bb(10).Write("nothing"),
 	},
+	{
+		Name: "marked items",
+		Given: NewListBox(ListBoxSpec{
+			Horizontal: true,
+			State: ListBoxState{
+				Items: TestItems{NItems: 2}, Selected: 0,
+				Marked: map[string]bool{"item 1": true}}}),
+		Width: 14, Height: 3,
+		Want: bb(14).
+			Write("item 0", ui.Inverse).
+			Write("  ").
+			Write("item 1", ui.Underlined),
+	},
 	{
 		Name: "all items when there is enough space, using minimal height",
 		Given: NewListBox(ListBoxSpec{
@@ -482,3 +508,75 @@ func TestListBox_Accept_IndexCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestListBox_Marking(t *testing.T) {
+	var accepted []int
+	w := NewListBox(ListBoxSpec{
+		OnAccept: func(it Items, i int) { accepted = []int{i} },
+		OnAcceptMarked: func(it Items, marked []int) {
+			accepted = marked
+		},
+		State: ListBoxState{Items: TestItems{NItems: 4}, Selected: 1}})
+
+	testMarked := func(want ...int) {
+		t.Helper()
+		if got := w.CopyState().MarkedIndices(); !reflect.DeepEqual(got, want) {
+			t.Errorf("marked = %v, want %v", got, want)
+		}
+	}
+	testAccepted := func(want ...int) {
+		t.Helper()
+		accepted = nil
+		w.Accept()
+		if !reflect.DeepEqual(accepted, want) {
+			t.Errorf("accepted = %v, want %v", accepted, want)
+		}
+	}
+
+	// Without marks, the selected item is accepted.
+	testAccepted(1)
+
+	w.ToggleMarked()
+	w.Select(Next)
+	w.Select(Next)
+	w.ToggleMarked()
+	testMarked(1, 3)
+	testAccepted(1, 3)
+
+	// Toggling again unmarks.
+	w.ToggleMarked()
+	testMarked(1)
+
+	// Marks all, and then unmarks all since all items are marked.
+	w.ToggleMarkedAll()
+	testMarked(0, 1, 2, 3)
+	w.ToggleMarkedAll()
+	testMarked()
+	testAccepted(3)
+
+	// Marks are kept across Reset, and apply to items with the same text.
+	w.ToggleMarked()
+	w.Reset(TestItems{NItems: 2}, 0)
+	testMarked()
+	w.Reset(TestItems{NItems: 5}, 0)
+	testMarked(3)
+
+	// ToggleMarkedAll only affects the current items.
+	w.Reset(TestItems{NItems: 2}, 0)
+	w.ToggleMarkedAll()
+	testMarked(0, 1)
+	w.ToggleMarkedAll()
+	testMarked()
+	w.Reset(TestItems{NItems: 4}, 0)
+	testMarked(3)
+}
+
+func TestListBox_Marking_NotSupported(t *testing.T) {
+	w := NewListBox(ListBoxSpec{
+		State: ListBoxState{Items: TestItems{NItems: 4}, Selected: 1}})
+	w.ToggleMarked()
+	w.ToggleMarkedAll()
+	if marked := w.CopyState().Marked; marked != nil {
+		t.Errorf("items marked without OnAcceptMarked: %v", marked)
+	}
+}
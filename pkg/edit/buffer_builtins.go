@@ -7,6 +7,7 @@ import (
 
 	"src.elv.sh/pkg/cli"
 	"src.elv.sh/pkg/cli/tk"
+	"src.elv.sh/pkg/edit/highlight"
 	"src.elv.sh/pkg/eval"
 	"src.elv.sh/pkg/strutil"
 	"src.elv.sh/pkg/wcwidth"
@@ -45,6 +46,8 @@ var bufferBuiltinsData = map[string]func(*tk.CodeBuffer){
 	"move-dot-up":   makeMove(moveDotUp),
 	"move-dot-down": makeMove(moveDotDown),
 
+	"jump-to-matching-bracket": makeMove(moveDotToMatchingBracket),
+
 	"kill-rune-left":        makeKill(moveDotLeft),
 	"kill-rune-right":       makeKill(moveDotRight),
 	"kill-word-left":        makeKill(moveDotLeftWord),
@@ -100,6 +103,23 @@ func makeTransform(t pureTransformer) func(*tk.CodeBuffer) {
 
 // Implementation of pure movers.
 
+func moveDotToMatchingBracket(buffer string, dot int) int {
+	pair, ok := highlight.MatchingBrackets(buffer, dot)
+	// The bracket under the dot takes precedence over the one before it.
+	switch {
+	case !ok:
+		return dot
+	case pair.Open == dot:
+		return pair.Close
+	case pair.Close == dot:
+		return pair.Open
+	case pair.Open == dot-1:
+		return pair.Close
+	default:
+		return pair.Open
+	}
+}
+
 func moveDotLeft(buffer string, dot int) int {
 	_, w := utf8.DecodeLastRuneInString(buffer[:dot])
 	return dot - w
@@ -2,16 +2,19 @@ package edit
 
 import (
 	"errors"
+	"strings"
 
 	"src.elv.sh/pkg/cli"
 	"src.elv.sh/pkg/cli/modes"
 	"src.elv.sh/pkg/cli/term"
 	"src.elv.sh/pkg/cli/tk"
+	"src.elv.sh/pkg/edit/highlight"
 	"src.elv.sh/pkg/eval"
 	"src.elv.sh/pkg/eval/errs"
 	"src.elv.sh/pkg/eval/vals"
 	"src.elv.sh/pkg/parse"
 	"src.elv.sh/pkg/parse/parseutil"
+	"src.elv.sh/pkg/strutil"
 	"src.elv.sh/pkg/ui"
 )
 
@@ -104,7 +107,7 @@ func smartEnter(ed *Editor) {
 	codeArea.MutateState(func(s *tk.CodeAreaState) {
 		buf := &s.Buffer
 		if !isSyntaxComplete(buf.Content) {
-			buf.InsertAtDot("\n")
+			buf.InsertAtDot("\n" + autoIndent(buf.Content, buf.Dot))
 			insertedNewline = true
 		}
 	})
@@ -130,6 +133,22 @@ func isSyntaxComplete(code string) bool {
 	return true
 }
 
+// Unit of indentation used by autoIndent.
+const indentUnit = "  "
+
+// Returns the indentation for a new line inserted at the dot. If the dot is
+// inside a pair of brackets, the new line is indented one level deeper than the
+// line of the opening bracket; otherwise it is not indented.
+func autoIndent(code string, dot int) string {
+	open, ok := highlight.EnclosingBracket(code, dot)
+	if !ok {
+		return ""
+	}
+	line := code[strutil.FindLastSOL(code[:open]):]
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	return indent + indentUnit
+}
+
 func wordify(fm *eval.Frame, code string) error {
 	out := fm.ValueOutput()
 	for _, s := range parseutil.Wordify(code) {
@@ -153,14 +153,60 @@ func TestReturnEOF(t *testing.T) {
 func TestSmartEnter_InsertsNewlineWhenIncomplete(t *testing.T) {
 	f := setup(t)
 
-	f.SetCodeBuffer(tk.CodeBuffer{Content: "put [", Dot: 5})
+	f.SetCodeBuffer(tk.CodeBuffer{Content: "put '", Dot: 5})
 	evals(f.Evaler, `edit:smart-enter`)
-	wantBuf := tk.CodeBuffer{Content: "put [\n", Dot: 6}
+	wantBuf := tk.CodeBuffer{Content: "put '\n", Dot: 6}
 	if buf := codeArea(f.Editor.app).CopyState().Buffer; buf != wantBuf {
 		t.Errorf("got code buffer %v, want %v", buf, wantBuf)
 	}
 }
 
+var smartEnterIndentTests = []struct {
+	name      string
+	bufBefore tk.CodeBuffer
+	bufAfter  tk.CodeBuffer
+}{
+	{
+		"unclosed bracket",
+		tk.CodeBuffer{Content: "put [", Dot: 5},
+		tk.CodeBuffer{Content: "put [\n  ", Dot: 8},
+	},
+	{
+		"nested unclosed brackets",
+		tk.CodeBuffer{Content: "if $true {\n  put (", Dot: 18},
+		tk.CodeBuffer{Content: "if $true {\n  put (\n    ", Dot: 23},
+	},
+	{
+		"innermost bracket is closed",
+		tk.CodeBuffer{Content: "each {|x|\n  put [$x]", Dot: 19},
+		tk.CodeBuffer{Content: "each {|x|\n  put [$x\n    ]", Dot: 24},
+	},
+	{
+		"after closed bracket",
+		tk.CodeBuffer{Content: "fn f {\n  put [a]", Dot: 16},
+		tk.CodeBuffer{Content: "fn f {\n  put [a]\n  ", Dot: 19},
+	},
+	{
+		"brackets in strings are ignored",
+		tk.CodeBuffer{Content: "{ echo '['", Dot: 10},
+		tk.CodeBuffer{Content: "{ echo '['\n  ", Dot: 13},
+	},
+}
+
+func TestSmartEnter_AutoIndentsInUnclosedBrackets(t *testing.T) {
+	for _, test := range smartEnterIndentTests {
+		t.Run(test.name, func(t *testing.T) {
+			f := setup(t)
+
+			f.SetCodeBuffer(test.bufBefore)
+			evals(f.Evaler, `edit:smart-enter`)
+			if buf := codeArea(f.Editor.app).CopyState().Buffer; buf != test.bufAfter {
+				t.Errorf("got code buffer %v, want %v", buf, test.bufAfter)
+			}
+		})
+	}
+}
+
 func TestSmartEnter_AcceptsCodeWhenWholeBufferIsComplete(t *testing.T) {
 	f := setup(t)
 
@@ -189,6 +235,11 @@ var bufferBuiltinsTests = []struct {
 		tk.CodeBuffer{Content: "ab", Dot: 1},
 		tk.CodeBuffer{Content: "ab", Dot: 2},
 	},
+	{
+		"jump-to-matching-bracket",
+		tk.CodeBuffer{Content: "put [a]", Dot: 4},
+		tk.CodeBuffer{Content: "put [a]", Dot: 6},
+	},
 	{
 		"kill-rune-left",
 		tk.CodeBuffer{Content: "ab", Dot: 1},
@@ -364,6 +415,30 @@ func TestMoveDotSOLEOL(t *testing.T) {
 	)
 }
 
+func TestMoveDotToMatchingBracket(t *testing.T) {
+	buffer := "put [{|x| (a)}] ?(b) '('"
+	// Index:
+	//         0123456789012345678901234
+	tt.Test(t, moveDotToMatchingBracket,
+		// Under the dot.
+		Args(buffer, 4).Rets(14),
+		Args(buffer, 14).Rets(4),
+		Args(buffer, 5).Rets(13),
+		Args(buffer, 10).Rets(12),
+		Args(buffer, 17).Rets(19),
+		// Just before the dot.
+		Args(buffer, 15).Rets(4),
+		Args(buffer, 11).Rets(12),
+		// The bracket under the dot takes precedence.
+		Args(buffer, 13).Rets(5),
+		// No bracket.
+		Args(buffer, 0).Rets(0),
+		Args(buffer, 8).Rets(8),
+		// Brackets in strings are not syntactical.
+		Args(buffer, 23).Rets(23),
+	)
+}
+
 func TestMoveDotUpDown(t *testing.T) {
 	buffer := "abc\n精灵语\ndef"
 	// Index:
@@ -77,6 +77,10 @@ func Complete(code CodeBuffer, ev *eval.Evaler, cfg Config) (*Result, error) {
 		}
 		rawItems = cfg.Filterer(ctx.name, ctx.seed, rawItems)
 		sort.Slice(rawItems, func(i, j int) bool {
+			si, sj := scoreOf(rawItems[i]), scoreOf(rawItems[j])
+			if si != sj {
+				return si > sj
+			}
 			return rawItems[i].String() < rawItems[j].String()
 		})
 		items := make([]modes.CompletionItem, len(rawItems))
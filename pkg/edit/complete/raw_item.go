@@ -48,3 +48,18 @@ func (c ComplexItem) Cook(q parse.PrimaryType) modes.CompletionItem {
 		ToShow:   display,
 	}
 }
+
+// ScoredItem wraps a RawItem with a score assigned by a Filterer. Items with
+// higher scores are sorted before items with lower scores; items that are not
+// ScoredItem have a score of 0.
+type ScoredItem struct {
+	RawItem
+	Score int
+}
+
+func scoreOf(item RawItem) int {
+	if scored, ok := item.(ScoredItem); ok {
+		return scored.Score
+	}
+	return 0
+}
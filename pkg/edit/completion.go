@@ -12,6 +12,7 @@ import (
 	"src.elv.sh/pkg/cli/modes"
 	"src.elv.sh/pkg/cli/tk"
 	"src.elv.sh/pkg/edit/complete"
+	"src.elv.sh/pkg/edit/filter"
 	"src.elv.sh/pkg/eval"
 	"src.elv.sh/pkg/eval/errs"
 	"src.elv.sh/pkg/eval/vals"
@@ -93,9 +94,11 @@ func completionStart(ed *Editor, bindings tk.Bindings, ev *eval.Evaler, cfg comp
 			}
 		}
 	}
+	preview, _ := getVar(ed.ns, "completion:preview").(eval.Callable)
 	w, err := modes.NewCompletion(ed.app, modes.CompletionSpec{
 		Name: result.Name, Replace: result.Replace, Items: result.Items,
-		Filter: filterSpec, Bindings: bindings,
+		Filter: filterSpec(ed), Bindings: bindings,
+		Preview: previewSpec(ed, ev, preview),
 	})
 	if w != nil {
 		ed.app.PushAddon(w)
@@ -126,6 +129,7 @@ func initCompletion(ed *Editor, ev *eval.Evaler, nb eval.NsBuilder) {
 		"complete-getopt":   completeGetopt,
 		"complete-sudo":     wrapArgGenerator(generateForSudo),
 		"complex-candidate": complexCandidate,
+		"match-fuzzy":       matchFuzzy,
 		"match-prefix":      wrapMatcher(strings.HasPrefix),
 		"match-subseq":      wrapMatcher(strutil.HasSubseq),
 		"match-substr":      wrapMatcher(strings.Contains),
@@ -137,6 +141,7 @@ func initCompletion(ed *Editor, ev *eval.Evaler, nb eval.NsBuilder) {
 				"arg-completer": argGeneratorMapVar,
 				"binding":       bindingVar,
 				"matcher":       matcherMapVar,
+				"preview":       newFnVar(nil),
 			}).
 			AddGoFns(map[string]any{
 				"accept":      func() { listingAccept(app) },
@@ -148,6 +153,9 @@ func initCompletion(ed *Editor, ev *eval.Evaler, nb eval.NsBuilder) {
 				"down-cycle":  func() { listingDownCycle(app) },
 				"left":        func() { listingLeft(app) },
 				"right":       func() { listingRight(app) },
+
+				"toggle-mark":     func() { listingToggleMark(app) },
+				"toggle-mark-all": func() { listingToggleMarkAll(app) },
 			}))
 }
 
@@ -283,6 +291,31 @@ func wrapMatcher(m matcher) wrappedMatcher {
 	}
 }
 
+// Implements edit:match-fuzzy. It is not implemented with wrapMatcher since it
+// outputs scores instead of booleans, and is smart-case by default.
+func matchFuzzy(fm *eval.Frame, opts matcherOpts, seed string, inputs eval.Inputs) error {
+	out := fm.ValueOutput()
+	if opts.IgnoreCase {
+		seed = strings.ToLower(seed)
+	}
+	var errOut error
+	inputs(func(v any) {
+		if errOut != nil {
+			return
+		}
+		s := vals.ToString(v)
+		if opts.IgnoreCase {
+			s = strings.ToLower(s)
+		}
+		if score, _, ok := filter.FuzzyScore(seed, s); ok {
+			errOut = out.Put(score)
+		} else {
+			errOut = out.Put(false)
+		}
+	})
+	return errOut
+}
+
 // Adapts $edit:completion:matcher into a Filterer.
 func adaptMatcherMap(nt notifier, ev *eval.Evaler, m vals.Map) complete.Filterer {
 	return func(ctxName, seed string, rawItems []complete.RawItem) []complete.RawItem {
@@ -334,8 +367,15 @@ func adaptMatcherMap(nt notifier, ev *eval.Evaler, m vals.Map) complete.Filterer
 		}
 		filtered := []complete.RawItem{}
 		for i := 0; i < len(rawItems) && i < len(outputs); i++ {
-			if vals.Bool(outputs[i]) {
-				filtered = append(filtered, rawItems[i])
+			switch output := outputs[i].(type) {
+			case int:
+				filtered = append(filtered, complete.ScoredItem{RawItem: rawItems[i], Score: output})
+			case float64:
+				filtered = append(filtered, complete.ScoredItem{RawItem: rawItems[i], Score: int(output)})
+			default:
+				if vals.Bool(output) {
+					filtered = append(filtered, rawItems[i])
+				}
 			}
 		}
 		return filtered
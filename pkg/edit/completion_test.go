@@ -172,6 +172,23 @@ func TestCompletionMatcher(t *testing.T) {
 	)
 }
 
+func TestCompletionMatcher_Scores(t *testing.T) {
+	f := setup(t)
+
+	testutil.ApplyDir(testutil.Dir{"xfb": "", "foo-bar": "", "fb": "", "bf": ""})
+
+	evals(f.Evaler, `set edit:completion:matcher[''] = $edit:match-fuzzy~`)
+	feedInput(f.TTYCtrl, "echo fb\t")
+	f.TestTTY(t,
+		"~> echo fb \n", Styles,
+		"   vvvv ___",
+		" COMPLETING argument  ", Styles,
+		"********************* ", term.DotHere, "\n",
+		"fb  foo-bar  xfb", Styles,
+		"++              ",
+	)
+}
+
 func TestBuiltinMatchers(t *testing.T) {
 	f := setup(t)
 
@@ -179,14 +196,23 @@ func TestBuiltinMatchers(t *testing.T) {
 		`var @prefix = (edit:match-prefix ab [ab abc cab acb ba [ab] [a b] [b a]])`,
 		`var @substr = (edit:match-substr ab [ab abc cab acb ba [ab] [a b] [b a]])`,
 		`var @subseq = (edit:match-subseq ab [ab abc cab acb ba [ab] [a b] [b a]])`,
+		`var @fuzzy = (edit:match-fuzzy ab [ab cab acb ba AB])`,
+		`var @fuzzy-sc = (edit:match-fuzzy aB [ab aB])`,
+		`var @fuzzy-ic = (edit:match-fuzzy &ignore-case aB [ab AB])`,
 	)
 	testGlobals(t, f.Evaler, map[string]any{
 		"prefix": vals.MakeList(true, true, false, false, false, false, false, false),
 		"substr": vals.MakeList(true, true, true, false, false, true, false, false),
 		"subseq": vals.MakeList(true, true, true, true, false, true, true, false),
+		// Higher scores for consecutive matches and matches at the start.
+		"fuzzy": vals.MakeList(62, 36, 49, false, 62),
+		// Case-sensitive since the seed is not all lower case.
+		"fuzzy-sc": vals.MakeList(false, 62),
+		"fuzzy-ic": vals.MakeList(62, 62),
 	})
 
 	testThatOutputErrorIsBubbled(t, f, "edit:match-prefix ab [ab]")
+	testThatOutputErrorIsBubbled(t, f, "edit:match-fuzzy ab [ab]")
 }
 
 func TestBuiltinMatchers_Options(t *testing.T) {
@@ -141,6 +141,7 @@ func complain(format string, args ...any) {
 
 func newIntVar(i int) vars.PtrVar             { return vars.FromPtr(&i) }
 func newFloatVar(f float64) vars.PtrVar       { return vars.FromPtr(&f) }
+func newStringVar(s string) vars.PtrVar       { return vars.FromPtr(&s) }
 func newBoolVar(b bool) vars.PtrVar           { return vars.FromPtr(&b) }
 func newListVar(l vals.List) vars.PtrVar      { return vars.FromPtr(&l) }
 func newMapVar(m vals.Map) vars.PtrVar        { return vars.FromPtr(&m) }
@@ -79,6 +79,7 @@ func NewEditor(tty cli.TTY, ev *eval.Evaler, st storedefs.Store) *Editor {
 	initNavigation(ed, ev, nb)
 	initCompletion(ed, ev, nb)
 	initHistWalk(ed, ev, hs, nb)
+	initHistsearch(ed, ev, hs, nb)
 	initInstant(ed, ev, nb)
 	initMinibuf(ed, ev, nb)
 
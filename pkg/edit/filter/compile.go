@@ -7,6 +7,7 @@ package filter
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -15,10 +16,62 @@ import (
 	"src.elv.sh/pkg/parse/cmpd"
 )
 
+// Matcher determines how literal words in a filter are matched. It doesn't
+// affect explicit subfilters like [re ...].
+type Matcher int
+
+const (
+	// Substring matches literal words as substrings. This is the default.
+	Substring Matcher = iota
+	// Subseq matches literal words as subsequences, i.e. the runes of the word
+	// must appear in order, but not necessarily consecutively.
+	Subseq
+	// Regexp matches literal words as regular expressions.
+	Regexp
+	// Fuzzy matches literal words as subsequences like Subseq, but finds the
+	// matches with [FuzzyScore], so that the highlighted parts are the ones
+	// that were scored.
+	Fuzzy
+)
+
+var matcherNames = []string{"substr", "subseq", "regexp", "fuzzy"}
+
+// String returns the name of the matcher, one of "substr", "subseq", "regexp"
+// and "fuzzy".
+func (m Matcher) String() string {
+	if 0 <= m && int(m) < len(matcherNames) {
+		return matcherNames[m]
+	}
+	return "unknown"
+}
+
+// Next returns the next matcher in the order they are defined, wrapping around
+// after the last one. This is useful for cycling through all the matchers.
+func (m Matcher) Next() Matcher {
+	return (m + 1) % Matcher(len(matcherNames))
+}
+
+// ParseMatcher parses the name of a matcher, as returned by [Matcher.String].
+func ParseMatcher(name string) (Matcher, error) {
+	for i, matcherName := range matcherNames {
+		if name == matcherName {
+			return Matcher(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown matcher %s, must be one of %s",
+		parse.Quote(name), strings.Join(matcherNames, ", "))
+}
+
 // Compile parses and compiles a filter.
 func Compile(q string) (Filter, error) {
+	return CompileWithMatcher(q, Substring)
+}
+
+// CompileWithMatcher is like [Compile], but uses the given [Matcher] for
+// literal words.
+func CompileWithMatcher(q string, m Matcher) (Filter, error) {
 	qn, errParse := parseFilter(q)
-	filter, errCompile := compileFilter(qn)
+	filter, errCompile := compiler{m}.compileFilter(qn)
 	return filter, errutil.Multi(errParse, errCompile)
 }
 
@@ -28,21 +81,23 @@ func parseFilter(q string) (*parse.Filter, error) {
 	return qn, err
 }
 
-func compileFilter(qn *parse.Filter) (Filter, error) {
+type compiler struct{ matcher Matcher }
+
+func (c compiler) compileFilter(qn *parse.Filter) (Filter, error) {
 	if len(qn.Opts) > 0 {
 		return nil, notSupportedError{"option"}
 	}
-	qs, err := compileCompounds(qn.Args)
+	qs, err := c.compileCompounds(qn.Args)
 	if err != nil {
 		return nil, err
 	}
 	return andFilter{qs}, nil
 }
 
-func compileCompounds(ns []*parse.Compound) ([]Filter, error) {
+func (c compiler) compileCompounds(ns []*parse.Compound) ([]Filter, error) {
 	qs := make([]Filter, len(ns))
 	for i, n := range ns {
-		q, err := compileCompound(n)
+		q, err := c.compileCompound(n)
 		if err != nil {
 			return nil, err
 		}
@@ -51,23 +106,39 @@ func compileCompounds(ns []*parse.Compound) ([]Filter, error) {
 	return qs, nil
 }
 
-func compileCompound(n *parse.Compound) (Filter, error) {
+func (c compiler) compileCompound(n *parse.Compound) (Filter, error) {
 	if pn, ok := cmpd.Primary(n); ok {
 		switch pn.Type {
 		case parse.Bareword, parse.SingleQuoted, parse.DoubleQuoted:
-			s := pn.Value
-			ignoreCase := s == strings.ToLower(s)
-			return substringFilter{s, ignoreCase}, nil
+			return c.compileLiteral(pn.Value)
 		case parse.List:
-			return compileList(pn.Elements)
+			return c.compileList(pn.Elements)
 		}
 	}
 	return nil, notSupportedError{cmpd.Shape(n)}
 }
 
+func (c compiler) compileLiteral(s string) (Filter, error) {
+	ignoreCase := s == strings.ToLower(s)
+	switch c.matcher {
+	case Subseq:
+		return subseqFilter{s, ignoreCase}, nil
+	case Fuzzy:
+		return fuzzyFilter{s}, nil
+	case Regexp:
+		p, err := regexp.Compile(s)
+		if err != nil {
+			return nil, err
+		}
+		return regexpFilter{p}, nil
+	default:
+		return substringFilter{s, ignoreCase}, nil
+	}
+}
+
 var errEmptySubfilter = errors.New("empty subfilter")
 
-func compileList(elems []*parse.Compound) (Filter, error) {
+func (c compiler) compileList(elems []*parse.Compound) (Filter, error) {
 	if len(elems) == 0 {
 		return nil, errEmptySubfilter
 	}
@@ -94,13 +165,13 @@ func compileList(elems []*parse.Compound) (Filter, error) {
 		}
 		return regexpFilter{p}, nil
 	case "and":
-		qs, err := compileCompounds(elems[1:])
+		qs, err := c.compileCompounds(elems[1:])
 		if err != nil {
 			return nil, err
 		}
 		return andFilter{qs}, nil
 	case "or":
-		qs, err := compileCompounds(elems[1:])
+		qs, err := c.compileCompounds(elems[1:])
 		if err != nil {
 			return nil, err
 		}
@@ -2,12 +2,25 @@ package filter
 
 import (
 	"regexp"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"src.elv.sh/pkg/diag"
 )
 
 // Filter represents a compiled filter, which can be used to match text.
 type Filter interface {
 	Match(s string) bool
+	// Locate is like Match, but also returns the ranges of s that were
+	// matched, sorted and non-overlapping. The ranges may be empty even if s
+	// matches, for example when the filter is empty.
+	Locate(s string) ([]diag.Ranging, bool)
+	// Score is like Locate, but also returns a score of how well s matches,
+	// higher being better. Literal words contribute to the score with
+	// [FuzzyScore], regardless of the matcher they are compiled with.
+	Score(s string) (int, []diag.Ranging, bool)
 }
 
 type andFilter struct {
@@ -23,6 +36,32 @@ func (aq andFilter) Match(s string) bool {
 	return true
 }
 
+func (aq andFilter) Locate(s string) ([]diag.Ranging, bool) {
+	var all []diag.Ranging
+	for _, q := range aq.queries {
+		rs, ok := q.Locate(s)
+		if !ok {
+			return nil, false
+		}
+		all = append(all, rs...)
+	}
+	return mergeRanges(all), true
+}
+
+func (aq andFilter) Score(s string) (int, []diag.Ranging, bool) {
+	total := 0
+	var all []diag.Ranging
+	for _, q := range aq.queries {
+		score, rs, ok := q.Score(s)
+		if !ok {
+			return 0, nil, false
+		}
+		total += score
+		all = append(all, rs...)
+	}
+	return total, mergeRanges(all), true
+}
+
 type orFilter struct {
 	queries []Filter
 }
@@ -36,6 +75,34 @@ func (oq orFilter) Match(s string) bool {
 	return false
 }
 
+func (oq orFilter) Locate(s string) ([]diag.Ranging, bool) {
+	var all []diag.Ranging
+	matched := false
+	for _, q := range oq.queries {
+		if rs, ok := q.Locate(s); ok {
+			all = append(all, rs...)
+			matched = true
+		}
+	}
+	return mergeRanges(all), matched
+}
+
+func (oq orFilter) Score(s string) (int, []diag.Ranging, bool) {
+	best := 0
+	var all []diag.Ranging
+	matched := false
+	for _, q := range oq.queries {
+		if score, rs, ok := q.Score(s); ok {
+			if !matched || score > best {
+				best = score
+			}
+			all = append(all, rs...)
+			matched = true
+		}
+	}
+	return best, mergeRanges(all), matched
+}
+
 type substringFilter struct {
 	pattern    string
 	ignoreCase bool
@@ -48,6 +115,110 @@ func (sq substringFilter) Match(s string) bool {
 	return strings.Contains(s, sq.pattern)
 }
 
+func (sq substringFilter) Locate(s string) ([]diag.Ranging, bool) {
+	if !sq.ignoreCase {
+		i := strings.Index(s, sq.pattern)
+		if i == -1 {
+			return nil, false
+		}
+		return []diag.Ranging{{From: i, To: i + len(sq.pattern)}}, true
+	}
+	// Lowercasing can change the byte length of some runes, so we can't just
+	// search in strings.ToLower(s).
+	for i := range s {
+		if n, ok := hasLowerPrefix(s[i:], sq.pattern); ok {
+			return []diag.Ranging{{From: i, To: i + n}}, true
+		}
+	}
+	return nil, false
+}
+
+func (sq substringFilter) Score(s string) (int, []diag.Ranging, bool) {
+	rs, ok := sq.Locate(s)
+	if !ok {
+		return 0, nil, false
+	}
+	// A substring is also a subsequence, so this always matches.
+	score, _, _ := FuzzyScore(sq.pattern, s)
+	return score, rs, true
+}
+
+// Reports whether s starts with a prefix that becomes p when lowercased, and
+// the length of that prefix.
+func hasLowerPrefix(s, p string) (int, bool) {
+	n := 0
+	for _, pr := range p {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if size == 0 || unicode.ToLower(r) != pr {
+			return 0, false
+		}
+		n += size
+	}
+	return n, true
+}
+
+// Matches if all the runes of the pattern appear in the same order, but not
+// necessarily consecutively.
+type subseqFilter struct {
+	pattern    string
+	ignoreCase bool
+}
+
+func (sq subseqFilter) Match(s string) bool {
+	_, ok := sq.Locate(s)
+	return ok
+}
+
+func (sq subseqFilter) Locate(s string) ([]diag.Ranging, bool) {
+	var rs []diag.Ranging
+	i := 0
+	for _, pr := range sq.pattern {
+		for {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if size == 0 {
+				return nil, false
+			}
+			if sq.ignoreCase {
+				r = unicode.ToLower(r)
+			}
+			i += size
+			if r == pr {
+				rs = append(rs, diag.Ranging{From: i - size, To: i})
+				break
+			}
+		}
+	}
+	return mergeRanges(rs), true
+}
+
+func (sq subseqFilter) Score(s string) (int, []diag.Ranging, bool) {
+	rs, ok := sq.Locate(s)
+	if !ok {
+		return 0, nil, false
+	}
+	score, _, _ := FuzzyScore(sq.pattern, s)
+	return score, rs, true
+}
+
+// Like subseqFilter, but finds a match with FuzzyScore and scores it.
+type fuzzyFilter struct {
+	pattern string
+}
+
+func (fq fuzzyFilter) Match(s string) bool {
+	_, _, ok := FuzzyScore(fq.pattern, s)
+	return ok
+}
+
+func (fq fuzzyFilter) Locate(s string) ([]diag.Ranging, bool) {
+	_, rs, ok := FuzzyScore(fq.pattern, s)
+	return rs, ok
+}
+
+func (fq fuzzyFilter) Score(s string) (int, []diag.Ranging, bool) {
+	return FuzzyScore(fq.pattern, s)
+}
+
 type regexpFilter struct {
 	pattern *regexp.Regexp
 }
@@ -55,3 +226,39 @@ type regexpFilter struct {
 func (rq regexpFilter) Match(s string) bool {
 	return rq.pattern.MatchString(s)
 }
+
+func (rq regexpFilter) Locate(s string) ([]diag.Ranging, bool) {
+	loc := rq.pattern.FindStringIndex(s)
+	if loc == nil {
+		return nil, false
+	}
+	if loc[0] == loc[1] {
+		return nil, true
+	}
+	return []diag.Ranging{{From: loc[0], To: loc[1]}}, true
+}
+
+func (rq regexpFilter) Score(s string) (int, []diag.Ranging, bool) {
+	rs, ok := rq.Locate(s)
+	return 0, rs, ok
+}
+
+// Sorts the ranges, and merges overlapping or adjacent ones.
+func mergeRanges(rs []diag.Ranging) []diag.Ranging {
+	if len(rs) <= 1 {
+		return rs
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].From < rs[j].From })
+	merged := rs[:1]
+	for _, r := range rs[1:] {
+		last := &merged[len(merged)-1]
+		if r.From <= last.To {
+			if r.To > last.To {
+				last.To = r.To
+			}
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,190 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"src.elv.sh/pkg/diag"
+)
+
+// Scores used by FuzzyScore. The scheme is loosely modelled after fzf: every
+// matched rune scores the same, gaps between matched runes are penalized, and
+// runes matched at the start of a "word" get a bonus.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	// Bonus for matching the first rune after a non-word rune, like "b" in
+	// "foo-bar".
+	bonusBoundary = scoreMatch / 2
+	// Bonus for matching the first rune of a path segment, like "b" in
+	// "foo/bar".
+	bonusPathSegment = bonusBoundary + 1
+	// Bonus for matching the first rune after whitespace or at the start of
+	// the string.
+	bonusWhitespace = bonusBoundary + 2
+	// Bonus for matching the first rune of a camelCase hump, like "B" in
+	// "fooBar", or the first digit after a letter, like "1" in "foo1".
+	bonusCamel = bonusBoundary - 1
+	// Bonus for matching a rune right after the previous matched rune. This
+	// is large enough to offset the penalty of a gap.
+	bonusConsecutive = -(scoreGapStart + scoreGapExtension)
+	// The bonus of the first rune of the pattern is multiplied by this.
+	bonusFirstRuneMultiplier = 2
+)
+
+type runeClass int
+
+const (
+	classWhitespace runeClass = iota
+	classPathSep
+	classNonWord
+	classLower
+	classUpper
+	classLetter
+	classDigit
+)
+
+func classOf(r rune) runeClass {
+	switch {
+	case unicode.IsSpace(r):
+		return classWhitespace
+	case r == '/' || r == '\\':
+		return classPathSep
+	case unicode.IsLower(r):
+		return classLower
+	case unicode.IsUpper(r):
+		return classUpper
+	case unicode.IsLetter(r):
+		return classLetter
+	case unicode.IsDigit(r):
+		return classDigit
+	default:
+		return classNonWord
+	}
+}
+
+func bonusFor(prev, cur runeClass) int {
+	if cur > classNonWord {
+		switch {
+		case prev == classWhitespace:
+			return bonusWhitespace
+		case prev == classPathSep:
+			return bonusPathSegment
+		case prev == classNonWord:
+			return bonusBoundary
+		case prev == classLower && cur == classUpper,
+			prev != classDigit && cur == classDigit:
+			return bonusCamel
+		}
+		return 0
+	}
+	if cur == classPathSep || cur == classNonWord {
+		return bonusBoundary
+	}
+	return 0
+}
+
+// FuzzyScore matches pattern against s as a subsequence, and scores how good
+// the match is, higher being better. Matches at word boundaries, path segments,
+// camelCase humps and consecutive matches score higher, while gaps between
+// matched runes score lower.
+//
+// It returns the score, the byte ranges of the matched runes in s, and whether
+// s matches at all. The match is case-insensitive if the pattern is all lower
+// case.
+//
+// Like fzf's "v1" algorithm, this finds the shortest window of s ending at the
+// earliest possible position that contains the pattern, rather than searching
+// for the best possible match.
+func FuzzyScore(pattern, s string) (int, []diag.Ranging, bool) {
+	if pattern == "" {
+		return 0, nil, true
+	}
+	ignoreCase := pattern == strings.ToLower(pattern)
+	p := []rune(pattern)
+	eq := func(r, pr rune) bool {
+		if ignoreCase {
+			r = unicode.ToLower(r)
+		}
+		return r == pr
+	}
+
+	// Find the end of the leftmost match.
+	end, pi := 0, 0
+	for end < len(s) && pi < len(p) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		end += size
+		if eq(r, p[pi]) {
+			pi++
+		}
+	}
+	if pi < len(p) {
+		return 0, nil, false
+	}
+	// Walk backwards from the end to find the shortest window.
+	start := end
+	for pi = len(p) - 1; pi >= 0; {
+		r, size := utf8.DecodeLastRuneInString(s[:start])
+		start -= size
+		if eq(r, p[pi]) {
+			pi--
+		}
+	}
+
+	// Score the window.
+	prevClass := classWhitespace
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:start])
+		prevClass = classOf(r)
+	}
+	var ranges []diag.Ranging
+	score, consecutive, firstBonus, inGap := 0, 0, 0, false
+	pi = 0
+	for i := start; i < end; {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		class := classOf(r)
+		if pi < len(p) && eq(r, p[pi]) {
+			score += scoreMatch
+			bonus := bonusFor(prevClass, class)
+			if consecutive == 0 {
+				firstBonus = bonus
+			} else {
+				// Consecutive matches keep the bonus of the start of the chunk,
+				// so that "foo" matching in "xx foo" scores higher than in
+				// "xxfoo".
+				if bonus >= bonusBoundary && bonus > firstBonus {
+					firstBonus = bonus
+				}
+				if firstBonus > bonus {
+					bonus = firstBonus
+				}
+				if bonusConsecutive > bonus {
+					bonus = bonusConsecutive
+				}
+			}
+			if pi == 0 {
+				bonus *= bonusFirstRuneMultiplier
+			}
+			score += bonus
+			consecutive++
+			inGap = false
+			pi++
+			ranges = append(ranges, diag.Ranging{From: i, To: i + size})
+		} else {
+			if inGap {
+				score += scoreGapExtension
+			} else {
+				score += scoreGapStart
+			}
+			inGap = true
+			consecutive = 0
+			firstBonus = 0
+		}
+		prevClass = class
+		i += size
+	}
+	return score, mergeRanges(ranges), true
+}
@@ -0,0 +1,84 @@
+package filter_test
+
+import (
+	"reflect"
+	"testing"
+
+	"src.elv.sh/pkg/diag"
+	"src.elv.sh/pkg/edit/filter"
+)
+
+func TestFuzzyScore_Ranges(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		wantOK  bool
+		want    []diag.Ranging
+	}{
+		{"", "foo", true, nil},
+		{"fb", "foo bar", true, []diag.Ranging{{From: 0, To: 1}, {From: 4, To: 5}}},
+		// The shortest window ending at the earliest position is used.
+		{"ab", "a a b", true, []diag.Ranging{{From: 2, To: 3}, {From: 4, To: 5}}},
+		{"ab", "ABC", true, []diag.Ranging{{From: 0, To: 2}}},
+		{"Ab", "abc", false, nil},
+		{"ba", "ab", false, nil},
+		{"你好", "你们好", true, []diag.Ranging{{From: 0, To: 3}, {From: 6, To: 9}}},
+	}
+	for _, tc := range tests {
+		_, got, ok := filter.FuzzyScore(tc.pattern, tc.s)
+		if ok != tc.wantOK || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("FuzzyScore(%q, %q) -> _, %v, %v, want %v, %v",
+				tc.pattern, tc.s, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestFuzzyScore_Ranking(t *testing.T) {
+	// Each test lists strings in the order of descending scores.
+	tests := []struct {
+		name    string
+		pattern string
+		ordered []string
+	}{
+		{"consecutive matches", "foo",
+			[]string{"foo", "f_o_o", "fxxoxxo"}},
+		{"word boundaries", "fb",
+			[]string{"foo bar", "foo-bar", "foobar"}},
+		{"path segments", "ab",
+			[]string{"x/a/b", "xa-b", "xaxb"}},
+		{"camelCase", "fb",
+			[]string{"fooBar", "foobar"}},
+		{"shorter gaps", "ac",
+			[]string{"abc", "abbbc"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 1; i < len(tc.ordered); i++ {
+				s1, s2 := tc.ordered[i-1], tc.ordered[i]
+				score1, _, _ := filter.FuzzyScore(tc.pattern, s1)
+				score2, _, _ := filter.FuzzyScore(tc.pattern, s2)
+				if score1 <= score2 {
+					t.Errorf("want score of %q (%d) > score of %q (%d)",
+						s1, score1, s2, score2)
+				}
+			}
+		})
+	}
+}
+
+func TestFuzzyMatcher(t *testing.T) {
+	f, _ := filter.CompileWithMatcher("fb [re x]", filter.Fuzzy)
+	score, rs, ok := f.Score("foo bar x")
+	wantScore, _, _ := filter.FuzzyScore("fb", "foo bar x")
+	wantRanges := []diag.Ranging{{From: 0, To: 1}, {From: 4, To: 5}, {From: 8, To: 9}}
+	if score != wantScore || !reflect.DeepEqual(rs, wantRanges) || !ok {
+		t.Errorf("got %v, %v, %v, want %v, %v, true", score, rs, ok, wantScore, wantRanges)
+	}
+
+	f, _ = filter.CompileWithMatcher("[or fb xyz]", filter.Fuzzy)
+	score, _, ok = f.Score("xyz")
+	wantScore, _, _ = filter.FuzzyScore("xyz", "xyz")
+	if score != wantScore || !ok {
+		t.Errorf("got %v, %v, want %v, true", score, ok, wantScore)
+	}
+}
@@ -0,0 +1,78 @@
+package filter_test
+
+import (
+	"reflect"
+	"testing"
+
+	"src.elv.sh/pkg/diag"
+	"src.elv.sh/pkg/edit/filter"
+)
+
+var locateTests = []struct {
+	name    string
+	filter  string
+	matcher filter.Matcher
+	s       string
+	wantOK  bool
+	want    []diag.Ranging
+}{
+	{"empty filter matches with no ranges", "", filter.Substring,
+		"foo", true, nil},
+	{"substring", "oo", filter.Substring,
+		"foo", true, []diag.Ranging{{From: 1, To: 3}}},
+	{"substring ignoring case", "oo", filter.Substring,
+		"FOO", true, []diag.Ranging{{From: 1, To: 3}}},
+	{"substring ignoring case with runes whose length change", "ia", filter.Substring,
+		"xİa", true, []diag.Ranging{{From: 1, To: 4}}},
+	{"substring not matching", "bar", filter.Substring,
+		"foo", false, nil},
+	{"AND filter merges ranges", "fo oo", filter.Substring,
+		"foo", true, []diag.Ranging{{From: 0, To: 3}}},
+	{"AND filter fails if any component fails", "fo bar", filter.Substring,
+		"foo", false, nil},
+	{"OR filter returns ranges of matching components", "[or bar lo]", filter.Substring,
+		"hello", true, []diag.Ranging{{From: 3, To: 5}}},
+	{"subseq", "fb", filter.Subseq,
+		"foo bar", true, []diag.Ranging{{From: 0, To: 1}, {From: 4, To: 5}}},
+	{"subseq merges adjacent runes", "foob", filter.Subseq,
+		"foo bar", true, []diag.Ranging{{From: 0, To: 3}, {From: 4, To: 5}}},
+	{"subseq not matching", "bf", filter.Subseq,
+		"foo bar", false, nil},
+	{"regexp", "o+", filter.Regexp,
+		"foo", true, []diag.Ranging{{From: 1, To: 3}}},
+	{"re subfilter works with any matcher", "[re o+]", filter.Subseq,
+		"foo", true, []diag.Ranging{{From: 1, To: 3}}},
+}
+
+func TestLocate(t *testing.T) {
+	for _, tc := range locateTests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := filter.CompileWithMatcher(tc.filter, tc.matcher)
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			got, ok := f.Locate(tc.s)
+			if ok != tc.wantOK || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Locate(%q) -> %v, %v, want %v, %v",
+					tc.s, got, ok, tc.want, tc.wantOK)
+			}
+			if match := f.Match(tc.s); match != tc.wantOK {
+				t.Errorf("Match(%q) -> %v, want %v", tc.s, match, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseMatcher(t *testing.T) {
+	for _, m := range []filter.Matcher{filter.Substring, filter.Subseq, filter.Regexp, filter.Fuzzy} {
+		parsed, err := filter.ParseMatcher(m.String())
+		if parsed != m || err != nil {
+			t.Errorf("ParseMatcher(%q) -> %v, %v", m.String(), parsed, err)
+		}
+	}
+	_, err := filter.ParseMatcher("bad")
+	wantErr := "unknown matcher bad, must be one of substr, subseq, regexp, fuzzy"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("got error %v, want %q", err, wantErr)
+	}
+}
@@ -0,0 +1,101 @@
+package highlight
+
+import (
+	"src.elv.sh/pkg/parse"
+	"src.elv.sh/pkg/ui"
+)
+
+// BracketPair records the positions of a pair of matching brackets.
+type BracketPair struct {
+	// Position of the opening bracket.
+	Open int
+	// Position of the closing bracket, or -1 if the opening bracket is not
+	// closed.
+	Close int
+}
+
+// Brackets returns all the pairs of brackets in the code, ordered by the
+// positions of the opening brackets. Brackets are only recognized when they
+// are part of the syntax (for example, not inside string literals), and only
+// match brackets belonging to the same parse tree node.
+func Brackets(code string) []BracketPair {
+	tree, _ := parse.Parse(parse.Source{Name: "[interactive]", Code: code}, parse.Config{})
+	return bracketsInner(tree.Root, nil)
+}
+
+func bracketsInner(n parse.Node, pairs []BracketPair) []BracketPair {
+	// Indices into pairs for opening brackets of this node that have not
+	// been closed yet.
+	var unclosed []int
+	for _, ch := range parse.Children(n) {
+		if _, ok := ch.(*parse.Sep); !ok {
+			pairs = bracketsInner(ch, pairs)
+			continue
+		}
+		text := sourceText(ch)
+		switch {
+		case isOpeningBracket(text):
+			// The "?(" of exception captures is a single Sep; the bracket is
+			// the last character.
+			unclosed = append(unclosed, len(pairs))
+			pairs = append(pairs, BracketPair{ch.Range().To - 1, -1})
+		case isClosingBracket(text) && len(unclosed) > 0:
+			pairs[unclosed[len(unclosed)-1]].Close = ch.Range().From
+			unclosed = unclosed[:len(unclosed)-1]
+		}
+	}
+	return pairs
+}
+
+func isOpeningBracket(text string) bool {
+	switch text {
+	case "(", "[", "{", "?(":
+		return true
+	}
+	return false
+}
+
+func isClosingBracket(text string) bool {
+	return text == ")" || text == "]" || text == "}"
+}
+
+// MatchingBrackets finds the bracket under the dot, or if there is none, the
+// bracket just before the dot, and returns the pair it belongs to. It returns
+// false if there is no such bracket, or the bracket has no match.
+func MatchingBrackets(code string, dot int) (BracketPair, bool) {
+	return matchingBrackets(Brackets(code), dot)
+}
+
+func matchingBrackets(pairs []BracketPair, dot int) (BracketPair, bool) {
+	for _, pos := range []int{dot, dot - 1} {
+		for _, pair := range pairs {
+			if pair.Close != -1 && (pair.Open == pos || pair.Close == pos) {
+				return pair, true
+			}
+		}
+	}
+	return BracketPair{}, false
+}
+
+// EnclosingBracket returns the position of the innermost opening bracket whose
+// content contains the dot, whether or not it is closed.
+func EnclosingBracket(code string, dot int) (int, bool) {
+	pairs := Brackets(code)
+	// Pairs are ordered by their opening brackets, so the last matching one is
+	// the innermost.
+	for i := len(pairs) - 1; i >= 0; i-- {
+		pair := pairs[i]
+		if pair.Open < dot && (pair.Close == -1 || dot <= pair.Close) {
+			return pair.Open, true
+		}
+	}
+	return 0, false
+}
+
+// Applies stylingForMatchingBracket to the given bracket pair in the text.
+func highlightBracketPair(t ui.Text, pair BracketPair) ui.Text {
+	parts := t.Partition(pair.Open, pair.Open+1, pair.Close, pair.Close+1)
+	parts[1] = ui.StyleText(parts[1], stylingForMatchingBracket)
+	parts[3] = ui.StyleText(parts[3], stylingForMatchingBracket)
+	return ui.Concat(parts...)
+}
@@ -0,0 +1,77 @@
+package highlight
+
+import (
+	"testing"
+
+	"src.elv.sh/pkg/tt"
+	"src.elv.sh/pkg/ui"
+)
+
+func TestBrackets(t *testing.T) {
+	tt.Test(t, Brackets,
+		Args("").Rets([]BracketPair(nil)),
+		Args("echo foo").Rets([]BracketPair(nil)),
+		Args("put [a (b)] {|x| }").Rets([]BracketPair{
+			{4, 10}, {7, 9}, {12, 17}}),
+		// Brackets of exception captures and indexing.
+		Args("?(put $a[0])").Rets([]BracketPair{{1, 11}, {8, 10}}),
+		// Braced lists.
+		Args("echo {a,b}").Rets([]BracketPair{{5, 9}}),
+		// Unclosed brackets.
+		Args("put [(a)").Rets([]BracketPair{{4, -1}, {5, 7}}),
+		// Brackets in strings and comments are ignored.
+		Args("echo '[' \"{\" # (").Rets([]BracketPair(nil)),
+	)
+}
+
+func TestMatchingBrackets(t *testing.T) {
+	tt.Test(t, MatchingBrackets,
+		Args("put [a]", 4).Rets(BracketPair{4, 6}, true),
+		Args("put [a]", 6).Rets(BracketPair{4, 6}, true),
+		Args("put [a]", 7).Rets(BracketPair{4, 6}, true),
+		Args("put [a]", 5).Rets(BracketPair{4, 6}, true),
+		Args("put [a]", 3).Rets(BracketPair{}, false),
+		// The bracket under the dot takes precedence.
+		Args("[()]", 3).Rets(BracketPair{0, 3}, true),
+		// Unclosed brackets have no match.
+		Args("put [a", 4).Rets(BracketPair{}, false),
+	)
+}
+
+func TestEnclosingBracket(t *testing.T) {
+	tt.Test(t, EnclosingBracket,
+		Args("put [a]", 5).Rets(4, true),
+		Args("put [a]", 6).Rets(4, true),
+		Args("put [a]", 4).Rets(0, false),
+		Args("put [a]", 7).Rets(0, false),
+		Args("put [a (b", 9).Rets(7, true),
+		Args("put [a (b)", 10).Rets(4, true),
+	)
+}
+
+var bracketStyles = ui.RuneStylesheet{
+	'_': ui.Stylings(ui.Bold, ui.Underlined),
+}
+
+func TestHighlighter_HighlightDot(t *testing.T) {
+	hl := NewHighlighter(Config{})
+	highlightDot := func(code string, dot int) ui.Text {
+		return hl.HighlightDot(ui.T(code), code, dot)
+	}
+	tt.Test(t, tt.Fn(highlightDot).Named("highlightDot"),
+		Args("put [(a)]", 4).Rets(
+			ui.MarkLines(
+				"put [(a)]", bracketStyles,
+				"    _   _")),
+		Args("put [(a)]", 7).Rets(
+			ui.MarkLines(
+				"put [(a)]", bracketStyles,
+				"     _ _ ")),
+		Args("put [(a)]", 9).Rets(
+			ui.MarkLines(
+				"put [(a)]", bracketStyles,
+				"    _   _")),
+		Args("put [(a)]", 2).Rets(ui.T("put [(a)]")),
+		Args("put [a", 4).Rets(ui.T("put [a")),
+	)
+}
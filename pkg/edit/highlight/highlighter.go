@@ -13,8 +13,9 @@ type Highlighter struct {
 	cfg   Config
 	lates chan struct{}
 
-	cacheMutex sync.Mutex
-	cache      cache
+	cacheMutex    sync.Mutex
+	cache         cache
+	bracketsCache bracketsCache
 }
 
 type cache struct {
@@ -23,6 +24,12 @@ type cache struct {
 	tips       []ui.Text
 }
 
+type bracketsCache struct {
+	code  string
+	pairs []BracketPair
+	valid bool
+}
+
 func NewHighlighter(cfg Config) *Highlighter {
 	return &Highlighter{cfg: cfg, lates: make(chan struct{}, latesBufferSize)}
 }
@@ -55,6 +62,22 @@ func (hl *Highlighter) Get(code string) (ui.Text, []ui.Text) {
 	return styledCode, tips
 }
 
+// HighlightDot additionally highlights the pair of matching brackets under or
+// just before the dot in the code, which has been highlighted by Get.
+func (hl *Highlighter) HighlightDot(styledCode ui.Text, code string, dot int) ui.Text {
+	hl.cacheMutex.Lock()
+	if !hl.bracketsCache.valid || hl.bracketsCache.code != code {
+		hl.bracketsCache = bracketsCache{code, Brackets(code), true}
+	}
+	pairs := hl.bracketsCache.pairs
+	hl.cacheMutex.Unlock()
+
+	if pair, ok := matchingBrackets(pairs, dot); ok {
+		return highlightBracketPair(styledCode, pair)
+	}
+	return styledCode
+}
+
 // LateUpdates returns a channel for notifying late updates.
 func (hl *Highlighter) LateUpdates() <-chan struct{} {
 	return hl.lates
@@ -36,4 +36,6 @@ var stylingFor = map[string]ui.Styling{
 var (
 	stylingForGoodCommand = ui.FgGreen
 	stylingForBadCommand  = ui.FgRed
+
+	stylingForMatchingBracket = ui.Stylings(ui.Bold, ui.Underlined)
 )
@@ -0,0 +1,82 @@
+package edit
+
+import (
+	"errors"
+
+	"src.elv.sh/pkg/cli"
+	"src.elv.sh/pkg/cli/histutil"
+	"src.elv.sh/pkg/cli/modes"
+	"src.elv.sh/pkg/diag"
+	"src.elv.sh/pkg/edit/filter"
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/vars"
+	"src.elv.sh/pkg/ui"
+)
+
+func initHistsearch(ed *Editor, ev *eval.Evaler, hs histutil.Store, nb eval.NsBuilder) {
+	bindingVar := newBindingVar(emptyBindingsMap)
+	bindings := newMapBindings(ed, ev, bindingVar)
+	matcherVar := newStringVar(filter.Substring.String())
+	app := ed.app
+	// Returns the matcher from $edit:histsearch:matcher, falling back to
+	// substring matching if it is invalid.
+	matcher := func() filter.Matcher {
+		m, _ := filter.ParseMatcher(matcherVar.Get().(string))
+		return m
+	}
+	spec := modes.HistsearchSpec{
+		Bindings: bindings,
+		Store:    hs,
+		Filter: modes.FilterSpec{
+			Locator: func(q string) func(string) ([]diag.Ranging, bool) {
+				f, _ := filter.CompileWithMatcher(q, matcher())
+				if f == nil {
+					return func(string) ([]diag.Ranging, bool) { return nil, false }
+				}
+				return f.Locate
+			},
+			Highlighter: filter.Highlight,
+		},
+		MatcherName: func() string { return matcher().String() },
+		CodeAreaRPrompt: func() ui.Text {
+			return bindingTips(ed.ns, "histsearch:binding",
+				bindingTip("matcher", "histsearch:cycle-matcher"))
+		},
+	}
+	nb.AddNs("histsearch",
+		eval.BuildNsNamed("edit:histsearch").
+			AddVars(map[string]vars.Var{
+				"binding": bindingVar,
+				"matcher": matcherVar,
+			}).
+			AddGoFns(map[string]any{
+				"start": func() {
+					_, err := filter.ParseMatcher(matcherVar.Get().(string))
+					if err != nil {
+						notifyError(app, err)
+						return
+					}
+					w, err := modes.NewHistsearch(app, spec)
+					startMode(app, w, err)
+				},
+				"prev":   func() { notifyError(app, histsearchDo(app, modes.Histsearch.Prev)) },
+				"next":   func() { notifyError(app, histsearchDo(app, modes.Histsearch.Next)) },
+				"accept": func() { notifyError(app, histsearchDo(app, modes.Histsearch.Accept)) },
+				"cycle-matcher": func() {
+					matcherVar.Set(matcher().Next().String())
+					if w, ok := app.ActiveWidget().(modes.Histsearch); ok {
+						w.Refilter()
+					}
+				},
+			}))
+}
+
+var errNotInHistsearchMode = errors.New("not in histsearch mode")
+
+func histsearchDo(app cli.App, f func(modes.Histsearch) error) error {
+	w, ok := app.ActiveWidget().(modes.Histsearch)
+	if !ok {
+		return errNotInHistsearchMode
+	}
+	return f(w)
+}
@@ -0,0 +1,76 @@
+package edit
+
+import (
+	"testing"
+
+	"src.elv.sh/pkg/cli/term"
+	"src.elv.sh/pkg/store/storedefs"
+	"src.elv.sh/pkg/ui"
+)
+
+var histsearchStyles = ui.RuneStylesheet{
+	'_': ui.Underlined,
+	'+': ui.Stylings(ui.Underlined, ui.Inverse),
+	'V': ui.Stylings(ui.Underlined, ui.FgGreen),
+	'W': ui.Stylings(ui.Underlined, ui.FgGreen, ui.Inverse),
+}
+
+func TestHistsearch(t *testing.T) {
+	f := startHistsearchTest(t)
+
+	f.TTYCtrl.Inject(term.K('o'), term.K('o'))
+	f.TestTTY(t,
+		"~> echo foo", histsearchStyles,
+		"   VVVV__++", "\n",
+		" SEARCH (substr)  oo", Styles,
+		"*****************   ", term.DotHere,
+		"                 Alt-m matcher", Styles,
+		"                 +++++        ",
+	)
+
+	f.TTYCtrl.Inject(term.K('R', ui.Ctrl))
+	f.TestTTY(t,
+		"~> echo bar boo", histsearchStyles,
+		"   VVVV______++", "\n",
+		" SEARCH (substr)  oo", Styles,
+		"*****************   ", term.DotHere,
+		"                 Alt-m matcher", Styles,
+		"                 +++++        ",
+	)
+
+	// Switching the matcher searches again from the newest entry.
+	f.TTYCtrl.Inject(term.K('m', ui.Alt))
+	f.TestTTY(t,
+		"~> echo foo", histsearchStyles,
+		"   VVVW__+_", "\n",
+		" SEARCH (subseq)  oo", Styles,
+		"*****************   ", term.DotHere,
+		"                 Alt-m matcher", Styles,
+		"                 +++++        ",
+	)
+
+	// Unhandled keys accept the match.
+	f.TTYCtrl.Inject(term.K(ui.Left))
+	f.TestTTY(t,
+		"~> echo fo", Styles,
+		"   vvvv   ", term.DotHere, "o",
+	)
+}
+
+func startHistsearchTest(t *testing.T) *fixture {
+	f := setup(t, storeOp(func(s storedefs.Store) {
+		s.AddCmd("echo bar boo")
+		s.AddCmd("echo foo")
+	}))
+	evals(f.Evaler, `set edit:insert:binding[Ctrl-R] = { edit:histsearch:start }`)
+
+	f.TTYCtrl.Inject(term.K('R', ui.Ctrl))
+	f.TestTTY(t,
+		"~> \n",
+		" SEARCH (substr)  ", Styles,
+		"***************** ", term.DotHere,
+		"                   Alt-m matcher", Styles,
+		"                   +++++        ",
+	)
+	return f
+}
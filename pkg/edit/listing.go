@@ -2,11 +2,13 @@ package edit
 
 import (
 	"os"
+	"time"
 
 	"src.elv.sh/pkg/cli"
 	"src.elv.sh/pkg/cli/histutil"
 	"src.elv.sh/pkg/cli/modes"
 	"src.elv.sh/pkg/cli/tk"
+	"src.elv.sh/pkg/diag"
 	"src.elv.sh/pkg/edit/filter"
 	"src.elv.sh/pkg/eval"
 	"src.elv.sh/pkg/eval/vals"
@@ -20,7 +22,12 @@ func initListings(ed *Editor, ev *eval.Evaler, st storedefs.Store, histStore his
 	app := ed.app
 	nb.AddNs("listing",
 		eval.BuildNsNamed("edit:listing").
-			AddVar("binding", bindingVar).
+			AddVars(map[string]vars.Var{
+				"binding": bindingVar,
+				"matcher": newStringVar(filter.Substring.String()),
+
+				"preview-stale-threshold": newFloatVar(0.2),
+			}).
 			AddGoFns(map[string]any{
 				"accept":     func() { listingAccept(app) },
 				"up":         func() { listingUp(app) },
@@ -29,8 +36,12 @@ func initListings(ed *Editor, ev *eval.Evaler, st storedefs.Store, histStore his
 				"down-cycle": func() { listingDownCycle(app) },
 				"page-up":    func() { listingPageUp(app) },
 				"page-down":  func() { listingPageDown(app) },
+
+				"toggle-mark":     func() { listingToggleMark(app) },
+				"toggle-mark-all": func() { listingToggleMarkAll(app) },
+
 				"start-custom": func(fm *eval.Frame, opts customListingOpts, items any) {
-					listingStartCustom(ed, fm, opts, items)
+					listingStartCustom(ed, fm, bindingVar, opts, items)
 				},
 			}))
 
@@ -39,36 +50,76 @@ func initListings(ed *Editor, ev *eval.Evaler, st storedefs.Store, histStore his
 	initLocation(ed, ev, st, bindingVar, nb)
 }
 
-var filterSpec = modes.FilterSpec{
-	Maker: func(f string) func(string) bool {
-		q, _ := filter.Compile(f)
-		if q == nil {
-			return func(string) bool { return true }
-		}
-		return q.Match
-	},
-	Highlighter: filter.Highlight,
+// Returns the filter configuration for listing modes. The matcher is read
+// from $edit:listing:matcher each time the filter changes, falling back to
+// substring matching if it is invalid. Items are ranked by how well they match
+// with any matcher; see [filter.FuzzyScore].
+func filterSpec(ed *Editor) modes.FilterSpec {
+	matcher := func() filter.Matcher {
+		name, _ := getVar(ed.ns, "listing:matcher").(string)
+		m, _ := filter.ParseMatcher(name)
+		return m
+	}
+	return modes.FilterSpec{
+		Maker: func(f string) func(string) bool {
+			q, _ := filter.CompileWithMatcher(f, matcher())
+			if q == nil {
+				return func(string) bool { return true }
+			}
+			return q.Match
+		},
+		Scorer: func(f string) func(string) (int, []diag.Ranging, bool) {
+			q, _ := filter.CompileWithMatcher(f, matcher())
+			if q == nil {
+				return nil
+			}
+			return q.Score
+		},
+		Highlighter: filter.Highlight,
+	}
+}
+
+// Returns the preview configuration for listing modes, computing previews by
+// calling the given function with the item. There is no preview pane if the
+// function is nil.
+func previewSpec(ed *Editor, ev *eval.Evaler, fn eval.Callable) modes.PreviewSpec {
+	if fn == nil {
+		return modes.PreviewSpec{}
+	}
+	return modes.PreviewSpec{
+		Compute: func(item string) ui.Text {
+			return callForStyledText(ed, ev, "preview", fn, item)
+		},
+		StaleThreshold: func() time.Duration {
+			seconds, _ := getVar(ed.ns, "listing:preview-stale-threshold").(float64)
+			return time.Duration(seconds * float64(time.Second))
+		},
+	}
 }
 
 func initHistlist(ed *Editor, ev *eval.Evaler, histStore histutil.Store, commonBindingVar vars.PtrVar, nb eval.NsBuilder) {
 	bindingVar := newBindingVar(emptyBindingsMap)
 	bindings := newMapBindings(ed, ev, bindingVar, commonBindingVar)
 	dedup := newBoolVar(true)
+	previewVar := newFnVar(nil)
 	ns := eval.BuildNsNamed("edit:histlist").
 		AddVar("binding", bindingVar).
+		AddVar("preview", previewVar).
 		AddGoFns(map[string]any{
 			"start": func() {
+				preview, _ := previewVar.Get().(eval.Callable)
 				w, err := modes.NewHistlist(ed.app, modes.HistlistSpec{
 					Bindings: bindings,
 					AllCmds:  histStore.AllCmds,
 					Dedup: func() bool {
 						return dedup.Get().(bool)
 					},
-					Filter: filterSpec,
+					Filter: filterSpec(ed),
 					CodeAreaRPrompt: func() ui.Text {
 						return bindingTips(ed.ns, "histlist:binding",
 							bindingTip("dedup", "histlist:toggle-dedup"))
 					},
+					Preview: previewSpec(ed, ev, preview),
 				})
 				startMode(ed.app, w, err)
 			},
@@ -100,6 +151,7 @@ func initLocation(ed *Editor, ev *eval.Evaler, st storedefs.Store, commonBinding
 	pinnedVar := newListVar(vals.EmptyList)
 	hiddenVar := newListVar(vals.EmptyList)
 	workspacesVar := newMapVar(vals.EmptyMap)
+	previewVar := newFnVar(nil)
 
 	bindings := newMapBindings(ed, ev, bindingVar, commonBindingVar)
 	workspaceIterator := modes.LocationWSIterator(
@@ -111,15 +163,18 @@ func initLocation(ed *Editor, ev *eval.Evaler, st storedefs.Store, commonBinding
 				"binding":    bindingVar,
 				"hidden":     hiddenVar,
 				"pinned":     pinnedVar,
+				"preview":    previewVar,
 				"workspaces": workspacesVar,
 			}).
 			AddGoFn("start", func() {
+				preview, _ := previewVar.Get().(eval.Callable)
 				w, err := modes.NewLocation(ed.app, modes.LocationSpec{
 					Bindings: bindings, Store: dirStore{ev, st},
 					IteratePinned:     adaptToIterateString(pinnedVar),
 					IterateHidden:     adaptToIterateString(hiddenVar),
 					IterateWorkspaces: workspaceIterator,
-					Filter:            filterSpec,
+					Filter:            filterSpec(ed),
+					Preview:           previewSpec(ed, ev, preview),
 				})
 				startMode(ed.app, w, err)
 			}))
@@ -145,6 +200,18 @@ func listingAccept(app cli.App) {
 	}
 }
 
+func listingToggleMark(app cli.App) {
+	if w, ok := activeComboBox(app); ok {
+		w.ListBox().ToggleMarked()
+	}
+}
+
+func listingToggleMarkAll(app cli.App) {
+	if w, ok := activeComboBox(app); ok {
+		w.ListBox().ToggleMarkedAll()
+	}
+}
+
 func listingUp(app cli.App) { listingSelect(app, tk.Prev) }
 
 func listingDown(app cli.App) { listingSelect(app, tk.Next) }
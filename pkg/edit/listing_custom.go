@@ -7,7 +7,6 @@ import (
 	"sync"
 
 	"src.elv.sh/pkg/cli/modes"
-	"src.elv.sh/pkg/cli/tk"
 	"src.elv.sh/pkg/eval"
 	"src.elv.sh/pkg/eval/vals"
 	"src.elv.sh/pkg/eval/vars"
@@ -21,14 +20,15 @@ type customListingOpts struct {
 	KeepBottom bool
 	Accept     eval.Callable
 	AutoAccept bool
+	Preview    eval.Callable
 }
 
 func (*customListingOpts) SetDefaultOptions() {}
 
-func listingStartCustom(ed *Editor, fm *eval.Frame, opts customListingOpts, items any) {
-	var bindings tk.Bindings
+func listingStartCustom(ed *Editor, fm *eval.Frame, commonBindingVar vars.PtrVar, opts customListingOpts, items any) {
+	bindings := newMapBindings(ed, fm.Evaler, commonBindingVar)
 	if opts.Binding.Map != nil {
-		bindings = newMapBindings(ed, fm.Evaler, vars.FromPtr(&opts.Binding))
+		bindings = newMapBindings(ed, fm.Evaler, vars.FromPtr(&opts.Binding), commonBindingVar)
 	}
 	var getItems func(string) []modes.ListingItem
 	if fn, isFn := items.(eval.Callable); isFn {
@@ -98,7 +98,17 @@ func listingStartCustom(ed *Editor, fm *eval.Frame, opts customListingOpts, item
 				callWithNotifyPorts(ed, fm.Evaler, opts.Accept, s)
 			}
 		},
+		AcceptMarked: func(ss []string) {
+			if opts.Accept != nil {
+				l := vals.EmptyList
+				for _, s := range ss {
+					l = l.Conj(s)
+				}
+				callWithNotifyPorts(ed, fm.Evaler, opts.Accept, l)
+			}
+		},
 		AutoAccept: opts.AutoAccept,
+		Preview:    previewSpec(ed, fm.Evaler, opts.Preview),
 	})
 	startMode(ed.app, w, err)
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"src.elv.sh/pkg/cli/term"
+	"src.elv.sh/pkg/eval/vals"
 	"src.elv.sh/pkg/store/storedefs"
 	"src.elv.sh/pkg/ui"
 )
@@ -72,6 +73,59 @@ func TestListingBuiltins(t *testing.T) {
 
 // Smoke tests for individual addons.
 
+var histlistStyles = ui.RuneStylesheet{
+	'*': ui.Stylings(ui.Bold, ui.FgWhite, ui.BgMagenta),
+	'+': ui.Inverse,
+	'y': ui.Stylings(ui.Bold, ui.FgYellow),
+	'Y': ui.Stylings(ui.Bold, ui.FgYellow, ui.Inverse),
+}
+
+func TestHistlistAddon_SubstrMatcherRanks(t *testing.T) {
+	f := setup(t, storeOp(func(s storedefs.Store) {
+		s.AddCmd("git checkout")
+		s.AddCmd("echo")
+	}))
+
+	// The default matcher is substr; matches at word boundaries still rank
+	// higher, and the best match is at the bottom.
+	f.TTYCtrl.Inject(term.K('R', ui.Ctrl), term.K('c'), term.K('h'))
+	f.TestTTY(t,
+		"~> \n",
+		" HISTORY (dedup on)  ch", histlistStyles,
+		"********************   ", term.DotHere,
+		"               Ctrl-D dedup\n", histlistStyles,
+		"               ++++++      ",
+		"   2 echo\n", histlistStyles,
+		"      yy",
+		"   1 git checkout                                 ", histlistStyles,
+		"+++++++++YY+++++++++++++++++++++++++++++++++++++++",
+	)
+}
+
+func TestHistlistAddon_FuzzyMatcher(t *testing.T) {
+	f := setup(t, storeOp(func(s storedefs.Store) {
+		s.AddCmd("git checkout")
+		s.AddCmd("xgxc")
+		s.AddCmd("make")
+	}))
+
+	evals(f.Evaler, `set edit:listing:matcher = fuzzy`)
+	f.TTYCtrl.Inject(term.K('R', ui.Ctrl), term.K('g'), term.K('c'))
+	// Matches at word boundaries rank higher, and the best match is at the
+	// bottom.
+	f.TestTTY(t,
+		"~> \n",
+		" HISTORY (dedup on)  gc", histlistStyles,
+		"********************   ", term.DotHere,
+		"               Ctrl-D dedup\n", histlistStyles,
+		"               ++++++      ",
+		"   2 xgxc\n", histlistStyles,
+		"      y y",
+		"   1 git checkout                                 ", histlistStyles,
+		"+++++Y+++Y++++++++++++++++++++++++++++++++++++++++",
+	)
+}
+
 func TestHistlistAddon(t *testing.T) {
 	f := setup(t, storeOp(func(s storedefs.Store) {
 		s.AddCmd("ls")
@@ -113,25 +167,47 @@ func TestHistlistAddon(t *testing.T) {
 	f.TTYCtrl.Inject(term.K('l'))
 	f.TestTTY(t,
 		"~> \n",
-		" HISTORY (dedup on)  l", Styles,
+		" HISTORY (dedup on)  l", histlistStyles,
 		"********************  ", term.DotHere,
-		"                Ctrl-D dedup\n", Styles,
+		"                Ctrl-D dedup\n", histlistStyles,
 		"                ++++++      ",
-		"   3 ls\n",
-		"   4 LS                                           ", Styles,
-		"++++++++++++++++++++++++++++++++++++++++++++++++++",
+		"   3 ls\n", histlistStyles,
+		"     y ",
+		"   4 LS                                           ", histlistStyles,
+		"+++++Y++++++++++++++++++++++++++++++++++++++++++++",
 	)
 
 	// Filtering is case-sensitive when filter is not all lower case.
 	f.TTYCtrl.Inject(term.K(ui.Backspace), term.K('L'))
 	f.TestTTY(t,
 		"~> \n",
-		" HISTORY (dedup on)  L", Styles,
+		" HISTORY (dedup on)  L", histlistStyles,
 		"********************  ", term.DotHere,
-		"                Ctrl-D dedup\n", Styles,
+		"                Ctrl-D dedup\n", histlistStyles,
 		"                ++++++      ",
-		"   4 LS                                           ", Styles,
-		"++++++++++++++++++++++++++++++++++++++++++++++++++",
+		"   4 LS                                           ", histlistStyles,
+		"+++++Y++++++++++++++++++++++++++++++++++++++++++++",
+	)
+}
+
+func TestHistlistAddon_Preview(t *testing.T) {
+	f := setup(t, storeOp(func(s storedefs.Store) {
+		s.AddCmd("ls")
+		s.AddCmd("echo")
+	}))
+
+	evals(f.Evaler, `set edit:histlist:preview = {|cmd| put 'cmd: ' (styled $cmd red) }`)
+	f.TTYCtrl.Inject(term.K('R', ui.Ctrl))
+	f.TestTTY(t,
+		"~> \n",
+		" HISTORY (dedup on)  ", Styles,
+		"******************** ", term.DotHere,
+		"                 Ctrl-D dedup\n", Styles,
+		"                 ++++++      ",
+		"   1 ls                  cmd: echo\n", Styles,
+		"                              !!!!",
+		"   2 echo               ", Styles,
+		"++++++++++++++++++++++++",
 	)
 }
 
@@ -179,6 +255,48 @@ func TestCustomListing_PassingList(t *testing.T) {
 	)
 }
 
+func TestCustomListing_AcceptMarked(t *testing.T) {
+	f := setup(t)
+
+	evals(f.Evaler,
+		`var items = [[&to-filter=1 &to-accept=a &to-show=a]
+		              [&to-filter=2 &to-accept=b &to-show=b]
+		              [&to-filter=3 &to-accept=c &to-show=c]]`,
+		`var accepted = $nil`,
+		`edit:listing:start-custom $items &accept={|x| set accepted = $x }`)
+	// Mark the first and last items, and accept.
+	f.TTYCtrl.Inject(term.K('T', ui.Ctrl), term.K(ui.Up), term.K(ui.Down),
+		term.K(ui.Down), term.K(ui.Down), term.K('T', ui.Ctrl), term.K('\n'))
+	f.TestTTY(t, "~> ", term.DotHere)
+	testGlobal(t, f.Evaler, "accepted", vals.MakeList("a", "c"))
+}
+
+func TestCustomListing_Preview(t *testing.T) {
+	f := setup(t)
+
+	evals(f.Evaler,
+		`var items = [[&to-filter=1 &to-accept=a &to-show=a]
+		              [&to-filter=2 &to-accept=b &to-show=b]]`,
+		`edit:listing:start-custom $items &caption=A &preview={|x| echo 'preview of '$x }`)
+	f.TestTTY(t,
+		"~> \n",
+		"A ", Styles,
+		"* ", term.DotHere, "\n",
+		"a                        preview of a\n", Styles,
+		"++++++++++++++++++++++++",
+		"b                        ",
+	)
+	f.TTYCtrl.Inject(term.K(ui.Down))
+	f.TestTTY(t,
+		"~> \n",
+		"A ", Styles,
+		"* ", term.DotHere, "\n",
+		"a                        preview of b\n",
+		"b                        ", Styles,
+		"++++++++++++++++++++++++ ",
+	)
+}
+
 func TestCustomListing_PassingValueCallback(t *testing.T) {
 	f := setup(t)
 
@@ -22,11 +22,15 @@ func navInsertSelected(app cli.App) {
 	if !ok {
 		return
 	}
-	fname := w.SelectedName()
-	if fname == "" {
-		// User pressed Alt-Enter or Enter in an empty directory with nothing
-		// selected; don't do anything.
-		return
+	fnames := w.MarkedNames()
+	if len(fnames) == 0 {
+		fname := w.SelectedName()
+		if fname == "" {
+			// User pressed Alt-Enter or Enter in an empty directory with
+			// nothing selected; don't do anything.
+			return
+		}
+		fnames = []string{fname}
 	}
 
 	codeArea.MutateState(func(s *tk.CodeAreaState) {
@@ -36,8 +40,12 @@ func navInsertSelected(app cli.App) {
 			// character is not a space or newline. Insert a space.
 			s.Buffer.InsertAtDot(" ")
 		}
-		// Insert the selected filename.
-		s.Buffer.InsertAtDot(parse.Quote(fname))
+		// Insert the marked or selected filenames.
+		quoted := make([]string, len(fnames))
+		for i, fname := range fnames {
+			quoted[i] = parse.Quote(fname)
+		}
+		s.Buffer.InsertAtDot(strings.Join(quoted, " "))
 	})
 }
 
@@ -98,7 +106,7 @@ func initNavigation(ed *Editor, ev *eval.Evaler, nb eval.NsBuilder) {
 					WidthRatio: func() [3]int {
 						return convertNavWidthRatio(widthRatioVar.Get())
 					},
-					Filter: filterSpec,
+					Filter: filterSpec(ed),
 					CodeAreaRPrompt: func() ui.Text {
 						return bindingTips(ed.ns, "navigation:binding",
 							bindingTip("hidden", "navigation:trigger-shown-hidden"),
@@ -127,6 +135,8 @@ func initNavigation(ed *Editor, ev *eval.Evaler, nb eval.NsBuilder) {
 			"file-preview-down": actOnNavigation(app,
 				func(w modes.Navigation) { w.ScrollPreview(1) }),
 
+			"toggle-mark": actOnNavigation(app, modes.Navigation.ToggleMarked),
+
 			"insert-selected":          func() { navInsertSelected(app) },
 			"insert-selected-and-quit": func() { navInsertSelectedAndQuit(app) },
 
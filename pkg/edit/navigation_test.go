@@ -91,6 +91,22 @@ func TestNavigation_EnterDoesNotAddSpaceAfterSpace(t *testing.T) {
 	)
 }
 
+func TestNavigation_InsertMarked(t *testing.T) {
+	f := setupNav(t)
+
+	feedInput(f.TTYCtrl, "put")
+	f.TTYCtrl.Inject(term.K('N', ui.Ctrl)) // begin navigation mode
+	f.TTYCtrl.Inject(term.K('T', ui.Ctrl)) // mark "a"
+	f.TTYCtrl.Inject(term.K(ui.Down))      // select "e"
+	f.TTYCtrl.Inject(term.K('T', ui.Ctrl)) // mark "e"
+	f.TTYCtrl.Inject(term.K(ui.Enter))     // insert the marked file names
+	f.TestTTY(t,
+		filepath.Join("~", "d"), "> ",
+		"put a e", Styles,
+		"vvv", term.DotHere,
+	)
+}
+
 // Test corner case: Inserting a selection when the CLI cursor is at the start
 // of the edit buffer omits the space char prefix.
 func TestNavigation_EnterDoesNotAddSpaceAtStartOfBuffer(t *testing.T) {
@@ -117,7 +133,8 @@ func TestNavigation_EnterDoesNotAddSpaceAtStartOfLine(t *testing.T) {
 		filepath.Join("~", "d"), "> ",
 		"put [", Styles,
 		"vvv b", "\n",
-		"     a", term.DotHere,
+		// The two spaces come from auto-indentation.
+		"       a", term.DotHere,
 	)
 }
 
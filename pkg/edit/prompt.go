@@ -90,7 +90,8 @@ func defaultStaleTransform(original ui.Text) ui.Text {
 }
 
 // Calls a function with the given arguments and closed input, and concatenates
-// its outputs to a styled text. Used to call prompts and stale transformers.
+// its outputs to a styled text. Used to call prompts, stale transformers and
+// previewers.
 func callForStyledText(nt notifier, ev *eval.Evaler, ctx string, fn eval.Callable, args ...any) ui.Text {
 	var (
 		result      ui.Text
@@ -101,7 +102,7 @@ func callForStyledText(nt notifier, ev *eval.Evaler, ctx string, fn eval.Callabl
 		defer resultMutex.Unlock()
 		newResult, err := result.Concat(v)
 		if err != nil {
-			nt.notifyf("invalid output type from prompt: %s", vals.Kind(v))
+			nt.notifyf("invalid output type from %s: %s", ctx, vals.Kind(v))
 		} else {
 			result = newResult.(ui.Text)
 		}
@@ -113,11 +114,11 @@ func callForStyledText(nt notifier, ev *eval.Evaler, ctx string, fn eval.Callabl
 			add(v)
 		}
 	}
-	// Byte output is added to the prompt as a single unstyled text.
+	// Byte output is added to the result as a single unstyled text.
 	bytesCb := func(r *os.File) {
 		allBytes, err := io.ReadAll(r)
 		if err != nil {
-			nt.notifyf("error reading prompt byte output: %v", err)
+			nt.notifyf("error reading %s byte output: %v", ctx, err)
 		}
 		if len(allBytes) > 0 {
 			add(ui.ParseSGREscapedText(string(allBytes)))
@@ -126,7 +127,7 @@ func callForStyledText(nt notifier, ev *eval.Evaler, ctx string, fn eval.Callabl
 
 	port1, done1, err := eval.PipePort(valuesCb, bytesCb)
 	if err != nil {
-		nt.notifyf("cannot create pipe for prompt: %v", err)
+		nt.notifyf("cannot create pipe for %s: %v", ctx, err)
 		return nil
 	}
 	port2, done2 := makeNotifyPort(nt)
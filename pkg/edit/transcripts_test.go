@@ -6,6 +6,8 @@ import (
 
 	"src.elv.sh/pkg/eval"
 	"src.elv.sh/pkg/eval/evaltest"
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/eval/vars"
 )
 
 //go:embed *.elvts
@@ -26,5 +28,13 @@ func TestTranscripts(t *testing.T) {
 		"add-vars-in-global", fnInGlobal("add-vars", addVars),
 		"del-var-in-global", fnInGlobal("del-var", delVar),
 		"del-vars-in-global", fnInGlobal("del-vars", delVars),
+		"complete-getopt-in-edit-ns", func(ev *eval.Evaler) {
+			// Enough of the edit: module for flag:completer and
+			// flag:register-completer.
+			ev.ExtendBuiltin(eval.BuildNs().AddNs("edit", eval.BuildNs().
+				AddGoFn("complete-getopt", completeGetopt).
+				AddNs("completion", eval.BuildNs().
+					AddVar("arg-completer", vars.FromInit(vals.EmptyMap)))))
+		},
 	)
 }
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"src.elv.sh/pkg/parse"
+	"src.elv.sh/pkg/parse/cmpd"
 )
 
 // Docs records doc comments.
@@ -104,17 +105,41 @@ var (
 	// Groups:
 	// 1. Name
 	idRegexp = regexp.MustCompile(`^#doc:id +(.+)`)
+	// Matches the start of a "pragma export" form, which may continue on
+	// following lines.
+	exportPragmaRegexp = regexp.MustCompile(`^pragma +export\b`)
 )
 
 const showUnstable = "#doc:show-unstable"
 
 // Extract extracts the elvdoc of one module from an Elvish source.
+//
+// If the source has "pragma export" forms at the top level, only the exported
+// functions and variables are included.
 func Extract(r io.Reader, symbolPrefix string) (Docs, error) {
 	var docs Docs
 	var block docBlock
+	var exports map[string]bool
+	var pragma []string
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := scanner.Text()
+		if pragma != nil || exportPragmaRegexp.MatchString(line) {
+			// Accumulate lines until they form a complete pragma.
+			pragma = append(pragma, line)
+			names, complete := parseExportPragma(strings.Join(pragma, "\n"))
+			if complete {
+				if exports == nil {
+					exports = make(map[string]bool)
+				}
+				for _, name := range names {
+					exports[name] = true
+				}
+				pragma = nil
+			}
+			block.consume()
+			continue
+		}
 		if line == "#" {
 			block.lines = append(block.lines, "")
 		} else if strings.HasPrefix(line, "# ") {
@@ -151,9 +176,48 @@ func Extract(r io.Reader, symbolPrefix string) (Docs, error) {
 		}
 	}
 
+	if exports != nil {
+		docs.Fns = filterEntries(docs.Fns, exports, "~")
+		docs.Vars = filterEntries(docs.Vars, exports, "")
+	}
 	return docs, scanner.Err()
 }
 
+// Parses a "pragma export" form, returning the exported names and whether the
+// form is complete. A malformed form is considered complete and exports
+// nothing.
+func parseExportPragma(code string) ([]string, bool) {
+	tree, err := parse.Parse(parse.Source{Code: code}, parse.Config{})
+	if err != nil {
+		// Errors at the end of the code indicate that it is incomplete.
+		for _, e := range parse.UnpackErrors(err) {
+			if e.Range().From == len(code) {
+				return nil, false
+			}
+		}
+		return nil, true
+	}
+	if len(tree.Root.Pipelines) != 1 || len(tree.Root.Pipelines[0].Forms) != 1 {
+		return nil, true
+	}
+	form := tree.Root.Pipelines[0].Forms[0]
+	if len(form.Args) != 3 {
+		return nil, true
+	}
+	names, _ := cmpd.NameList(form.Args[2], "")
+	return names, true
+}
+
+func filterEntries(entries []Entry, exports map[string]bool, suffix string) []Entry {
+	var filtered []Entry
+	for _, entry := range entries {
+		if exports[entry.Name+suffix] {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
 func fnUsage(name, sig string) string {
 	var sb strings.Builder
 	sb.WriteString("```elvish\n")
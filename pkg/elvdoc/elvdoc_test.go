@@ -250,6 +250,34 @@ var extractTests = []struct {
 			},
 		},
 	},
+	{
+		name: "only exported fns and vars with export pragma",
+		text: dedent(`
+			pragma export = [add
+			  $x]
+			# Adds numbers.
+			fn add {|a b| }
+			# Subtracts numbers.
+			fn sub {|a b| }
+			# X.
+			var x
+			# Y.
+			var y
+			`),
+		wantFns: []Entry{
+			{
+				Name: "add",
+				Content: dedent(tildeToBackquote(`
+						~~~elvish
+						add $a $b
+						~~~
+
+						Adds numbers.
+						`)),
+			},
+		},
+		wantVars: []Entry{{Name: "x", Content: "X.\n"}},
+	},
 }
 
 func TestExtract(t *testing.T) {
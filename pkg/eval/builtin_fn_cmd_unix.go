@@ -33,6 +33,9 @@ func execFn(fm *Frame, args ...any) error {
 		}
 	}
 
+	if err := fm.Evaler.Sandbox.CheckCommand(argstrings[0]); err != nil {
+		return err
+	}
 	var err error
 	argstrings[0], err = exec.LookPath(argstrings[0])
 	if err != nil {
@@ -3,6 +3,8 @@ package eval
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"src.elv.sh/pkg/eval/errs"
 	"src.elv.sh/pkg/eval/vals"
@@ -25,6 +27,12 @@ func init() {
 		"has-value": hasValue,
 
 		"keys": keys,
+
+		"get-in":      getIn,
+		"assoc-in":    assocIn,
+		"update-in":   updateIn,
+		"dissoc-in":   dissocIn,
+		"select-path": selectPath,
 	})
 }
 
@@ -135,3 +143,232 @@ func keys(fm *Frame, v any) error {
 	}
 	return errPut
 }
+
+// PathError is thrown by get-in, assoc-in, update-in and dissoc-in when a
+// segment of the path can't be used.
+type PathError struct {
+	Path []any
+	// Index of the segment that can't be used.
+	Segment int
+	Err     error
+}
+
+func (e PathError) Error() string {
+	return fmt.Sprintf("path %s, segment %d (%s): %v",
+		vals.ReprPlain(vals.MakeListSlice(e.Path)), e.Segment,
+		vals.ReprPlain(e.Path[e.Segment]), e.Err)
+}
+
+func (e PathError) Unwrap() error { return e.Err }
+
+var errEmptyPath = errs.BadValue{What: "path", Valid: "non-empty list", Actual: "[]"}
+
+func collectPath(v any) ([]any, error) {
+	if _, ok := v.(vals.List); !ok {
+		return nil, errs.BadValue{What: "path", Valid: "list", Actual: vals.Kind(v)}
+	}
+	return vals.Collect(v)
+}
+
+func getIn(container, pathVal any) (any, error) {
+	path, err := collectPath(pathVal)
+	if err != nil {
+		return nil, err
+	}
+	v := container
+	for i, k := range path {
+		v, err = vals.Index(v, k)
+		if err != nil {
+			return nil, PathError{path, i, err}
+		}
+	}
+	return v, nil
+}
+
+func assocIn(container, pathVal, value any) (any, error) {
+	path, err := collectPath(pathVal)
+	if err != nil {
+		return nil, err
+	}
+	return updatePath(container, path, 0, true,
+		func(any) (any, error) { return value, nil })
+}
+
+func updateIn(fm *Frame, container, pathVal any, f Callable) (any, error) {
+	path, err := collectPath(pathVal)
+	if err != nil {
+		return nil, err
+	}
+	return updatePath(container, path, 0, false, func(old any) (any, error) {
+		outputs, err := fm.CaptureOutput(func(fm *Frame) error {
+			return f.Call(fm, []any{old}, NoOpts)
+		})
+		if err != nil {
+			return nil, err
+		} else if len(outputs) != 1 {
+			return nil, errs.ArityMismatch{
+				What:     "number of outputs of the function",
+				ValidLow: 1, ValidHigh: 1, Actual: len(outputs)}
+		}
+		return outputs[0], nil
+	})
+}
+
+// Returns a copy of container with the value at path[i:] replaced by the
+// return value of f, which is called with the existing value. If create is
+// true, keys missing from maps are created, with intermediate values being
+// empty maps, and f is not passed the existing value.
+func updatePath(container any, path []any, i int, create bool, f func(any) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return nil, errEmptyPath
+	}
+	k := path[i]
+	var newValue any
+	if create && i == len(path)-1 {
+		v, err := f(nil)
+		if err != nil {
+			return nil, err
+		}
+		newValue = v
+	} else {
+		child, err := vals.Index(container, k)
+		if err != nil {
+			if _, isMap := container.(vals.Map); !create || !isMap {
+				return nil, PathError{path, i, err}
+			}
+			child = vals.EmptyMap
+		}
+		if i == len(path)-1 {
+			newValue, err = f(child)
+		} else {
+			newValue, err = updatePath(child, path, i+1, create, f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	updated, err := vals.Assoc(container, k, newValue)
+	if err != nil {
+		return nil, PathError{path, i, err}
+	}
+	return updated, nil
+}
+
+func dissocIn(container, pathVal any) (any, error) {
+	path, err := collectPath(pathVal)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, errEmptyPath
+	}
+	last := len(path) - 1
+	if last == 0 {
+		return dissocPathSegment(container, path, last)
+	}
+	updated, err := updatePath(container, path[:last], 0, false, func(parent any) (any, error) {
+		return dissocPathSegment(parent, path, last)
+	})
+	if pathErr, ok := err.(PathError); ok {
+		// Show the full path in the error.
+		pathErr.Path = path
+		return nil, pathErr
+	}
+	return updated, err
+}
+
+func dissocPathSegment(container any, path []any, i int) (any, error) {
+	updated := vals.Dissoc(container, path[i])
+	if updated == nil {
+		return nil, PathError{path, i, errCannotDissoc}
+	}
+	return updated, nil
+}
+
+func selectPath(fm *Frame, data, pattern any) error {
+	var segs []any
+	if s, ok := pattern.(string); ok {
+		for _, seg := range strings.Split(s, ".") {
+			segs = append(segs, seg)
+		}
+	} else if list, ok := pattern.(vals.List); ok {
+		for it := list.Iterator(); it.HasElem(); it.Next() {
+			segs = append(segs, it.Elem())
+		}
+	} else {
+		return errs.BadValue{What: "pattern",
+			Valid: "string or list", Actual: vals.Kind(pattern)}
+	}
+	out := fm.ValueOutput()
+	var errPut error
+	matchPath(data, segs, func(v any) bool {
+		errPut = out.Put(v)
+		return errPut == nil
+	})
+	return errPut
+}
+
+// Calls f with each value within v matching segs, until f returns false.
+// Returns false if the matching was aborted.
+func matchPath(v any, segs []any, f func(any) bool) bool {
+	if len(segs) == 0 {
+		return f(v)
+	}
+	switch segs[0] {
+	case "*":
+		return eachChild(v, func(child any) bool {
+			return matchPath(child, segs[1:], f)
+		})
+	case "**":
+		// Match zero levels, then one or more levels.
+		return matchPath(v, segs[1:], f) && eachChild(v, func(child any) bool {
+			return matchPath(child, segs, f)
+		})
+	default:
+		if _, ok := v.(string); ok {
+			// Don't match the characters in strings.
+			return true
+		}
+		child, err := vals.Index(v, segs[0])
+		if err != nil {
+			return true
+		}
+		return matchPath(child, segs[1:], f)
+	}
+}
+
+// Calls f with each element of a list, or each value of a map, until f returns
+// false. Values of maps are visited in the order of their keys. Returns false
+// if the iteration was aborted.
+func eachChild(v any, f func(any) bool) bool {
+	switch v := v.(type) {
+	case string:
+		return true
+	case vals.List:
+		for it := v.Iterator(); it.HasElem(); it.Next() {
+			if !f(it.Elem()) {
+				return false
+			}
+		}
+		return true
+	}
+	var keys []any
+	if vals.IterateKeys(v, func(k any) bool {
+		keys = append(keys, k)
+		return true
+	}) != nil {
+		return true
+	}
+	if _, ok := v.(vals.Map); ok {
+		sort.Slice(keys, func(i, j int) bool {
+			return vals.CmpTotal(keys[i], keys[j]) == vals.CmpLess
+		})
+	}
+	for _, k := range keys {
+		child, err := vals.Index(v, k)
+		if err == nil && !f(child) {
+			return false
+		}
+	}
+	return true
+}
@@ -104,15 +104,20 @@ func (o *eawkOpt) SetDefaultOptions() {
 	o.Sep = "[ \t]+"
 }
 
-// Eawk implements the re:awk command and the deprecated eawk command. It is
-// put in this package and exported since this package can't depend on
+// Eawk implements the deprecated eawk command, and most of the re:awk command.
+// It is put in this package and exported since this package can't depend on
 // src.elv.sh/pkg/mods/re.
 func Eawk(fm *Frame, opts eawkOpt, f Callable, inputs Inputs) error {
 	wordSep, err := makePattern(opts.Sep, opts.SepPosix, opts.SepLongest)
 	if err != nil {
 		return err
 	}
+	return EawkWithSep(fm, wordSep, f, inputs)
+}
 
+// EawkWithSep is like [Eawk], but takes an already compiled field separator.
+func EawkWithSep(fm *Frame, wordSep *regexp.Regexp, f Callable, inputs Inputs) error {
+	var err error
 	broken := false
 	inputs(func(v any) {
 		if broken {
@@ -0,0 +1,417 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strings"
+
+	"src.elv.sh/pkg/eval/errs"
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/sys"
+	"src.elv.sh/pkg/ui"
+	"src.elv.sh/pkg/wcwidth"
+)
+
+// Tabular output.
+
+func init() {
+	addBuiltinFns(map[string]any{
+		"table": table,
+	})
+}
+
+type tableOpts struct {
+	Columns any
+	SortBy  any
+	Reverse bool
+	Width   int
+	Format  string
+}
+
+func (o *tableOpts) SetDefaultOptions() { o.Format = "text" }
+
+// Minimum width a column can be truncated to to fit the table within the
+// width limit.
+const tableMinColumnWidth = 4
+
+// Separator between columns in the text format.
+const tableColumnSep = "  "
+
+type tableColumn struct {
+	key    any
+	header string
+	// Whether all the cells in the column are numbers, in which case they are
+	// aligned to the right.
+	numeric bool
+}
+
+// A cell of the table. The present field is false if the row lacks the
+// column.
+type tableCell struct {
+	value   any
+	present bool
+}
+
+func table(fm *Frame, opts tableOpts, inputs Inputs) error {
+	var writeTable func(*Frame, []tableColumn, [][]tableCell, int) error
+	switch opts.Format {
+	case "text":
+		writeTable = writeTableText
+	case "csv":
+		writeTable = writeTableCSV
+	case "markdown":
+		writeTable = writeTableMarkdown
+	case "json":
+		writeTable = writeTableJSON
+	default:
+		return errs.BadValue{What: "&format",
+			Valid: "text, csv, markdown or json", Actual: opts.Format}
+	}
+
+	var rows []any
+	var errInput error
+	inputs(func(v any) {
+		if errInput != nil {
+			return
+		}
+		if err := vals.IterateKeys(v, func(any) bool { return false }); err != nil {
+			errInput = errs.BadValue{What: "input to table",
+				Valid: "map or struct map", Actual: vals.Kind(v)}
+			return
+		}
+		rows = append(rows, v)
+	})
+	if errInput != nil {
+		return errInput
+	}
+
+	var columns []tableColumn
+	if opts.Columns != nil {
+		err := vals.Iterate(opts.Columns, func(v any) bool {
+			columns = append(columns, tableColumn{key: v, header: vals.ToString(v)})
+			return true
+		})
+		if err != nil {
+			return errs.BadValue{What: "&columns",
+				Valid: "list of column names", Actual: vals.Kind(opts.Columns)}
+		}
+	} else {
+		columns = tableColumnsOf(rows)
+	}
+
+	if opts.SortBy != nil {
+		keyOf := func(row any) any {
+			v, _ := vals.Index(row, opts.SortBy)
+			return v
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			o := vals.CmpTotal(keyOf(rows[i]), keyOf(rows[j]))
+			if opts.Reverse {
+				return o == vals.CmpMore
+			}
+			return o == vals.CmpLess
+		})
+	} else if opts.Reverse {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	cells := make([][]tableCell, len(rows))
+	for i := range columns {
+		columns[i].numeric = len(rows) > 0
+	}
+	for i, row := range rows {
+		cells[i] = make([]tableCell, len(columns))
+		for j, column := range columns {
+			v, err := vals.Index(row, column.key)
+			if err != nil {
+				continue
+			}
+			cells[i][j] = tableCell{v, true}
+			if !isNum(v) {
+				columns[j].numeric = false
+			}
+		}
+	}
+
+	width := opts.Width
+	if width == 0 {
+		if out := fm.Port(1).File; out != nil {
+			_, width = sys.WinSize(out)
+		}
+	}
+	return writeTable(fm, columns, cells, width)
+}
+
+// Returns the union of the keys of all the rows, in the order they first
+// appear. Keys of maps are sorted, while fields of struct maps keep their
+// order.
+func tableColumnsOf(rows []any) []tableColumn {
+	var columns []tableColumn
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		var keys []any
+		vals.IterateKeys(row, func(k any) bool {
+			keys = append(keys, k)
+			return true
+		})
+		if _, ok := row.(vals.Map); ok {
+			sort.Slice(keys, func(i, j int) bool {
+				return vals.CmpTotal(keys[i], keys[j]) == vals.CmpLess
+			})
+		}
+		for _, k := range keys {
+			header := vals.ToString(k)
+			if !seen[header] {
+				seen[header] = true
+				columns = append(columns, tableColumn{key: k, header: header})
+			}
+		}
+	}
+	return columns
+}
+
+func isNum(v any) bool {
+	switch v.(type) {
+	case int, *big.Int, *big.Rat, float64:
+		return true
+	}
+	return false
+}
+
+// Converts a cell to styled text. Newlines are replaced with spaces to keep
+// each row on one line.
+func (c tableCell) text() ui.Text {
+	if !c.present {
+		return nil
+	}
+	var t ui.Text
+	switch v := c.value.(type) {
+	case ui.Text:
+		t = v.Clone()
+	case *ui.Segment:
+		t = ui.TextFromSegment(v.Clone())
+	default:
+		t = ui.T(vals.ToString(v))
+	}
+	for _, seg := range t {
+		seg.Text = strings.ReplaceAll(seg.Text, "\n", " ")
+	}
+	return t
+}
+
+// Converts a cell to plain text, discarding any styles.
+func (c tableCell) plain() string {
+	return plainText(c.text())
+}
+
+func plainText(t ui.Text) string {
+	var sb strings.Builder
+	for _, seg := range t {
+		sb.WriteString(seg.Text)
+	}
+	return sb.String()
+}
+
+// Like t.VTString, but only outputs escape sequences around segments that are
+// styled.
+func vtStringIfStyled(t ui.Text) string {
+	var sb strings.Builder
+	for _, seg := range t {
+		if sgr := seg.SGR(); sgr != "" {
+			sb.WriteString("\033[;" + sgr + "m" + seg.Text + "\033[m")
+		} else {
+			sb.WriteString(seg.Text)
+		}
+	}
+	return sb.String()
+}
+
+func writeTableText(fm *Frame, columns []tableColumn, cells [][]tableCell, maxWidth int) error {
+	texts := make([][]ui.Text, len(cells)+2)
+	widths := make([]int, len(columns))
+	for j, column := range columns {
+		widths[j] = wcwidth.Of(column.header)
+	}
+	for i, row := range cells {
+		texts[i+2] = make([]ui.Text, len(columns))
+		for j, cell := range row {
+			t := cell.text()
+			texts[i+2][j] = t
+			if w := wcwidth.Of(plainText(t)); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+
+	if maxWidth > 0 {
+		shrinkTableColumns(widths, maxWidth)
+	}
+
+	// The header and a line under it.
+	texts[0] = make([]ui.Text, len(columns))
+	texts[1] = make([]ui.Text, len(columns))
+	for j, column := range columns {
+		texts[0][j] = ui.T(column.header)
+		texts[1][j] = ui.T(strings.Repeat("-", widths[j]))
+	}
+
+	var buf bytes.Buffer
+	for _, row := range texts {
+		// Empty cells at the end of the row are omitted to avoid trailing
+		// spaces.
+		last := len(row) - 1
+		for last > 0 && len(row[last]) == 0 {
+			last--
+		}
+		var line ui.Text
+		for j, t := range row[:last+1] {
+			if j > 0 {
+				line = append(line, &ui.Segment{Text: tableColumnSep})
+			}
+			line = append(line, fitTableCell(t, widths[j], columns[j].numeric, j == last)...)
+		}
+		buf.WriteString(vtStringIfStyled(line))
+		buf.WriteByte('\n')
+	}
+	_, err := fm.ByteOutput().Write(buf.Bytes())
+	return err
+}
+
+// Shrinks the widest columns until the table fits in maxWidth, or all columns
+// have been shrunk to tableMinColumnWidth.
+func shrinkTableColumns(widths []int, maxWidth int) {
+	total := len(tableColumnSep) * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for total > maxWidth {
+		widest := 0
+		for j, w := range widths {
+			if w > widths[widest] {
+				widest = j
+			}
+		}
+		if widths[widest] <= tableMinColumnWidth {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// Truncates or pads t to the given width. The last column in a row is not
+// padded on the right.
+func fitTableCell(t ui.Text, width int, alignRight, last bool) ui.Text {
+	w := wcwidth.Of(plainText(t))
+	if w > width {
+		t = append(t.TrimWcwidth(width-1), &ui.Segment{Text: "…"})
+		w = wcwidth.Of(plainText(t))
+	}
+	padding := &ui.Segment{Text: strings.Repeat(" ", width-w)}
+	switch {
+	case alignRight:
+		return append(ui.Text{padding}, t...)
+	case last:
+		return t
+	default:
+		return append(t, padding)
+	}
+}
+
+func writeTableCSV(fm *Frame, columns []tableColumn, cells [][]tableCell, _ int) error {
+	w := csv.NewWriter(fm.ByteOutput())
+	record := make([]string, len(columns))
+	for j, column := range columns {
+		record[j] = column.header
+	}
+	w.Write(record)
+	for _, row := range cells {
+		for j, cell := range row {
+			record[j] = cell.plain()
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeTableMarkdown(fm *Frame, columns []tableColumn, cells [][]tableCell, _ int) error {
+	var buf bytes.Buffer
+	writeRow := func(row []string) {
+		buf.WriteString("|")
+		for _, s := range row {
+			buf.WriteString(" " + strings.ReplaceAll(s, "|", `\|`) + " |")
+		}
+		buf.WriteString("\n")
+	}
+	row := make([]string, len(columns))
+	for j, column := range columns {
+		row[j] = column.header
+	}
+	writeRow(row)
+	buf.WriteString("|")
+	for _, column := range columns {
+		if column.numeric {
+			buf.WriteString(" ---: |")
+		} else {
+			buf.WriteString(" --- |")
+		}
+	}
+	buf.WriteString("\n")
+	for _, cellsRow := range cells {
+		for j, cell := range cellsRow {
+			row[j] = cell.plain()
+		}
+		writeRow(row)
+	}
+	_, err := fm.ByteOutput().Write(buf.Bytes())
+	return err
+}
+
+// Writes the table as a JSON array of objects. Unlike to-json, the keys of
+// each object are in the order of the columns, and missing cells are omitted.
+func writeTableJSON(fm *Frame, columns []tableColumn, cells [][]tableCell, _ int) error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, row := range cells {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("{")
+		first := true
+		for j, cell := range row {
+			if !cell.present {
+				continue
+			}
+			if !first {
+				buf.WriteString(",")
+			}
+			first = false
+			key, err := json.Marshal(columns[j].header)
+			if err != nil {
+				return err
+			}
+			v := cell.value
+			switch cell.value.(type) {
+			case ui.Text, *ui.Segment:
+				v = cell.plain()
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteString(":")
+			buf.Write(value)
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString("]\n")
+	_, err := fm.ByteOutput().Write(buf.Bytes())
+	return err
+}
@@ -29,22 +29,9 @@ var (
 )
 
 func sleep(fm *Frame, duration any) error {
-	var f float64
-	var d time.Duration
-
-	if err := vals.ScanToGo(duration, &f); err == nil {
-		d = time.Duration(f * float64(time.Second))
-	} else {
-		// See if it is a duration string rather than a simple number.
-		switch duration := duration.(type) {
-		case string:
-			d, err = time.ParseDuration(duration)
-			if err != nil {
-				return ErrInvalidSleepDuration
-			}
-		default:
-			return ErrInvalidSleepDuration
-		}
+	d, ok := scanDuration(duration)
+	if !ok {
+		return ErrInvalidSleepDuration
 	}
 
 	if d < 0 {
@@ -59,6 +46,42 @@ func sleep(fm *Frame, duration any) error {
 	}
 }
 
+// Converts a number of seconds or a string accepted by [time.ParseDuration] to
+// a duration.
+func scanDuration(v any) (time.Duration, bool) {
+	var f float64
+	if err := vals.ScanToGo(v, &f); err == nil {
+		return time.Duration(f * float64(time.Second)), true
+	}
+	// See if it is a duration string rather than a simple number.
+	if s, ok := v.(string); ok {
+		d, err := time.ParseDuration(s)
+		return d, err == nil
+	}
+	return 0, false
+}
+
+// ParsePositiveDuration parses a positive duration, which can be a number of
+// seconds or a string accepted by [time.ParseDuration], like the argument of
+// sleep. The what argument is used to describe the value in errors, for
+// example "&timeout".
+func ParsePositiveDuration(what string, v any) (time.Duration, error) {
+	d, ok := scanDuration(v)
+	if !ok {
+		actual := vals.ReprPlain(v)
+		if s, isString := v.(string); isString {
+			actual = parse.Quote(s)
+		}
+		return 0, errs.BadValue{What: what,
+			Valid: "number or duration string", Actual: actual}
+	}
+	if d <= 0 {
+		return 0, errs.BadValue{What: what,
+			Valid: "positive duration", Actual: d.String()}
+	}
+	return d, nil
+}
+
 type timeOpt struct{ OnEnd Callable }
 
 func (o *timeOpt) SetDefaultOptions() {}
@@ -18,6 +18,7 @@ package eval
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -178,6 +179,9 @@ type delEnvVarOp struct {
 }
 
 func (op delEnvVarOp) exec(fm *Frame) Exception {
+	if err := fm.Evaler.Sandbox.CheckEnvMutation(op.name); err != nil {
+		return fm.errorp(op, err)
+	}
 	return fm.errorp(op, os.Unsetenv(op.name))
 }
 
@@ -275,13 +279,25 @@ func (op fnWrap) exec(fm *Frame) Exception {
 	return nil
 }
 
-// UseForm = 'use' StringPrimary
+// UseForm = 'use' StringPrimary [ StringPrimary | ListPrimary ]
 func compileUse(cp *compiler, fn *parse.Form) effectOp {
 	args := getArgs(cp, fn)
 	spec := args.get(0, "module spec").stringLiteral()
 	name := ""
+	var importNames []string
 	if args.has(1) {
-		name = args.get(1, "module name").stringLiteral()
+		arg := fn.Args[1]
+		if pn, ok := cmpd.Primary(arg); ok && pn.Type == parse.List {
+			// Selective import
+			var err error
+			importNames, err = cmpd.NameList(arg, "imported names")
+			if err != nil {
+				cp.errorpf(arg, "%v", err)
+			}
+			args.get(1, "imported names")
+		} else {
+			name = args.get(1, "module name").stringLiteral()
+		}
 	} else {
 		name = spec[strings.LastIndexByte(spec, '/')+1:]
 	}
@@ -289,13 +305,30 @@ func compileUse(cp *compiler, fn *parse.Form) effectOp {
 		return nil
 	}
 
-	return useOp{fn.Range(), cp.thisScope().add(name + NsSuffix), spec}
+	if importNames == nil {
+		return useOp{fn.Range(), cp.thisScope().add(name + NsSuffix), spec, nil}
+	}
+	imports := make([]importedVar, len(importNames))
+	for i, name := range importNames {
+		imports[i] = importedVar{name, cp.thisScope().add(name)}
+	}
+	return useOp{fn.Range(), -1, spec, imports}
 }
 
 type useOp struct {
 	diag.Ranging
+	// Index of the variable to store the namespace in, or -1 for selective
+	// imports.
 	varIndex int
 	spec     string
+	imports  []importedVar
+}
+
+// A variable imported with a selective import, like the "join" in "use str
+// [join]".
+type importedVar struct {
+	name  string
+	index int
 }
 
 func (op useOp) exec(fm *Frame) Exception {
@@ -303,56 +336,141 @@ func (op useOp) exec(fm *Frame) Exception {
 	if err != nil {
 		return fm.errorp(op, err)
 	}
-	fm.local.slots[op.varIndex].Set(ns)
+	if op.varIndex != -1 {
+		fm.local.slots[op.varIndex].Set(ns)
+		return nil
+	}
+	for _, imp := range op.imports {
+		variable := ns.IndexString(imp.name)
+		if variable == nil {
+			return fm.errorpf(op, "module %s doesn't export %s", op.spec, showVarName(imp.name))
+		}
+		// Share the variable with the module, like using it as $mod:name.
+		fm.local.slots[imp.index] = variable
+	}
 	return nil
 }
 
 // TODO: Add support for module specs relative to a package/workspace.
 // See https://github.com/elves/elvish/issues/1421.
 func use(fm *Frame, spec string, r diag.Ranger) (*Ns, error) {
-	// Handle relative imports. Note that this deliberately does not support Windows backslash as a
-	// path separator because module specs are meant to be platform independent. If necessary, we
-	// translate a module spec to an appropriate path for the platform.
-	if strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") {
-		var dir string
-		if fm.srcMeta.IsFile {
-			dir = filepath.Dir(fm.srcMeta.Name)
-		} else {
-			var err error
-			dir, err = os.Getwd()
-			if err != nil {
-				return nil, err
-			}
+	if err := fm.Evaler.Sandbox.CheckUse(spec); err != nil {
+		return nil, err
+	}
+	if !isRelativeSpec(spec) {
+		// Handle imports of pre-defined modules like `builtin` and `str`.
+		if ns, ok := fm.Evaler.modules[spec]; ok {
+			return ns, nil
+		}
+		if code, ok := fm.Evaler.BundledModules[spec]; ok {
+			return evalModule(fm, spec,
+				parse.Source{Name: "[bundled " + spec + "]", Code: code}, r)
 		}
-		path := filepath.Clean(dir + "/" + spec)
-		return useFromFile(fm, spec, path, r)
+	}
+	if fm.Evaler.Bundle != nil {
+		return useFromBundle(fm, spec, r)
 	}
 
-	// Handle imports of pre-defined modules like `builtin` and `str`.
-	if ns, ok := fm.Evaler.modules[spec]; ok {
-		return ns, nil
+	dir, err := fm.srcDir()
+	if err != nil {
+		return nil, err
 	}
-	if code, ok := fm.Evaler.BundledModules[spec]; ok {
-		return evalModule(fm, spec,
-			parse.Source{Name: "[bundled " + spec + "]", Code: code}, r)
+	paths, err := modulePaths(spec, dir, fm.Evaler.LibDirs, fm.Evaler.findManifest)
+	if err != nil {
+		return nil, err
 	}
-
-	// Handle imports relative to the Elvish module search directories.
-	//
 	// TODO: For non-relative imports, use the spec (instead of the full path)
 	// as the module key instead to avoid searching every time.
-	for _, dir := range fm.Evaler.LibDirs {
-		ns, err := useFromFile(fm, spec, filepath.Join(dir, spec), r)
-		if _, isNoSuchModule := err.(NoSuchModule); isNoSuchModule {
+	for _, path := range paths {
+		ns, err := useFromFile(fm, spec, path, r)
+		if isNoSuchModule(err) {
 			continue
 		}
 		return ns, err
 	}
-
 	// Sadly, we couldn't resolve the module spec.
 	return nil, NoSuchModule{spec}
 }
 
+// Resolves a module spec used from the code being executed with the bundle,
+// without accessing the filesystem.
+func useFromBundle(fm *Frame, spec string, r diag.Ranger) (*Ns, error) {
+	b := fm.Evaler.Bundle
+	path, ok := b.Uses[fm.srcMeta.Name][spec]
+	if !ok {
+		return nil, NoSuchModule{spec}
+	}
+	if ns, ok := fm.Evaler.modules[path]; ok {
+		return ns, nil
+	}
+	code, err := fs.ReadFile(b.FS, path)
+	if err != nil {
+		return nil, err
+	}
+	src := parse.Source{Name: path, Code: string(code), IsFile: true}
+	return evalModule(fm, path, src, r)
+}
+
+// FindModuleFile returns the path of the source file of a user-defined module,
+// resolving the module spec in the same way as use from code in dir, with
+// libDirs as the module search directories. It returns a NoSuchModule error
+// if there is no such file, which is always the case for pre-defined modules.
+func FindModuleFile(spec, dir string, libDirs []string) (string, error) {
+	paths, err := modulePaths(spec, dir, libDirs, FindManifest)
+	if err != nil {
+		return "", err
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(path + ".elv"); err == nil && info.Mode().IsRegular() {
+			return path + ".elv", nil
+		}
+	}
+	return "", NoSuchModule{spec}
+}
+
+// Returns the paths (without extensions) where a module spec may be found
+// when used from code in dir, in order of precedence. The manifest that
+// applies to dir is found with findManifest.
+func modulePaths(spec, dir string, libDirs []string, findManifest func(string) (*Manifest, error)) ([]string, error) {
+	// Handle relative imports. Note that this deliberately does not support Windows backslash as a
+	// path separator because module specs are meant to be platform independent. If necessary, we
+	// translate a module spec to an appropriate path for the platform.
+	if isRelativeSpec(spec) {
+		return []string{filepath.Clean(dir + "/" + spec)}, nil
+	}
+
+	var paths []string
+	// Handle imports using the project manifest, if there is one. Modules
+	// are keyed by their full paths, so different projects can use different
+	// versions of the same module.
+	manifest, err := findManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		if path, ok := manifest.resolvePackage(spec); ok {
+			return []string{path}, nil
+		}
+		for _, libDir := range manifest.LibDirs {
+			paths = append(paths, filepath.Join(libDir, spec))
+		}
+	}
+	// Handle imports relative to the Elvish module search directories.
+	for _, libDir := range libDirs {
+		paths = append(paths, filepath.Join(libDir, spec))
+	}
+	return paths, nil
+}
+
+func isRelativeSpec(spec string) bool {
+	return strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../")
+}
+
+func isNoSuchModule(err error) bool {
+	_, ok := err.(NoSuchModule)
+	return ok
+}
+
 // TODO: Make access to fm.Evaler.modules concurrency-safe.
 func useFromFile(fm *Frame, spec, path string, r diag.Ranger) (*Ns, error) {
 	if ns, ok := fm.Evaler.modules[path]; ok {
@@ -371,6 +489,10 @@ func useFromFile(fm *Frame, spec, path string, r diag.Ranger) (*Ns, error) {
 		return evalModule(fm, path, src, r)
 	}
 
+	if fm.Evaler.Sandbox != nil {
+		// Plugins run native code, which can't be restricted.
+		return nil, SandboxViolation{"use", spec}
+	}
 	plug, err := pluginOpen(path + ".so")
 	if err != nil {
 		return nil, NoSuchModule{spec}
@@ -400,7 +522,7 @@ func readFileUTF8(fname string) (string, error) {
 
 // TODO: Make access to fm.Evaler.modules concurrency-safe.
 func evalModule(fm *Frame, key string, src parse.Source, r diag.Ranger) (*Ns, error) {
-	ns, exec, err := fm.PrepareEval(src, r, new(Ns))
+	ns, exec, exports, err := fm.prepareEval(src, r, new(Ns))
 	if err != nil {
 		return nil, err
 	}
@@ -408,6 +530,13 @@ func evalModule(fm *Frame, key string, src parse.Source, r diag.Ranger) (*Ns, er
 	// from resulting in an infinite recursion.
 	fm.Evaler.modules[key] = ns
 	err = exec()
+	if err == nil && exports != nil {
+		// Only keep the exported names in the namespace. Circular use'es
+		// still see the full namespace, since it has been installed before
+		// executing.
+		ns, err = ns.restrict(exports)
+		fm.Evaler.modules[key] = ns
+	}
 	if err != nil {
 		// Unload the namespace.
 		delete(fm.Evaler.modules, key)
@@ -796,6 +925,21 @@ func compilePragma(cp *compiler, fn *parse.Form) effectOp {
 	}
 
 	switch name {
+	case "export":
+		if len(cp.scopes) > 1 {
+			cp.errorpf(fn, "pragma export can only be used at the top level")
+			break
+		}
+		names, err := cmpd.NameList(valueNode, "value for export")
+		if err != nil {
+			cp.errorpf(valueNode, "%v", err)
+			break
+		}
+		if cp.exports == nil {
+			// Distinguish "pragma export = []" from no export pragma.
+			cp.exports = []string{}
+		}
+		cp.exports = append(cp.exports, names...)
 	case "unknown-command":
 		value := stringLiteralOrError(cp, valueNode, "value for unknown-command")
 		switch value {
@@ -0,0 +1,19 @@
+package eval
+
+import "io/fs"
+
+// Bundle contains the source files of a program bundled into an executable
+// with "elvish -bundle", and how the module specs used in them are resolved.
+//
+// When an Evaler has a Bundle, module specs that don't refer to predefined
+// modules are resolved with the Bundle only; the filesystem, module search
+// directories and project manifests are never consulted.
+type Bundle struct {
+	// Source files, indexed by slash-separated paths.
+	FS fs.FS `json:"-"`
+	// Path of the entry script.
+	Main string `json:"main"`
+	// Maps the path of a file and a module spec used in it to the path of the
+	// module.
+	Uses map[string]map[string]string `json:"uses"`
+}
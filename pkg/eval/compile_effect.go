@@ -516,6 +516,9 @@ func (op *redirOp) exec(fm *Frame) Exception {
 	}
 	switch src := src.(type) {
 	case string:
+		if err := checkRedirFile(fm.Evaler.Sandbox, op.mode, src); err != nil {
+			return fm.errorp(op, err)
+		}
 		f, err := os.OpenFile(src, op.flag, defaultFileRedirPerm)
 		if err != nil {
 			return fm.errorpf(op, "failed to open file %s: %s", vals.ReprPlain(src), err)
@@ -558,6 +561,19 @@ func (op *redirOp) exec(fm *Frame) Exception {
 	return nil
 }
 
+// Checks whether the file can be opened for the redirection mode.
+func checkRedirFile(sb *Sandbox, mode parse.RedirMode, name string) error {
+	if mode == parse.Read || mode == parse.ReadWrite {
+		if err := sb.CheckRead(name); err != nil {
+			return err
+		}
+	}
+	if mode != parse.Read {
+		return sb.CheckWrite(name)
+	}
+	return nil
+}
+
 // Creates a port that only have a file component, populating the
 // channel-related fields with suitable values depending on the redirection
 // mode.
@@ -35,6 +35,8 @@ type compiler struct {
 	errors []*CompilationError
 	// Suggested code to fix potential issues found during compilation.
 	autofixes []string
+	// Names exported with "pragma export"; nil if there is no such pragma.
+	exports []string
 }
 
 type scopePragma struct {
@@ -47,14 +49,16 @@ func compile(b, g *staticNs, modules []string, tree parse.Tree, w io.Writer) (ns
 		b, []*staticNs{g}, []*staticUpNs{new(staticUpNs)},
 		[]*scopePragma{{unknownCommandIsExternal: true}},
 		modules,
-		w, newDeprecationRegistry(), tree.Source, nil, nil}
+		w, newDeprecationRegistry(), tree.Source, nil, nil, nil}
 	chunkOp := cp.chunkOp(tree.Root)
-	return nsOp{chunkOp, g}, cp.autofixes, diag.PackErrors(cp.errors)
+	return nsOp{chunkOp, g, cp.exports}, cp.autofixes, diag.PackErrors(cp.errors)
 }
 
 type nsOp struct {
 	inner    effectOp
 	template *staticNs
+	// Names exported with "pragma export"; nil if there is no such pragma.
+	exports []string
 }
 
 // Prepares the local namespace, and returns the namespace and a function for
@@ -52,6 +52,12 @@ type Evaler struct {
 	LibDirs []string
 	// Source code of internal bundled modules indexed by use specs.
 	BundledModules map[string]string
+	// If non-nil, user-defined modules are loaded from the bundle instead of
+	// the filesystem.
+	Bundle *Bundle
+	// If non-nil, restricts what the evaluated code can do. Must not be
+	// mutated once the Evaler is used to evaluate any code.
+	Sandbox *Sandbox
 	// Callback to notify the success or failure of background jobs. Must not be
 	// mutated once the Evaler is used to evaluate any code.
 	BgJobNotify func(string)
@@ -75,6 +81,8 @@ type Evaler struct {
 	// Internal modules are indexed by use specs. External modules are indexed by
 	// absolute paths.
 	modules map[string]*Ns
+	// Results of finding manifests, indexed by directories.
+	manifests map[string]cachedManifest
 
 	// Various states and configs exposed to Elvish code.
 	//
@@ -100,6 +108,7 @@ func NewEvaler() *Evaler {
 		deprecations: newDeprecationRegistry(),
 
 		modules:        make(map[string]*Ns),
+		manifests:      make(map[string]cachedManifest),
 		BundledModules: make(map[string]string),
 
 		valuePrefix:        defaultValuePrefix,
@@ -115,6 +124,7 @@ func NewEvaler() *Evaler {
 
 	ev.ExtendBuiltin(BuildNs().
 		AddVar("pwd", NewPwdVar(ev)).
+		AddVar("paths", newPathsVar(ev)).
 		AddVar("before-chdir", vars.FromPtr(&beforeChdirElvish)).
 		AddVar("after-chdir", vars.FromPtr(&afterChdirElvish)).
 		AddVar("value-out-indicator",
@@ -225,6 +235,17 @@ func (ev *Evaler) AddModule(name string, mod *Ns) {
 	ev.modules[name] = mod
 }
 
+// IsPredefinedModule returns whether spec refers to a module that is not
+// loaded from a file, like an internal module added with AddModule or a
+// bundled module in BundledModules.
+func (ev *Evaler) IsPredefinedModule(spec string) bool {
+	ev.mu.RLock()
+	defer ev.mu.RUnlock()
+	_, isInternal := ev.modules[spec]
+	_, isBundled := ev.BundledModules[spec]
+	return (isInternal || isBundled) && !isRelativeSpec(spec)
+}
+
 // ValuePrefix returns the prefix to prepend to value outputs when writing them
 // to terminal.
 func (ev *Evaler) ValuePrefix() string {
@@ -1,6 +1,7 @@
 package eval
 
 import (
+	"context"
 	"errors"
 	"os"
 	"os/exec"
@@ -86,6 +87,9 @@ func (e externalCmd) Call(fm *Frame, argVals []any, opts map[string]any) error {
 		args[i+1] = vals.ToString(a)
 	}
 
+	if err := fm.Evaler.Sandbox.CheckCommand(e.Name); err != nil {
+		return err
+	}
 	path, err := exec.LookPath(e.Name)
 	if err != nil {
 		return err
@@ -110,6 +114,22 @@ func (e externalCmd) Call(fm *Frame, argVals []any, opts map[string]any) error {
 	if err != nil {
 		return err
 	}
+	// Interrupts like Ctrl-C are delivered to the process by the terminal,
+	// but nothing does that when the evaluation has a deadline, so kill the
+	// process when it expires.
+	if _, ok := fm.ctx.Deadline(); ok {
+		waited := make(chan struct{})
+		defer close(waited)
+		go func() {
+			select {
+			case <-fm.ctx.Done():
+				if errors.Is(fm.ctx.Err(), context.DeadlineExceeded) {
+					proc.Kill()
+				}
+			case <-waited:
+			}
+		}()
+	}
 
 	state, err := proc.Wait()
 	if err != nil {
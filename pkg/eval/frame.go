@@ -53,9 +53,16 @@ type Frame struct {
 // returns the altered local namespace, function that can be called to actuate
 // the evaluation, and a nil error.
 func (fm *Frame) PrepareEval(src parse.Source, r diag.Ranger, ns *Ns) (*Ns, func() Exception, error) {
+	newLocal, exec, _, err := fm.prepareEval(src, r, ns)
+	return newLocal, exec, err
+}
+
+// Like PrepareEval, but also returns the names exported with "pragma export",
+// or nil if there is no such pragma.
+func (fm *Frame) prepareEval(src parse.Source, r diag.Ranger, ns *Ns) (*Ns, func() Exception, []string, error) {
 	tree, err := parse.Parse(src, parse.Config{WarningWriter: fm.ErrorFile()})
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	local := fm.local
 	if ns != nil {
@@ -69,10 +76,10 @@ func (fm *Frame) PrepareEval(src parse.Source, r diag.Ranger, ns *Ns) (*Ns, func
 		fm.Evaler, src, local, new(Ns), nil, fm.ctx, fm.ports, traceback, fm.background}
 	op, _, err := compile(fm.Evaler.Builtin().static(), local.static(), nil, tree, fm.ErrorFile())
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	newLocal, exec := op.prepare(newFm)
-	return newLocal, exec, nil
+	return newLocal, exec, op.exports, nil
 }
 
 // Eval evaluates a piece of code in a copy of the current Frame. It returns the
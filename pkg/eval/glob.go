@@ -232,7 +232,9 @@ func stringToSegments(s string) []glob.Segment {
 	return segs
 }
 
-func doGlob(ctx context.Context, gp globPattern) ([]any, error) {
+// Expands a glob pattern. Paths that can't be read within the sandbox are
+// skipped.
+func doGlob(ctx context.Context, sb *Sandbox, gp globPattern) ([]any, error) {
 	but := make(map[string]struct{})
 	for _, s := range gp.Buts {
 		but[s] = struct{}{}
@@ -250,6 +252,9 @@ func doGlob(ctx context.Context, gp globPattern) ([]any, error) {
 		if _, ignore := but[pathInfo.Path]; ignore {
 			return true
 		}
+		if sb.CheckRead(pathInfo.Path) != nil {
+			return true
+		}
 
 		if gp.TypeCb == nil || gp.TypeCb(pathInfo.Info.Mode()) {
 			vs = append(vs, pathInfo.Path)
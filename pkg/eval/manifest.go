@@ -0,0 +1,214 @@
+package eval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManifestName is the name of project manifest files.
+const ManifestName = "elvish.mod"
+
+// Manifest is a project manifest, which changes how modules are resolved for
+// code in the directory containing it and all its subdirectories.
+//
+// A manifest is a text file. Each line that is not empty or a comment
+// (starting with #) is a directive, which is one of the following:
+//
+//   - "lib $dir": Searches $dir for modules before the directories in
+//     [Evaler.LibDirs]. Directories from multiple lib directives are searched
+//     in order.
+//
+//   - "package $name $dir": Resolves module specs starting with $name
+//     followed by a slash relative to $dir instead, and $name itself to
+//     $dir.elv. This can be used to pin a specific version of a package. If
+//     multiple package directives match a module spec, the one with the
+//     longest name wins.
+//
+// Relative paths are resolved relative to the directory containing the
+// manifest.
+type Manifest struct {
+	// Absolute path of the manifest file.
+	Path string
+	// Absolute paths of the lib directories.
+	LibDirs []string
+	// Absolute paths of package directories, indexed by package names.
+	Packages map[string]string
+}
+
+// FindManifest finds the manifest in dir or its nearest ancestor, and parses
+// it. It returns nil and no error if there is no manifest.
+//
+// Only readable regular files are considered manifests; anything else named
+// elvish.mod, like a directory, is skipped.
+func FindManifest(dir string) (*Manifest, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, ManifestName)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			if content, err := os.ReadFile(path); err == nil {
+				return ParseManifest(path, string(content))
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// A cached result of FindManifest.
+type cachedManifest struct {
+	m *Manifest
+	// Modification time of the manifest file when it was parsed.
+	modTime time.Time
+	// Paths of manifest files that were searched before m was found (or all
+	// the paths searched if m is nil), none of which existed at the time.
+	missing []string
+}
+
+// Like FindManifest, but caches the result for each directory, since every
+// use of a module searches for the manifest from the directory of the code.
+// The cached result is discarded if the manifest file has been modified or
+// removed, or a manifest file has been created in a directory that was
+// searched before it, which only takes a stat for each such directory.
+func (ev *Evaler) findManifest(dir string) (*Manifest, error) {
+	ev.mu.RLock()
+	cached, ok := ev.manifests[dir]
+	ev.mu.RUnlock()
+	if ok && cached.valid() {
+		return cached.m, nil
+	}
+	m, err := FindManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	cached = cachedManifest{m: m, missing: missingManifests(dir, m)}
+	if m != nil {
+		cached.modTime = manifestModTime(m.Path)
+	}
+	ev.mu.Lock()
+	ev.manifests[dir] = cached
+	ev.mu.Unlock()
+	return m, nil
+}
+
+func (c cachedManifest) valid() bool {
+	if c.m != nil && manifestModTime(c.m.Path) != c.modTime {
+		return false
+	}
+	for _, path := range c.missing {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the paths of manifest files that FindManifest searched before it
+// found m in dir or an ancestor of it, or all the paths it searched if m is
+// nil.
+func missingManifests(dir string, m *Manifest) []string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for {
+		path := filepath.Join(dir, ManifestName)
+		if m != nil && path == m.Path {
+			return paths
+		}
+		paths = append(paths, path)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return paths
+		}
+		dir = parent
+	}
+}
+
+// Returns the modification time of a manifest file, or the zero time if it
+// can't be found.
+func manifestModTime(path string) time.Time {
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// ParseManifest parses the content of a manifest at the given path, which
+// must be absolute.
+func ParseManifest(path, content string) (*Manifest, error) {
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if filepath.IsAbs(p) {
+			return filepath.Clean(p)
+		}
+		return filepath.Join(dir, p)
+	}
+	m := &Manifest{Path: path, Packages: make(map[string]string)}
+	for i, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch {
+		case fields[0] == "lib" && len(fields) == 2:
+			m.LibDirs = append(m.LibDirs, resolve(fields[1]))
+		case fields[0] == "package" && len(fields) == 3:
+			m.Packages[strings.TrimSuffix(fields[1], "/")] = resolve(fields[2])
+		case fields[0] == "lib" || fields[0] == "package":
+			return nil, fmt.Errorf("%s:%d: wrong number of arguments to %s", path, i+1, fields[0])
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown directive %s", path, i+1, fields[0])
+		}
+	}
+	return m, nil
+}
+
+// Resolves a module spec using the package directives, returning the path
+// of the module without the extension.
+func (m *Manifest) resolvePackage(spec string) (string, bool) {
+	bestName, bestDir := "", ""
+	for name, dir := range m.Packages {
+		if (spec == name || strings.HasPrefix(spec, name+"/")) && len(name) > len(bestName) {
+			bestName, bestDir = name, dir
+		}
+	}
+	if bestName == "" {
+		return "", false
+	}
+	return bestDir + filepath.FromSlash(spec[len(bestName):]), true
+}
+
+// Manifest returns the manifest that applies to the code being executed,
+// which is found from the directory of the source file, or the working
+// directory if the code doesn't come from a file. It returns nil and no error
+// if there is no manifest, or if the Evaler has a bundle.
+func (fm *Frame) Manifest() (*Manifest, error) {
+	if fm.Evaler.Bundle != nil {
+		// Modules are resolved with the bundle instead.
+		return nil, nil
+	}
+	dir, err := fm.srcDir()
+	if err != nil {
+		return nil, err
+	}
+	return fm.Evaler.findManifest(dir)
+}
+
+// Returns the directory of the source file of the code being executed, or the
+// working directory if the code doesn't come from a file.
+func (fm *Frame) srcDir() (string, error) {
+	if fm.srcMeta.IsFile {
+		return filepath.Dir(fm.srcMeta.Name), nil
+	}
+	return os.Getwd()
+}
@@ -0,0 +1,125 @@
+package eval_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	. "src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/must"
+	"src.elv.sh/pkg/parse"
+	"src.elv.sh/pkg/testutil"
+)
+
+func TestParseManifest(t *testing.T) {
+	root := testutil.TempDir(t)
+	path := filepath.Join(root, ManifestName)
+	m, err := ParseManifest(path,
+		"# comment\n\nlib lib\nlib "+filepath.Join(root, "abs")+"\npackage example.com/x/ vendor/x\n")
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	want := &Manifest{
+		Path:     path,
+		LibDirs:  []string{filepath.Join(root, "lib"), filepath.Join(root, "abs")},
+		Packages: map[string]string{"example.com/x": filepath.Join(root, "vendor", "x")},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %v, want %v", m, want)
+	}
+
+	for _, tc := range []struct{ content, wantErr string }{
+		{"lib", path + ":1: wrong number of arguments to lib"},
+		{"lib a\npackage a", path + ":2: wrong number of arguments to package"},
+		{"foo", path + ":1: unknown directive foo"},
+	} {
+		_, err := ParseManifest(path, tc.content)
+		if err == nil || err.Error() != tc.wantErr {
+			t.Errorf("ParseManifest(%q) -> error %v, want %q", tc.content, err, tc.wantErr)
+		}
+	}
+}
+
+func TestFindManifest(t *testing.T) {
+	root := testutil.TempDir(t)
+	testutil.ApplyDirIn(testutil.Dir{
+		"project": testutil.Dir{
+			ManifestName: "lib lib",
+			"a": testutil.Dir{
+				"b": testutil.Dir{},
+				// Not a regular file, so skipped.
+				ManifestName: testutil.Dir{},
+			},
+		},
+	}, root)
+
+	m, err := FindManifest(filepath.Join(root, "project", "a", "b"))
+	if err != nil || m == nil || m.Path != filepath.Join(root, "project", ManifestName) {
+		t.Errorf("got %v, %v, want manifest in project", m, err)
+	}
+	m, err = FindManifest(root)
+	if err != nil || m != nil {
+		t.Errorf("got %v, %v, want nil, nil", m, err)
+	}
+}
+
+func TestFindManifest_CachedByEvaler(t *testing.T) {
+	dir := testutil.InTempDir(t)
+	testutil.ApplyDir(testutil.Dir{
+		ManifestName: "",
+		"lib":        testutil.Dir{"m.elv": "var v = project"},
+		"global":     testutil.Dir{"m.elv": "var v = global"},
+	})
+	ev := NewEvaler()
+	ev.LibDirs = []string{filepath.Join(dir, "global")}
+	useM := func() any {
+		t.Helper()
+		err := ev.Eval(parse.Source{Name: "[test]", Code: "use m; var v = $m:v"}, EvalCfg{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, _ := ev.Global().Index("v")
+		return v
+	}
+
+	if v := useM(); v != "global" {
+		t.Errorf("got %v, want global", v)
+	}
+	// The manifest is found again when it has been modified.
+	must.WriteFile(ManifestName, "lib lib")
+	later := time.Now().Add(time.Hour)
+	must.OK(os.Chtimes(ManifestName, later, later))
+	if v := useM(); v != "project" {
+		t.Errorf("got %v, want project", v)
+	}
+}
+
+func TestFindManifest_CachedByEvaler_NewManifest(t *testing.T) {
+	dir := testutil.InTempDir(t)
+	testutil.ApplyDir(testutil.Dir{
+		"lib":    testutil.Dir{"m.elv": "var v = project"},
+		"global": testutil.Dir{"m.elv": "var v = global"},
+	})
+	ev := NewEvaler()
+	ev.LibDirs = []string{filepath.Join(dir, "global")}
+	useM := func() any {
+		t.Helper()
+		err := ev.Eval(parse.Source{Name: "[test]", Code: "use m; var v = $m:v"}, EvalCfg{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, _ := ev.Global().Index("v")
+		return v
+	}
+
+	if v := useM(); v != "global" {
+		t.Errorf("got %v, want global", v)
+	}
+	// A manifest created after the directory has been searched is found.
+	must.WriteFile(ManifestName, "lib lib")
+	if v := useM(); v != "project" {
+		t.Errorf("got %v, want project", v)
+	}
+}
@@ -2,6 +2,7 @@ package eval
 
 import (
 	"fmt"
+	"strings"
 	"unsafe"
 
 	"src.elv.sh/pkg/eval/vars"
@@ -165,6 +166,36 @@ func (ns *Ns) HasKeyString(k string) bool {
 	return false
 }
 
+// Returns a namespace with only the given names from ns, sharing the same
+// variables. It returns an error if any of the names doesn't exist.
+func (ns *Ns) restrict(names []string) (*Ns, error) {
+	restricted := &Ns{}
+	for _, name := range names {
+		if restricted.HasKeyString(name) {
+			continue
+		}
+		info, i := ns.lookup(name)
+		if i == -1 {
+			return nil, fmt.Errorf("exported %s is not defined", showVarName(name))
+		}
+		restricted.slots = append(restricted.slots, ns.slots[i])
+		restricted.infos = append(restricted.infos, info)
+	}
+	return restricted, nil
+}
+
+// Describes a variable, like "function f" for f~ or "variable $x" for x.
+func showVarName(name string) string {
+	switch {
+	case strings.HasSuffix(name, FnSuffix):
+		return "function " + strings.TrimSuffix(name, FnSuffix)
+	case strings.HasSuffix(name, NsSuffix):
+		return "namespace " + name
+	default:
+		return "variable $" + name
+	}
+}
+
 func (ns *Ns) static() *staticNs {
 	return &staticNs{ns.infos}
 }
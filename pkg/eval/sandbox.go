@@ -0,0 +1,216 @@
+package eval
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"src.elv.sh/pkg/env"
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/eval/vars"
+)
+
+// Sandbox restricts what code evaluated by an Evaler can do, which is useful
+// for running untrusted code. The zero value allows none of the restricted
+// capabilities.
+//
+// All the methods of Sandbox can be called on a nil *Sandbox, which allows
+// everything.
+type Sandbox struct {
+	// External commands that can be run, either by the name used to invoke
+	// them or by the path they resolve to.
+	AllowedCommands []string
+	// Directories under which files can be read. Relative paths are resolved
+	// against the working directory when checked, so these should normally be
+	// absolute.
+	ReadRoots []string
+	// Directories under which files can be both read and written, resolved
+	// like ReadRoots.
+	WriteRoots []string
+	// Whether environment variables, including $paths, can be changed.
+	AllowEnvMutation bool
+	// Module specs that can't be used.
+	DeniedModules []string
+}
+
+// SandboxViolation is the reason of exceptions thrown when code tries to do
+// something not allowed by the [Sandbox] of the Evaler.
+type SandboxViolation struct {
+	// The restricted capability: "command", "read", "write", "env" or "use".
+	Capability string
+	// The name of the command, the path, the name of the environment
+	// variable or the module spec.
+	Subject string
+}
+
+var sandboxViolationVerbs = map[string]string{
+	"command": "running external command",
+	"read":    "reading",
+	"write":   "writing",
+	"env":     "changing environment variable",
+	"use":     "using module",
+}
+
+// Error implements the error interface.
+func (e SandboxViolation) Error() string {
+	return "sandbox: " + sandboxViolationVerbs[e.Capability] + " " + e.Subject + " is not allowed"
+}
+
+// Kind returns "sandbox-error".
+func (e SandboxViolation) Kind() string { return "sandbox-error" }
+
+// Fields implements the vals.PseudoMap interface.
+func (e SandboxViolation) Fields() vals.StructMap { return sandboxViolationFields{e} }
+
+type sandboxViolationFields struct{ e SandboxViolation }
+
+func (sandboxViolationFields) IsStructMap()         {}
+func (sandboxViolationFields) Type() string         { return "sandbox-violation" }
+func (f sandboxViolationFields) Capability() string { return f.e.Capability }
+func (f sandboxViolationFields) Subject() string    { return f.e.Subject }
+
+// CheckCommand returns a [SandboxViolation] if the named external command
+// can't be run.
+func (sb *Sandbox) CheckCommand(name string) error {
+	if sb == nil || contains(sb.AllowedCommands, name) {
+		return nil
+	}
+	if path, err := exec.LookPath(name); err == nil && contains(sb.AllowedCommands, path) {
+		return nil
+	}
+	return SandboxViolation{"command", name}
+}
+
+// CheckRead returns a [SandboxViolation] if the file at path can't be read.
+func (sb *Sandbox) CheckRead(path string) error {
+	if sb == nil || underRoots(path, sb.ReadRoots) || underRoots(path, sb.WriteRoots) {
+		return nil
+	}
+	return SandboxViolation{"read", path}
+}
+
+// CheckWrite returns a [SandboxViolation] if the file at path can't be
+// written.
+func (sb *Sandbox) CheckWrite(path string) error {
+	if sb == nil || underRoots(path, sb.WriteRoots) {
+		return nil
+	}
+	return SandboxViolation{"write", path}
+}
+
+// CheckEnvMutation returns a [SandboxViolation] if the named environment
+// variable can't be changed.
+func (sb *Sandbox) CheckEnvMutation(name string) error {
+	if sb == nil || sb.AllowEnvMutation {
+		return nil
+	}
+	return SandboxViolation{"env", name}
+}
+
+// CheckUse returns a [SandboxViolation] if the module spec can't be used.
+func (sb *Sandbox) CheckUse(spec string) error {
+	if sb != nil && contains(sb.DeniedModules, spec) {
+		return SandboxViolation{"use", spec}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether path is one of roots or under one of them, after resolving
+// symlinks in both.
+func underRoots(path string, roots []string) bool {
+	for _, root := range roots {
+		if inside, err := PathInside(path, root); err == nil && inside {
+			return true
+		}
+	}
+	return false
+}
+
+// PathInside returns whether path is dir or inside it, after resolving
+// symlinks in both. Since the paths may not exist yet, only their longest
+// existing prefixes are resolved.
+func PathInside(path, dir string) (bool, error) {
+	path, err := realPath(path)
+	if err != nil {
+		return false, err
+	}
+	dir, err = realPath(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false, nil
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+// Returns the absolute path with symlinks resolved. Since path may not exist
+// yet (for example, when creating a file), only the longest prefix of it that
+// exists is resolved.
+func realPath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	dir, rest := path, ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path, nil
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
+
+// An environment variable that checks the sandbox before being changed.
+type sandboxedEnvVar struct {
+	vars.UnsettableVar
+	sb   *Sandbox
+	name string
+}
+
+func (v sandboxedEnvVar) Set(val any) error {
+	if err := v.sb.CheckEnvMutation(v.name); err != nil {
+		return err
+	}
+	return v.UnsettableVar.Set(val)
+}
+
+func (v sandboxedEnvVar) Unset() error {
+	if err := v.sb.CheckEnvMutation(v.name); err != nil {
+		return err
+	}
+	return v.UnsettableVar.Unset()
+}
+
+// The $paths variable, which checks the sandbox of the Evaler before being
+// changed.
+type pathsVar struct {
+	vars.Var
+	ev *Evaler
+}
+
+func newPathsVar(ev *Evaler) vars.Var {
+	return pathsVar{vars.NewEnvListVar(env.PATH), ev}
+}
+
+func (v pathsVar) Set(val any) error {
+	if err := v.ev.Sandbox.CheckEnvMutation(env.PATH); err != nil {
+		return err
+	}
+	return v.Var.Set(val)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -84,6 +85,20 @@ func TestTranscripts(t *testing.T) {
 				AddVar("lib1", vars.NewReadOnly(libdir1)).
 				AddVar("lib2", vars.NewReadOnly(libdir2)))
 		},
+		"sandbox", func(t *testing.T, ev *eval.Evaler) {
+			dir := testutil.InTempDir(t)
+			testutil.ApplyDir(testutil.Dir{
+				"ro":  testutil.Dir{"a": "content of a\n"},
+				"rw":  testutil.Dir{},
+				"out": testutil.Dir{"secret": "secret\n"},
+			})
+			ev.Sandbox = &eval.Sandbox{
+				AllowedCommands: []string{"echo"},
+				ReadRoots:       []string{filepath.Join(dir, "ro")},
+				WriteRoots:      []string{filepath.Join(dir, "rw")},
+				DeniedModules:   []string{"re"},
+			}
+		},
 		"add-var-in-builtin", func(ev *eval.Evaler) {
 			addVar := func(name string, val any) {
 				ev.ExtendGlobal(eval.BuildNs().AddVar(name, vars.FromInit(val)))
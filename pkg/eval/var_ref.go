@@ -145,7 +145,11 @@ func derefBase(fm *Frame, ref *varRef) (vars.Var, []string) {
 	case builtinScope:
 		return fm.Evaler.Builtin().slots[ref.index], ref.subNames
 	case envScope:
-		return vars.FromEnv(ref.subNames[0]), nil
+		name := ref.subNames[0]
+		if sb := fm.Evaler.Sandbox; sb != nil {
+			return sandboxedEnvVar{vars.FromEnv(name), sb, name}, nil
+		}
+		return vars.FromEnv(name), nil
 	case externalScope:
 		return vars.NewReadOnly(NewExternalCmd(ref.subNames[0])), nil
 	default:
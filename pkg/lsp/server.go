@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"path/filepath"
 
 	"github.com/sourcegraph/jsonrpc2"
 	lsp "pkg.nimblebun.works/go-lsp"
@@ -125,7 +127,7 @@ func (s *server) hover(_ context.Context, params lsp.TextDocumentPositionParams)
 	var primary *parse.Primary
 	if p.Match(np.Store(&primary)) && primary.Type == parse.Variable {
 		// TODO: Take shadowing into consideration.
-		markdown, err := doc.Source("$" + primary.Value)
+		markdown, err := s.docSource(params.TextDocument.URI, "$"+primary.Value)
 		if err == nil {
 			return lsp.Hover{Contents: lsp.MarkupContent{Kind: lsp.MKMarkdown, Value: markdown}}, nil
 		}
@@ -135,7 +137,7 @@ func (s *server) hover(_ context.Context, params lsp.TextDocumentPositionParams)
 	var form *parse.Form
 	if p.Match(np.SimpleExpr(&expr, nil), np.Store(&form)) && form.Head == expr.Compound {
 		// TODO: Take shadowing into consideration.
-		markdown, err := doc.Source(expr.Value)
+		markdown, err := s.docSource(params.TextDocument.URI, expr.Value)
 		if err == nil {
 			return lsp.Hover{Contents: lsp.MarkupContent{Kind: lsp.MKMarkdown, Value: markdown}}, nil
 		}
@@ -143,6 +145,29 @@ func (s *server) hover(_ context.Context, params lsp.TextDocumentPositionParams)
 	return nil, nil
 }
 
+// Finds the doc of a symbol, falling back to user-defined modules that can be
+// used from the document when it's not a builtin symbol.
+func (s *server) docSource(uri lsp.DocumentURI, fqname string) (string, error) {
+	markdown, err := doc.Source(fqname)
+	if err == nil {
+		return markdown, nil
+	}
+	dir, ok := documentDir(uri)
+	if !ok {
+		return "", err
+	}
+	return doc.UserSource(fqname, dir, s.evaler.LibDirs)
+}
+
+// Returns the directory containing the document if it has a file URI.
+func documentDir(uri lsp.DocumentURI) (string, bool) {
+	u, err := url.Parse(string(uri))
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	return filepath.Dir(filepath.FromSlash(u.Path)), true
+}
+
 func (s *server) completion(_ context.Context, params lsp.CompletionParams) (any, error) {
 	document, ok := s.documents[params.TextDocument.URI]
 	if !ok {
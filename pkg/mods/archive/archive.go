@@ -0,0 +1,381 @@
+// Package archive implements the archive: module for working with archives
+// and compressed streams.
+package archive
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/errs"
+	"src.elv.sh/pkg/eval/vals"
+	osmod "src.elv.sh/pkg/mods/os"
+)
+
+// Ns is the namespace for the archive: module.
+var Ns = eval.BuildNsNamed("archive").
+	AddGoFns(map[string]any{
+		"create":  create,
+		"extract": extract,
+		"list":    list,
+
+		"gzip":   gzipFn,
+		"gunzip": gunzip,
+	}).Ns()
+
+// Supported archive formats.
+const (
+	formatTar   = "tar"
+	formatTarGz = "tar.gz"
+	formatZip   = "zip"
+)
+
+// ErrUnsafePath is wrapped in the error thrown when extracting an entry whose
+// path, or link target, would escape the destination directory.
+var ErrUnsafePath = errors.New("path escapes the destination directory")
+
+type formatOpts struct{ Format string }
+
+func (*formatOpts) SetDefaultOptions() {}
+
+// Determines the format of an archive from the &format option, or the file
+// name if the option is empty.
+func archiveFormat(opt, name string) (string, error) {
+	switch opt {
+	case formatTar, formatTarGz, formatZip:
+		return opt, nil
+	case "":
+		lower := strings.ToLower(name)
+		switch {
+		case strings.HasSuffix(lower, ".tar"):
+			return formatTar, nil
+		case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+			return formatTarGz, nil
+		case strings.HasSuffix(lower, ".zip"):
+			return formatZip, nil
+		}
+		return "", errs.BadValue{What: "archive name",
+			Valid:  "name ending in .tar, .tar.gz, .tgz or .zip (or use &format)",
+			Actual: name}
+	default:
+		return "", errs.BadValue{What: "&format",
+			Valid: "tar, tar.gz or zip", Actual: opt}
+	}
+}
+
+// An entry in an archive that is being read.
+type entry struct {
+	name       string
+	mode       fs.FileMode
+	size       int64
+	modTime    time.Time
+	linkTarget string
+	// Whether the entry is a hard link to the entry named by linkTarget.
+	hardLink bool
+	// Opens the content of the entry. Only valid during the callback of
+	// readEntries.
+	open func() (io.ReadCloser, error)
+}
+
+// Calls f for each entry in the archive.
+func readEntries(name, format string, f func(*entry) error) error {
+	if format == formatZip {
+		return readZipEntries(name, f)
+	}
+	return readTarEntries(name, format == formatTarGz, f)
+}
+
+// Writes entries to an archive.
+type archiveWriter interface {
+	// Adds an entry. The content is read from r for regular files.
+	add(name string, fi fs.FileInfo, linkTarget string, r io.Reader) error
+	Close() error
+}
+
+func newArchiveWriter(w io.Writer, format string) archiveWriter {
+	if format == formatZip {
+		return newZipWriter(w)
+	}
+	return newTarWriter(w, format == formatTarGz)
+}
+
+func create(fm *eval.Frame, opts formatOpts, dst string, paths ...string) (err error) {
+	format, err := archiveFormat(opts.Format, dst)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := fm.Evaler.Sandbox.CheckRead(p); err != nil {
+			return err
+		}
+	}
+	if err := fm.Evaler.Sandbox.CheckWrite(dst); err != nil {
+		return err
+	}
+	file, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	aw := newArchiveWriter(file, format)
+	defer func() {
+		err = errors.Join(err, aw.Close(), file.Close())
+		if err != nil {
+			// Don't leave a partial archive behind.
+			os.Remove(dst)
+		}
+	}()
+	// The archive itself may be inside one of the paths.
+	self, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := addTree(aw, p, self); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Adds the file or directory at root to the archive, recursively, skipping
+// the archive itself, whose FileInfo is self.
+func addTree(aw archiveWriter, root string, self fs.FileInfo) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name, err := entryName(p)
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if os.SameFile(fi, self) {
+			return nil
+		}
+		switch {
+		case fi.Mode()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return aw.add(name, fi, filepath.ToSlash(target), nil)
+		case fi.Mode().IsRegular():
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return aw.add(name, fi, "", f)
+		default:
+			return aw.add(name, fi, "", nil)
+		}
+	})
+}
+
+// Converts a filesystem path to the name of an archive entry, which always
+// uses slashes and is relative.
+func entryName(p string) (string, error) {
+	name := path.Clean(filepath.ToSlash(p))
+	// Strip the volume name and leading slashes of absolute paths, like tar.
+	name = strings.TrimLeft(name[len(filepath.VolumeName(p)):], "/")
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return "", &fs.PathError{Op: "archive", Path: p, Err: ErrUnsafePath}
+	}
+	return name, nil
+}
+
+type extractOpts struct {
+	Format    string
+	Dest      string
+	Overwrite bool
+}
+
+func (o *extractOpts) SetDefaultOptions() { o.Dest = "." }
+
+func extract(fm *eval.Frame, opts extractOpts, name string) error {
+	format, err := archiveFormat(opts.Format, name)
+	if err != nil {
+		return err
+	}
+	if err := fm.Evaler.Sandbox.CheckRead(name); err != nil {
+		return err
+	}
+	if err := fm.Evaler.Sandbox.CheckWrite(opts.Dest); err != nil {
+		return err
+	}
+	return readEntries(name, format, func(e *entry) error {
+		return extractEntry(fm.Evaler.Sandbox, opts.Dest, opts.Overwrite, e)
+	})
+}
+
+func extractEntry(sb *eval.Sandbox, dest string, overwrite bool, e *entry) error {
+	rel := filepath.FromSlash(strings.TrimSuffix(e.name, "/"))
+	if rel == "" || rel == "." {
+		return nil
+	}
+	if !filepath.IsLocal(rel) {
+		return &fs.PathError{Op: "extract", Path: e.name, Err: ErrUnsafePath}
+	}
+	target := filepath.Join(dest, rel)
+	// Checking dest alone is not enough, since it may contain symlinks that
+	// point outside the write roots.
+	if err := sb.CheckWrite(target); err != nil {
+		return err
+	}
+	// Entries are never written through symlinks, even those that point inside
+	// dest, since a chain of symlinks can be used to escape it.
+	if err := checkNoSymlinkParent(dest, rel); err != nil {
+		return &fs.PathError{Op: "extract", Path: e.name, Err: err}
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	perm := e.mode & fs.ModePerm
+	switch {
+	case e.hardLink:
+		// Unlike symlinks, the targets of hard links are relative to the root
+		// of the archive.
+		linkTarget := filepath.FromSlash(e.linkTarget)
+		if !filepath.IsLocal(linkTarget) {
+			return &fs.PathError{Op: "extract", Path: e.name, Err: ErrUnsafePath}
+		}
+		if err := checkNoSymlinkParent(dest, linkTarget); err != nil {
+			return &fs.PathError{Op: "extract", Path: e.name, Err: err}
+		}
+		if overwrite {
+			os.Remove(target)
+		}
+		return os.Link(filepath.Join(dest, linkTarget), target)
+	case e.mode.IsDir():
+		err := os.Mkdir(target, perm|0700)
+		if err != nil && !(errors.Is(err, fs.ErrExist) && isDir(target)) {
+			return err
+		}
+		return nil
+	case e.mode&fs.ModeSymlink != 0:
+		linkTarget := filepath.FromSlash(e.linkTarget)
+		if filepath.IsAbs(linkTarget) ||
+			!filepath.IsLocal(filepath.Join(filepath.Dir(rel), linkTarget)) {
+			return &fs.PathError{Op: "extract", Path: e.name, Err: ErrUnsafePath}
+		}
+		if overwrite {
+			os.Remove(target)
+		}
+		return os.Symlink(linkTarget, target)
+	case e.mode.IsRegular():
+		flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if overwrite {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			// Don't write through an existing symlink.
+			if fi, err := os.Lstat(target); err == nil && fi.Mode()&fs.ModeSymlink != 0 {
+				os.Remove(target)
+			}
+		}
+		out, err := os.OpenFile(target, flag, perm)
+		if err != nil {
+			return err
+		}
+		in, err := e.open()
+		if err != nil {
+			out.Close()
+			return err
+		}
+		_, err = io.Copy(out, in)
+		return errors.Join(err, in.Close(), out.Close())
+	default:
+		// Other types of files, like devices, are skipped.
+		return nil
+	}
+}
+
+// Returns ErrUnsafePath if any existing directory that contains rel under dest
+// (excluding dest itself) is a symlink.
+func checkNoSymlinkParent(dest, rel string) error {
+	p := dest
+	for _, part := range strings.Split(filepath.Dir(rel), string(filepath.Separator)) {
+		if part == "." {
+			continue
+		}
+		p = filepath.Join(p, part)
+		fi, err := os.Lstat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return ErrUnsafePath
+		}
+	}
+	return nil
+}
+
+func isDir(p string) bool {
+	fi, err := os.Lstat(p)
+	return err == nil && fi.IsDir()
+}
+
+func list(fm *eval.Frame, opts formatOpts, name string) error {
+	format, err := archiveFormat(opts.Format, name)
+	if err != nil {
+		return err
+	}
+	if err := fm.Evaler.Sandbox.CheckRead(name); err != nil {
+		return err
+	}
+	out := fm.ValueOutput()
+	return readEntries(name, format, func(e *entry) error {
+		return out.Put(entryMap{e})
+	})
+}
+
+// Exposes an entry as a struct map.
+type entryMap struct{ e *entry }
+
+func (entryMap) IsStructMap() {}
+
+func (m entryMap) Name() string       { return m.e.name }
+func (m entryMap) Type() string       { return osmod.TypeName(m.e.mode) }
+func (m entryMap) Size() vals.Num     { return vals.Int64ToNum(m.e.size) }
+func (m entryMap) Perm() int          { return int(m.e.mode & fs.ModePerm) }
+func (m entryMap) Mtime() vals.Num    { return vals.Int64ToNum(m.e.modTime.Unix()) }
+func (m entryMap) LinkTarget() string { return m.e.linkTarget }
+
+type gzipOpts struct{ Level int }
+
+func (o *gzipOpts) SetDefaultOptions() { o.Level = gzip.DefaultCompression }
+
+func gzipFn(fm *eval.Frame, opts gzipOpts) error {
+	if opts.Level < gzip.HuffmanOnly || opts.Level > gzip.BestCompression {
+		return errs.OutOfRange{What: "&level",
+			ValidLow:  strconv.Itoa(gzip.HuffmanOnly),
+			ValidHigh: strconv.Itoa(gzip.BestCompression),
+			Actual:    strconv.Itoa(opts.Level)}
+	}
+	w, err := gzip.NewWriterLevel(fm.ByteOutput(), opts.Level)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, fm.InputFile())
+	return errors.Join(err, w.Close())
+}
+
+func gunzip(fm *eval.Frame) error {
+	r, err := gzip.NewReader(fm.InputFile())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fm.ByteOutput(), r)
+	return errors.Join(err, r.Close())
+}
@@ -0,0 +1,72 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"embed"
+	"os"
+	"testing"
+
+	"src.elv.sh/pkg/eval/evaltest"
+)
+
+//go:embed *.elvts
+var transcripts embed.FS
+
+func TestTranscripts(t *testing.T) {
+	evaltest.TestTranscriptsInFS(t, transcripts,
+		"unsafe-archives", func(t *testing.T) {
+			// Archives with entries that escape the destination directory,
+			// which can't be created with archive:create.
+			writeTar(t, "parent.tar", &tar.Header{Name: "../evil", Mode: 0o644})
+			writeTar(t, "abs.tar", &tar.Header{Name: "/evil", Mode: 0o644})
+			writeTar(t, "symlink.tar", &tar.Header{Name: "link",
+				Typeflag: tar.TypeSymlink, Linkname: "../evil"})
+			writeTar(t, "hardlink.tar", &tar.Header{Name: "link",
+				Typeflag: tar.TypeLink, Linkname: "../evil"})
+			writeZip(t, "parent.zip", "a/../../evil")
+			// A chain of symlinks, each of which points inside the
+			// destination by itself, but together point outside.
+			writeTar(t, "symlink-chain.tar",
+				&tar.Header{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."},
+				&tar.Header{Name: "a/b", Typeflag: tar.TypeSymlink, Linkname: ".."},
+				&tar.Header{Name: "a/b/c", Typeflag: tar.TypeSymlink, Linkname: ".."},
+				&tar.Header{Name: "a/b/c/evil", Mode: 0o644})
+			writeTar(t, "through-symlink.tar",
+				&tar.Header{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."},
+				&tar.Header{Name: "a/evil", Mode: 0o644})
+		},
+	)
+}
+
+func writeTar(t *testing.T, name string, hs ...*tar.Header) {
+	f := create(t, name)
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for _, h := range hs {
+		must(t, tw.WriteHeader(h))
+	}
+	must(t, tw.Close())
+}
+
+func writeZip(t *testing.T, name, entry string) {
+	f := create(t, name)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	_, err := zw.Create(entry)
+	must(t, err)
+	must(t, zw.Close())
+}
+
+func create(t *testing.T, name string) *os.File {
+	f, err := os.Create(name)
+	must(t, err)
+	return f
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
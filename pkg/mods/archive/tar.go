@@ -0,0 +1,86 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+func readTarEntries(name string, gzipped bool, f func(*entry) error) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if gzipped {
+		gr, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		e := &entry{
+			name: h.Name, mode: h.FileInfo().Mode(), size: h.Size,
+			modTime: h.ModTime, linkTarget: h.Linkname,
+			open: func() (io.ReadCloser, error) { return io.NopCloser(tr), nil },
+		}
+		e.hardLink = h.Typeflag == tar.TypeLink
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+}
+
+type tarWriter struct {
+	tw *tar.Writer
+	gw *gzip.Writer
+}
+
+func newTarWriter(w io.Writer, gzipped bool) *tarWriter {
+	if gzipped {
+		gw := gzip.NewWriter(w)
+		return &tarWriter{tar.NewWriter(gw), gw}
+	}
+	return &tarWriter{tar.NewWriter(w), nil}
+}
+
+func (w *tarWriter) add(name string, fi fs.FileInfo, linkTarget string, r io.Reader) error {
+	h, err := tar.FileInfoHeader(fi, linkTarget)
+	if err != nil {
+		return err
+	}
+	h.Name = name
+	if fi.IsDir() {
+		h.Name += "/"
+	}
+	// Don't record user and group names, which are not portable.
+	h.Uname, h.Gname = "", ""
+	if err := w.tw.WriteHeader(h); err != nil {
+		return err
+	}
+	if r != nil {
+		_, err = io.Copy(w.tw, r)
+	}
+	return err
+}
+
+func (w *tarWriter) Close() error {
+	err := w.tw.Close()
+	if w.gw != nil {
+		err = errors.Join(err, w.gw.Close())
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package archive
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+func readZipEntries(name string, f func(*entry) error) error {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, file := range zr.File {
+		e := &entry{
+			name: file.Name, mode: file.Mode(),
+			size: int64(file.UncompressedSize64), modTime: file.Modified,
+			open: file.Open,
+		}
+		if e.mode&fs.ModeSymlink != 0 {
+			// The target of a symlink is stored as its content.
+			target, err := readAll(file)
+			if err != nil {
+				return err
+			}
+			e.linkTarget = target
+		}
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readAll(file *zip.File) (string, error) {
+	r, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	var sb strings.Builder
+	_, err = io.Copy(&sb, r)
+	return sb.String(), err
+}
+
+type zipWriter struct{ zw *zip.Writer }
+
+func newZipWriter(w io.Writer) zipWriter { return zipWriter{zip.NewWriter(w)} }
+
+func (w zipWriter) add(name string, fi fs.FileInfo, linkTarget string, r io.Reader) error {
+	h, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return err
+	}
+	h.Name = name
+	if fi.IsDir() {
+		h.Name += "/"
+	} else {
+		h.Method = zip.Deflate
+	}
+	fw, err := w.zw.CreateHeader(h)
+	if err != nil {
+		return err
+	}
+	switch {
+	case linkTarget != "":
+		_, err = io.WriteString(fw, linkTarget)
+	case r != nil:
+		_, err = io.Copy(fw, r)
+	}
+	return err
+}
+
+func (w zipWriter) Close() error { return w.zw.Close() }
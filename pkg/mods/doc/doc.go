@@ -4,6 +4,8 @@ package doc
 import (
 	"fmt"
 	"io/fs"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -20,7 +22,7 @@ var Ns = eval.BuildNsNamed("doc").
 	AddGoFns(map[string]any{
 		"show":     show,
 		"find":     find,
-		"source":   Source,
+		"source":   source,
 		"-symbols": symbols,
 	}).
 	Ns()
@@ -30,7 +32,7 @@ type showOptions struct{ Width int }
 func (opts *showOptions) SetDefaultOptions() {}
 
 func show(fm *eval.Frame, opts showOptions, fqname string) error {
-	doc, err := Source(fqname)
+	doc, err := source(fm, fqname)
 	if err != nil {
 		return err
 	}
@@ -66,7 +68,7 @@ func show(fm *eval.Frame, opts showOptions, fqname string) error {
 }
 
 func find(fm *eval.Frame, qs ...string) {
-	for ns, docs := range docs() {
+	findInDocs := func(ns string, docs elvdoc.Docs) {
 		findIn := func(name, markdown string) {
 			if bs, ok := match(markdown, qs); ok {
 				out := fm.ByteOutput()
@@ -83,6 +85,37 @@ func find(fm *eval.Frame, qs ...string) {
 			findIn("$"+ns+entry.Name, entry.Content)
 		}
 	}
+	for ns, docs := range docs() {
+		findInDocs(ns, docs)
+	}
+	if dir, err := os.Getwd(); err == nil {
+		userDocs := userDocs(dir, fm.Evaler.LibDirs)
+		prefixes := make([]string, 0, len(userDocs))
+		for prefix := range userDocs {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+		for _, prefix := range prefixes {
+			findInDocs(prefix, userDocs[prefix])
+		}
+	}
+}
+
+// Like Source, but also looks up symbols in user-defined modules, resolved
+// from the working directory and the module search directories of fm.
+func source(fm *eval.Frame, fqname string) (string, error) {
+	doc, err := Source(fqname)
+	if err == nil {
+		return doc, nil
+	}
+	dir, wdErr := os.Getwd()
+	if wdErr != nil {
+		return "", err
+	}
+	if userDoc, userErr := UserSource(fqname, dir, fm.Evaler.LibDirs); userErr == nil {
+		return userDoc, nil
+	}
+	return "", err
 }
 
 // Source returns the doc source for a symbol.
@@ -101,19 +134,124 @@ func Source(fqname string) (string, error) {
 	if !ok {
 		return "", fmt.Errorf("no doc for %s", parse.Quote(fqname))
 	}
-	var entries []elvdoc.Entry
+	if content, ok := findEntry(docs, isVar, rest); ok {
+		return content, nil
+	}
+	return "", fmt.Errorf("no doc for %s", parse.Quote(fqname))
+}
+
+// UserSource returns the doc source for a symbol in a user-defined module,
+// extracted from the comments in the source file of the module. The symbol
+// must be qualified with the module spec, like "a/b/c:f" or "$a/b/c:x".
+//
+// The module is resolved in the same way as "use" from code in dir, with
+// libDirs as the module search directories.
+func UserSource(fqname, dir string, libDirs []string) (string, error) {
+	isVar := strings.HasPrefix(fqname, "$")
+	first, rest := eval.SplitQName(strings.TrimPrefix(fqname, "$"))
+	if rest == "" {
+		return "", fmt.Errorf("no doc for %s", parse.Quote(fqname))
+	}
+	file, err := eval.FindModuleFile(strings.TrimSuffix(first, ":"), dir, libDirs)
+	if err != nil {
+		return "", fmt.Errorf("no doc for %s", parse.Quote(fqname))
+	}
+	docs, err := extractFile(file, first)
+	if err != nil {
+		return "", err
+	}
+	if content, ok := findEntry(docs, isVar, rest); ok {
+		return content, nil
+	}
+	return "", fmt.Errorf("no doc for %s", parse.Quote(fqname))
+}
+
+func findEntry(docs elvdoc.Docs, isVar bool, name string) (string, bool) {
+	entries := docs.Fns
 	if isVar {
 		entries = docs.Vars
-	} else {
-		entries = docs.Fns
 	}
 	for _, entry := range entries {
-		if entry.Name == rest {
-			return entry.Content, nil
+		if entry.Name == name {
+			return entry.Content, true
 		}
 	}
+	return "", false
+}
 
-	return "", fmt.Errorf("no doc for %s", parse.Quote(fqname))
+func extractFile(name, prefix string) (elvdoc.Docs, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return elvdoc.Docs{}, err
+	}
+	defer file.Close()
+	return elvdoc.Extract(file, prefix)
+}
+
+// Returns the elvdocs of all the user-defined modules that can be used from
+// code in dir, indexed by namespace prefixes derived from their module specs
+// (like "a/b/c:"). Modules shadowed by other modules with the same spec are
+// not included, and neither are modules in hidden directories, like .git.
+func userDocs(dir string, libDirs []string) map[string]elvdoc.Docs {
+	type root struct{ dir, specPrefix string }
+	prefixToDocs := make(map[string]elvdoc.Docs)
+	addFile := func(path, prefix string) {
+		if _, shadowed := prefixToDocs[prefix]; shadowed {
+			return
+		}
+		if docs, err := extractFile(path, prefix); err == nil {
+			prefixToDocs[prefix] = docs
+		}
+	}
+
+	var roots []root
+	if manifest, _ := eval.FindManifest(dir); manifest != nil {
+		// Packages with longer names take precedence, like when resolving
+		// module specs.
+		names := make([]string, 0, len(manifest.Packages))
+		for name := range manifest.Packages {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if len(names[i]) != len(names[j]) {
+				return len(names[i]) > len(names[j])
+			}
+			return names[i] < names[j]
+		})
+		for _, name := range names {
+			packageDir := manifest.Packages[name]
+			// The package name itself resolves to $dir.elv.
+			addFile(packageDir+".elv", name+":")
+			roots = append(roots, root{packageDir, name + "/"})
+		}
+		for _, libDir := range manifest.LibDirs {
+			roots = append(roots, root{libDir, ""})
+		}
+	}
+	for _, libDir := range libDirs {
+		roots = append(roots, root{libDir, ""})
+	}
+
+	for _, r := range roots {
+		filepath.WalkDir(r.dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() && path != r.dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.Type().IsRegular() || filepath.Ext(path) != ".elv" {
+				return nil
+			}
+			rel, err := filepath.Rel(r.dir, path)
+			if err != nil {
+				return nil
+			}
+			addFile(path, r.specPrefix+filepath.ToSlash(strings.TrimSuffix(rel, ".elv"))+":")
+			return nil
+		})
+	}
+	return prefixToDocs
 }
 
 func symbols(fm *eval.Frame) error {
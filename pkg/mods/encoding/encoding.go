@@ -0,0 +1,184 @@
+// Package encoding implements the encoding: module, which provides functions
+// for encoding binary data as text and decoding it.
+package encoding
+
+import (
+	"bufio"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
+	"unicode"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/errs"
+)
+
+// Ns is the namespace for the encoding: module.
+var Ns = eval.BuildNsNamed("encoding").
+	AddGoFns(map[string]any{
+		"base64":        base64Encode,
+		"base64-decode": base64Decode,
+		"base32":        base32Encode,
+		"base32-decode": base32Decode,
+		"hex":           hexEncode,
+		"hex-decode":    hexDecode,
+	}).Ns()
+
+type base64Opts struct {
+	URL       bool
+	NoPadding bool
+}
+
+func (*base64Opts) SetDefaultOptions() {}
+
+func (opts base64Opts) encoding() *base64.Encoding {
+	enc := base64.StdEncoding
+	if opts.URL {
+		enc = base64.URLEncoding
+	}
+	if opts.NoPadding {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	return enc
+}
+
+func base64Encode(fm *eval.Frame, opts base64Opts, data ...string) error {
+	return encode(fm, data, func(w io.Writer) io.WriteCloser {
+		return base64.NewEncoder(opts.encoding(), w)
+	})
+}
+
+func base64Decode(fm *eval.Frame, opts base64Opts, data ...string) error {
+	return decode(fm, data, func(r io.Reader) io.Reader {
+		return base64.NewDecoder(opts.encoding(), r)
+	})
+}
+
+type base32Opts struct {
+	Hex       bool
+	NoPadding bool
+}
+
+func (*base32Opts) SetDefaultOptions() {}
+
+func (opts base32Opts) encoding() *base32.Encoding {
+	enc := base32.StdEncoding
+	if opts.Hex {
+		enc = base32.HexEncoding
+	}
+	if opts.NoPadding {
+		enc = enc.WithPadding(base32.NoPadding)
+	}
+	return enc
+}
+
+func base32Encode(fm *eval.Frame, opts base32Opts, data ...string) error {
+	return encode(fm, data, func(w io.Writer) io.WriteCloser {
+		return base32.NewEncoder(opts.encoding(), w)
+	})
+}
+
+func base32Decode(fm *eval.Frame, opts base32Opts, data ...string) error {
+	return decode(fm, data, func(r io.Reader) io.Reader {
+		return base32.NewDecoder(opts.encoding(), r)
+	})
+}
+
+func hexEncode(fm *eval.Frame, data ...string) error {
+	return encode(fm, data, func(w io.Writer) io.WriteCloser {
+		return nopCloser{hex.NewEncoder(w)}
+	})
+}
+
+func hexDecode(fm *eval.Frame, data ...string) error {
+	return decode(fm, data, hex.NewDecoder)
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// Implements an encoding command. If data has one element, it is encoded and
+// output as a string. If data is empty, the byte input is encoded and written
+// to the byte output, followed by a newline.
+func encode(fm *eval.Frame, data []string, newEncoder func(io.Writer) io.WriteCloser) error {
+	switch len(data) {
+	case 0:
+		out := bufio.NewWriter(fm.ByteOutput())
+		enc := newEncoder(out)
+		if _, err := io.Copy(enc, fm.InputFile()); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		out.WriteByte('\n')
+		return out.Flush()
+	case 1:
+		var sb strings.Builder
+		enc := newEncoder(&sb)
+		io.WriteString(enc, data[0])
+		enc.Close()
+		return fm.ValueOutput().Put(sb.String())
+	default:
+		return errs.ArityMismatch{What: "arguments",
+			ValidLow: 0, ValidHigh: 1, Actual: len(data)}
+	}
+}
+
+// Implements a decoding command. If data has one element, it is decoded and
+// output as a string. If data is empty, the byte input is decoded and written
+// to the byte output. In both cases, whitespace in the input is ignored.
+func decode(fm *eval.Frame, data []string, newDecoder func(io.Reader) io.Reader) error {
+	switch len(data) {
+	case 0:
+		dec := newDecoder(skipSpaceReader{bufio.NewReader(fm.InputFile())})
+		_, err := io.Copy(fm.ByteOutput(), dec)
+		return err
+	case 1:
+		dec := newDecoder(strings.NewReader(stripSpace(data[0])))
+		decoded, err := io.ReadAll(dec)
+		if err != nil {
+			return err
+		}
+		return fm.ValueOutput().Put(string(decoded))
+	default:
+		return errs.ArityMismatch{What: "arguments",
+			ValidLow: 0, ValidHigh: 1, Actual: len(data)}
+	}
+}
+
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// A reader that skips ASCII whitespace in the underlying reader.
+type skipSpaceReader struct{ r *bufio.Reader }
+
+func (r skipSpaceReader) Read(p []byte) (int, error) {
+	n := 0
+	// Avoid blocking for more input if some bytes are already available.
+	for n < len(p) && (n == 0 || r.r.Buffered() > 0) {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			if n > 0 && err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+		switch b {
+		case ' ', '\t', '\n', '\r', '\v', '\f':
+		default:
+			p[n] = b
+			n++
+		}
+	}
+	return n, nil
+}
@@ -2,15 +2,103 @@ package epm_test
 
 import (
 	"embed"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
+	"src.elv.sh/pkg/eval"
 	"src.elv.sh/pkg/eval/evaltest"
 	"src.elv.sh/pkg/mods"
+	"src.elv.sh/pkg/must"
+	"src.elv.sh/pkg/testutil"
 )
 
 //go:embed *.elvts
 var transcripts embed.FS
 
 func TestTranscripts(t *testing.T) {
-	evaltest.TestTranscriptsInFS(t, transcripts, "prepare-deps", mods.AddTo)
+	evaltest.TestTranscriptsInFS(t, transcripts,
+		"prepare-deps", mods.AddTo,
+		"local-registries", setupLocalRegistries,
+	)
+}
+
+// Sets up the following in the working directory, so that epm can be tested
+// without network access:
+//
+//   - registry/x/lib: a git repository with tags v1.0.0, v1.1.0 and v2.0.0;
+//     v1.1.0 and v2.0.0 depend on example.com/x/dep@^1.0.0.
+//   - registry/x/dep: a git repository with tags v1.0.0 and v2.0.0.
+//   - local/x/plain: a plain directory, with version 0.1.0 in metadata.json.
+//   - lib: an epm-managed directory, with the example.com domain configured to
+//     fetch from registry via git, and the local.test domain configured to
+//     copy from local.
+func setupLocalRegistries(t *testing.T, ev *eval.Evaler) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	for _, name := range []string{"AUTHOR", "COMMITTER"} {
+		testutil.Setenv(t, "GIT_"+name+"_NAME", "Elvish")
+		testutil.Setenv(t, "GIT_"+name+"_EMAIL", "elvish@example.com")
+		testutil.Setenv(t, "GIT_"+name+"_DATE", "2024-01-01T00:00:00Z")
+	}
+	testutil.Setenv(t, "GIT_CONFIG_NOSYSTEM", "1")
+	testutil.Setenv(t, "GIT_CONFIG_GLOBAL", os.DevNull)
+
+	dir := must.OK1(os.Getwd())
+	testutil.ApplyDir(testutil.Dir{
+		"local": testutil.Dir{
+			"x": testutil.Dir{
+				"plain": testutil.Dir{
+					"metadata.json": `{"version": "0.1.0"}`,
+					"plain.elv":     "fn version { put 0.1.0 }\n",
+				},
+			},
+		},
+		"lib": testutil.Dir{
+			"example.com": testutil.Dir{
+				"epm-domain.cfg": `{"method": "git", "protocol": "file", "levels": 2, ` +
+					`"location": "` + filepath.ToSlash(filepath.Join(dir, "registry")) + `"}`,
+			},
+			"local.test": testutil.Dir{
+				"epm-domain.cfg": `{"method": "local", "levels": 2, ` +
+					`"location": "` + filepath.ToSlash(filepath.Join(dir, "local")) + `"}`,
+			},
+		},
+	})
+
+	dep := `{"dependencies": ["example.com/x/dep@^1.0.0"]}`
+	makeGitRepo(t, "registry/x/lib",
+		gitVersion{"v1.0.0", testutil.Dir{"lib.elv": "fn version { put 1.0.0 }\n"}},
+		gitVersion{"v1.1.0", testutil.Dir{"lib.elv": "fn version { put 1.1.0 }\n", "metadata.json": dep}},
+		gitVersion{"v2.0.0", testutil.Dir{"lib.elv": "fn version { put 2.0.0 }\n", "metadata.json": dep}})
+	makeGitRepo(t, "registry/x/dep",
+		gitVersion{"v1.0.0", testutil.Dir{"dep.elv": "fn version { put 1.0.0 }\n"}},
+		gitVersion{"v2.0.0", testutil.Dir{"dep.elv": "fn version { put 2.0.0 }\n"}})
+}
+
+type gitVersion struct {
+	tag   string
+	files testutil.Dir
+}
+
+// Creates a git repository with one tagged commit for each version.
+func makeGitRepo(t *testing.T, dir string, versions ...gitVersion) {
+	must.MkdirAll(dir)
+	git(t, dir, "init", "-q")
+	for _, version := range versions {
+		testutil.ApplyDirIn(version.files, dir)
+		git(t, dir, "add", "-A")
+		git(t, dir, "commit", "-q", "-m", version.tag)
+		git(t, dir, "tag", version.tag)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
 }
@@ -52,7 +52,10 @@ func isTTYPort(fm *eval.Frame, portNum int) bool {
 	return p != nil && sys.IsATTY(p.File.Fd())
 }
 
-func open(name string) (vals.File, error) {
+func open(fm *eval.Frame, name string) (vals.File, error) {
+	if err := fm.Evaler.Sandbox.CheckRead(name); err != nil {
+		return nil, err
+	}
 	return os.Open(name)
 }
 
@@ -71,7 +74,7 @@ func (opts *openOutputOpts) SetDefaultOptions() {
 
 var errIfNotExistsAndIfExistsBothError = errors.New("both &if-not-exists and &if-exists are error")
 
-func openOutput(opts openOutputOpts, name string) (vals.File, error) {
+func openOutput(fm *eval.Frame, opts openOutputOpts, name string) (vals.File, error) {
 	perm := opts.CreatePerm
 	if perm < 0 || perm > 0o777 {
 		return nil, errs.OutOfRange{What: "create-perm option",
@@ -108,6 +111,14 @@ func openOutput(opts openOutputOpts, name string) (vals.File, error) {
 			Valid: "truncate, append, update or error", Actual: parse.Quote(opts.IfExists)}
 	}
 
+	if opts.AlsoInput {
+		if err := fm.Evaler.Sandbox.CheckRead(name); err != nil {
+			return nil, err
+		}
+	}
+	if err := fm.Evaler.Sandbox.CheckWrite(name); err != nil {
+		return nil, err
+	}
 	return os.OpenFile(name, mode, fs.FileMode(perm))
 }
 
@@ -155,11 +166,14 @@ func tell(f vals.File) (vals.Num, error) {
 	return vals.Int64ToNum(offset), nil
 }
 
-func truncate(name string, rawSize vals.Num) error {
+func truncate(fm *eval.Frame, name string, rawSize vals.Num) error {
 	size, err := toInt64(rawSize, "size", 0, "0")
 	if err != nil {
 		return err
 	}
+	if err := fm.Evaler.Sandbox.CheckWrite(name); err != nil {
+		return err
+	}
 	return os.Truncate(name, size)
 }
 
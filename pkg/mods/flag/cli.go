@@ -0,0 +1,608 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/errs"
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/getopt"
+)
+
+// Support for declarative CLI specs, used by flag:run, flag:usage,
+// flag:completer and flag:register-completer.
+
+// A command parsed from a CLI spec.
+type command struct {
+	// Full name of the command, like "tool sub".
+	name        string
+	description string
+	flags       []*cliFlag
+	args        []cliArg
+	commands    []*command
+	fn          eval.Callable
+	parent      *command
+
+	// Option specs of flags, including the implicit help flag.
+	optSpecs []*getopt.OptionSpec
+	helpSpec *getopt.OptionSpec
+	specFlag map[*getopt.OptionSpec]*cliFlag
+}
+
+type cliFlag struct {
+	name        string
+	short       rune
+	def         any
+	description string
+	argName     string
+	complete    eval.Callable
+}
+
+type cliArg struct {
+	name        string
+	description string
+	optional    bool
+	variadic    bool
+	complete    eval.Callable
+}
+
+// Fields of a command spec map.
+type commandSpec struct {
+	Name        string
+	Description string
+	Flags       vals.List
+	Args        vals.List
+	Commands    vals.List
+	Fn          eval.Callable
+}
+
+// Fields of a flag spec map.
+type flagSpec struct {
+	Name        string
+	Short       rune
+	Default     any
+	Description string
+	ArgName     string
+	Complete    eval.Callable
+}
+
+// Fields of an argument spec map.
+type argSpec struct {
+	Name        string
+	Description string
+	Optional    bool
+	Variadic    bool
+	Complete    eval.Callable
+}
+
+var (
+	errCommandNameEmpty = errors.New("command name must not be empty")
+	errFlagNameEmpty    = errors.New("flag name must not be empty")
+	errArgNameEmpty     = errors.New("argument name must not be empty")
+	errArgsAndCommands  = errors.New("a command cannot have both arguments and subcommands")
+)
+
+func parseCommandSpec(m vals.Map) (*command, error) {
+	return parseCommandSpecWithParent(m, nil)
+}
+
+func parseCommandSpecWithParent(m vals.Map, parent *command) (*command, error) {
+	s := commandSpec{Flags: vals.EmptyList, Args: vals.EmptyList, Commands: vals.EmptyList}
+	if err := vals.ScanMapToGo(m, &s); err != nil {
+		return nil, err
+	}
+	if s.Name == "" {
+		return nil, errCommandNameEmpty
+	}
+	c := &command{name: s.Name, description: s.Description, fn: s.Fn,
+		parent: parent, specFlag: make(map[*getopt.OptionSpec]*cliFlag)}
+	if parent != nil {
+		c.name = parent.name + " " + s.Name
+	}
+
+	var flagMaps []vals.Map
+	if err := vals.ScanListToGo(s.Flags, &flagMaps); err != nil {
+		return nil, err
+	}
+	for _, flagMap := range flagMaps {
+		f, err := parseFlagSpec(flagMap)
+		if err != nil {
+			return nil, err
+		}
+		if f.name == "help" {
+			return nil, fmt.Errorf("%s: flag --help is reserved", c.name)
+		}
+		// Flags of all the commands on the path are passed to the same
+		// function, so their names must be distinct.
+		for p := c; p != nil; p = p.parent {
+			if p.flagNamed(f.name) != nil {
+				return nil, fmt.Errorf("%s: duplicate flag --%s", c.name, f.name)
+			}
+		}
+		c.flags = append(c.flags, f)
+		spec := &getopt.OptionSpec{Short: f.short, Long: f.name, Arity: getopt.RequiredArgument}
+		if _, isBool := f.def.(bool); isBool {
+			spec.Arity = getopt.OptionalArgument
+		}
+		c.optSpecs = append(c.optSpecs, spec)
+		c.specFlag[spec] = f
+	}
+	c.helpSpec = &getopt.OptionSpec{Long: "help"}
+	if c.flagWithShort('h') == nil {
+		c.helpSpec.Short = 'h'
+	}
+	c.optSpecs = append(c.optSpecs, c.helpSpec)
+
+	var argMaps []vals.Map
+	if err := vals.ScanListToGo(s.Args, &argMaps); err != nil {
+		return nil, err
+	}
+	for i, argMap := range argMaps {
+		var s argSpec
+		if err := vals.ScanMapToGo(argMap, &s); err != nil {
+			return nil, err
+		}
+		if s.Name == "" {
+			return nil, errArgNameEmpty
+		}
+		if s.Variadic && i != len(argMaps)-1 {
+			return nil, fmt.Errorf("%s: only the last argument can be variadic", c.name)
+		}
+		if !s.Optional && !s.Variadic && i > 0 && (c.args[i-1].optional) {
+			return nil, fmt.Errorf("%s: required argument <%s> cannot follow optional arguments", c.name, s.Name)
+		}
+		c.args = append(c.args, cliArg{s.Name, s.Description, s.Optional, s.Variadic, s.Complete})
+	}
+
+	var commandMaps []vals.Map
+	if err := vals.ScanListToGo(s.Commands, &commandMaps); err != nil {
+		return nil, err
+	}
+	if len(commandMaps) > 0 && len(c.args) > 0 {
+		return nil, fmt.Errorf("%s: %w", c.name, errArgsAndCommands)
+	}
+	for _, commandMap := range commandMaps {
+		sub, err := parseCommandSpecWithParent(commandMap, c)
+		if err != nil {
+			return nil, err
+		}
+		c.commands = append(c.commands, sub)
+	}
+	if c.fn == nil && len(c.commands) == 0 {
+		return nil, fmt.Errorf("%s: a command must have &fn or &commands", c.name)
+	}
+	return c, nil
+}
+
+func parseFlagSpec(m vals.Map) (*cliFlag, error) {
+	var s flagSpec
+	if err := vals.ScanMapToGo(m, &s); err != nil {
+		return nil, err
+	}
+	if s.Name == "" {
+		return nil, errFlagNameEmpty
+	}
+	switch s.Default.(type) {
+	case nil:
+		s.Default = false
+	case bool, string, int, *big.Int, *big.Rat, float64, vals.List:
+	default:
+		return nil, errs.BadValue{What: "flag default value",
+			Valid:  "boolean, number, string or list",
+			Actual: vals.ReprPlain(s.Default)}
+	}
+	if s.ArgName == "" {
+		s.ArgName = "value"
+	}
+	return &cliFlag{s.Name, s.Short, s.Default, s.Description, s.ArgName, s.Complete}, nil
+}
+
+func (c *command) flagNamed(name string) *cliFlag {
+	for _, f := range c.flags {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (c *command) flagWithShort(short rune) *cliFlag {
+	for _, f := range c.flags {
+		if f.short == short {
+			return f
+		}
+	}
+	return nil
+}
+
+func (c *command) subcommand(name string) *command {
+	for _, sub := range c.commands {
+		if sub.name == c.name+" "+name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// Converts the argument of a flag according to the type of its default value.
+func (f *cliFlag) convert(arg string) (any, error) {
+	switch f.def.(type) {
+	case bool:
+		if arg == "" {
+			return true, nil
+		}
+		return strconv.ParseBool(arg)
+	case string:
+		return arg, nil
+	case vals.List:
+		return vals.MakeListSlice(strings.Split(arg, ",")), nil
+	default:
+		var n vals.Num
+		err := vals.ScanToGo(arg, &n)
+		return n, err
+	}
+}
+
+// The result of parsing command-line arguments.
+type parsedArgs struct {
+	// The command to run.
+	command *command
+	// Values of the flags of all the commands on the path to the command.
+	flags map[string]any
+	// Positional arguments.
+	args []string
+	// Whether -h or --help was given.
+	help bool
+}
+
+func (c *command) parse(args []string) (*parsedArgs, error) {
+	flags := make(map[string]any)
+	for {
+		cfg := getopt.StopAfterDoubleDash
+		if len(c.commands) > 0 {
+			cfg |= getopt.StopBeforeFirstNonOption
+		}
+		opts, rest, err := getopt.Parse(args, c.optSpecs, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.name, err)
+		}
+		for _, f := range c.flags {
+			flags[f.name] = f.def
+		}
+		for _, opt := range opts {
+			if opt.Spec == c.helpSpec {
+				return &parsedArgs{command: c, help: true}, nil
+			}
+			f := c.specFlag[opt.Spec]
+			value, err := f.convert(opt.Argument)
+			if err != nil {
+				return nil, fmt.Errorf("%s: bad value for --%s: %s",
+					c.name, f.name, vals.ReprPlain(opt.Argument))
+			}
+			flags[f.name] = value
+		}
+
+		if len(c.commands) == 0 {
+			if err := c.checkArgs(rest); err != nil {
+				return nil, err
+			}
+			return &parsedArgs{command: c, flags: flags, args: rest}, nil
+		}
+		if len(rest) == 0 {
+			if c.fn != nil {
+				return &parsedArgs{command: c, flags: flags}, nil
+			}
+			return nil, fmt.Errorf("%s: missing command", c.name)
+		}
+		sub := c.subcommand(rest[0])
+		if sub == nil {
+			return nil, fmt.Errorf("%s: unknown command %s", c.name, vals.ReprPlain(rest[0]))
+		}
+		c, args = sub, rest[1:]
+	}
+}
+
+func (c *command) checkArgs(args []string) error {
+	for i, arg := range c.args {
+		if i >= len(args) {
+			if arg.optional || arg.variadic {
+				return nil
+			}
+			return fmt.Errorf("%s: missing argument <%s>", c.name, arg.name)
+		}
+	}
+	if len(args) > len(c.args) && (len(c.args) == 0 || !c.args[len(c.args)-1].variadic) {
+		return fmt.Errorf("%s: unexpected argument %s", c.name, vals.ReprPlain(args[len(c.args)]))
+	}
+	return nil
+}
+
+// Returns the usage text of the command.
+func (c *command) usage() string {
+	var sb strings.Builder
+	sb.WriteString("Usage: " + c.name + " [flags]")
+	switch {
+	case len(c.commands) > 0 && c.fn != nil:
+		sb.WriteString(" [<command>]")
+	case len(c.commands) > 0:
+		sb.WriteString(" <command>")
+	}
+	for _, arg := range c.args {
+		switch {
+		case arg.variadic:
+			sb.WriteString(" [<" + arg.name + ">...]")
+		case arg.optional:
+			sb.WriteString(" [<" + arg.name + ">]")
+		default:
+			sb.WriteString(" <" + arg.name + ">")
+		}
+	}
+	sb.WriteString("\n")
+	if c.description != "" {
+		sb.WriteString("\n" + c.description + "\n")
+	}
+
+	if len(c.commands) > 0 {
+		var rows [][2]string
+		for _, sub := range c.commands {
+			rows = append(rows, [2]string{sub.name[len(c.name)+1:], sub.description})
+		}
+		writeTable(&sb, "Commands", rows)
+	}
+	if len(c.args) > 0 {
+		var rows [][2]string
+		for _, arg := range c.args {
+			rows = append(rows, [2]string{"<" + arg.name + ">", arg.description})
+		}
+		writeTable(&sb, "Arguments", rows)
+	}
+	var rows [][2]string
+	for _, f := range c.flags {
+		left := "    --" + f.name
+		if f.short != 0 {
+			left = "-" + string(f.short) + ", --" + f.name
+		}
+		if _, isBool := f.def.(bool); !isBool {
+			left += " <" + f.argName + ">"
+		}
+		right := f.description
+		if def := defaultString(f.def); def != "" {
+			right = strings.TrimLeft(right+" (default "+def+")", " ")
+		}
+		rows = append(rows, [2]string{left, right})
+	}
+	helpLeft := "    --help"
+	if c.helpSpec.Short != 0 {
+		helpLeft = "-h, --help"
+	}
+	rows = append(rows, [2]string{helpLeft, "Show this help"})
+	writeTable(&sb, "Flags", rows)
+	return sb.String()
+}
+
+// Returns how the default value of a flag is shown in the usage text, or ""
+// if it should not be shown.
+func defaultString(def any) string {
+	switch def := def.(type) {
+	case bool:
+		if def {
+			return "true"
+		}
+		return ""
+	case vals.List:
+		var elems []string
+		for it := def.Iterator(); it.HasElem(); it.Next() {
+			elems = append(elems, vals.ToString(it.Elem()))
+		}
+		return strings.Join(elems, ",")
+	default:
+		s := vals.ToString(def)
+		if s == "0" {
+			return ""
+		}
+		return s
+	}
+}
+
+func writeTable(sb *strings.Builder, title string, rows [][2]string) {
+	width := 0
+	for _, row := range rows {
+		if len(row[0]) > width {
+			width = len(row[0])
+		}
+	}
+	sb.WriteString("\n" + title + ":\n")
+	for _, row := range rows {
+		if row[1] == "" {
+			sb.WriteString("  " + row[0] + "\n")
+		} else {
+			fmt.Fprintf(sb, "  %-*s  %s\n", width, row[0], row[1])
+		}
+	}
+}
+
+func run(fm *eval.Frame, specMap vals.Map, argsVal vals.List) error {
+	c, err := parseCommandSpec(specMap)
+	if err != nil {
+		return err
+	}
+	var args []string
+	if err := vals.ScanListToGo(argsVal, &args); err != nil {
+		return err
+	}
+	parsed, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	if parsed.help {
+		_, err := fm.ByteOutput().WriteString(parsed.command.usage())
+		return err
+	}
+	fn, opts := parsed.command.fn, parsed.flags
+	if closure, ok := fn.(*eval.Closure); ok {
+		// Only pass the flags that the function declares as options.
+		opts = make(map[string]any)
+		for _, name := range closure.OptNames {
+			if value, ok := parsed.flags[name]; ok {
+				opts[name] = value
+			}
+		}
+	}
+	return fn.Call(fm.Fork("flag:run"), callArgs(parsed.args), opts)
+}
+
+func usage(specMap vals.Map, names ...string) (string, error) {
+	c, err := parseCommandSpec(specMap)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range names {
+		sub := c.subcommand(name)
+		if sub == nil {
+			return "", fmt.Errorf("%s: unknown command %s", c.name, vals.ReprPlain(name))
+		}
+		c = sub
+	}
+	return c.usage(), nil
+}
+
+func completer(specMap vals.Map) (eval.Callable, error) {
+	c, err := parseCommandSpec(specMap)
+	if err != nil {
+		return nil, err
+	}
+	return eval.NewGoFn("flag:completer", c.complete), nil
+}
+
+func registerCompleter(fm *eval.Frame, specMap vals.Map) error {
+	c, err := parseCommandSpec(specMap)
+	if err != nil {
+		return err
+	}
+	editNs, ok := nsIn(fm.Evaler.Builtin(), "edit")
+	if !ok {
+		return errNoEdit
+	}
+	completionNs, ok := nsIn(editNs, "completion")
+	if !ok {
+		return errNoEdit
+	}
+	v := completionNs.IndexString("arg-completer")
+	if v == nil {
+		return errNoEdit
+	}
+	m, ok := v.Get().(vals.Map)
+	if !ok {
+		return errNoEdit
+	}
+	return v.Set(m.Assoc(c.name, eval.NewGoFn("flag:completer", c.complete)))
+}
+
+var errNoEdit = errors.New("the edit: module is not available; completers can only be registered in the interactive REPL")
+
+// Generates completion candidates for the arguments of the command, with
+// words[0] being the command itself, and the last element of words being the
+// word to complete.
+func (c *command) complete(fm *eval.Frame, words ...string) error {
+	completeGetopt, ok := editFn(fm, "complete-getopt")
+	if !ok {
+		return errNoEdit
+	}
+	if len(words) < 2 {
+		return nil
+	}
+	args := words[1:]
+	// Find the subcommand being completed.
+	for len(c.commands) > 0 {
+		_, rest, err := getopt.Parse(args[:len(args)-1], c.optSpecs,
+			getopt.StopAfterDoubleDash|getopt.StopBeforeFirstNonOption)
+		if err != nil || len(rest) == 0 {
+			break
+		}
+		sub := c.subcommand(rest[0])
+		if sub == nil {
+			break
+		}
+		c, args = sub, args[len(args)-len(rest):]
+	}
+
+	optSpecs := make([]any, len(c.optSpecs))
+	for i, spec := range c.optSpecs {
+		m := vals.MakeMap("long", spec.Long)
+		if spec.Short != 0 {
+			m = m.Assoc("short", string(spec.Short))
+		}
+		if spec == c.helpSpec {
+			m = m.Assoc("desc", "Show this help")
+		} else {
+			f := c.specFlag[spec]
+			if f.description != "" {
+				m = m.Assoc("desc", f.description)
+			}
+			if spec.Arity == getopt.OptionalArgument {
+				m = m.Assoc("arg-optional", true)
+			} else {
+				m = m.Assoc("arg-required", true).Assoc("arg-desc", "<"+f.argName+">")
+			}
+			if f.complete != nil {
+				m = m.Assoc("completer", f.complete)
+			}
+		}
+		optSpecs[i] = m
+	}
+
+	var argHandlers []any
+	if len(c.commands) > 0 {
+		argHandlers = append(argHandlers, eval.NewGoFn("flag:complete-command",
+			func(_ string) []string {
+				names := make([]string, len(c.commands))
+				for i, sub := range c.commands {
+					names[i] = sub.name[len(c.name)+1:]
+				}
+				return names
+			}))
+	}
+	for _, arg := range c.args {
+		handler := arg.complete
+		if handler == nil {
+			handler, ok = editFn(fm, "complete-filename")
+			if !ok {
+				handler = eval.NewGoFn("flag:complete-nothing", func(_ string) {})
+			}
+		}
+		argHandlers = append(argHandlers, handler)
+		if arg.variadic {
+			argHandlers = append(argHandlers, "...")
+		}
+	}
+
+	return completeGetopt.Call(fm, []any{
+		vals.MakeListSlice(args), vals.MakeListSlice(optSpecs),
+		vals.MakeListSlice(argHandlers)}, eval.NoOpts)
+}
+
+func nsIn(ns *eval.Ns, name string) (*eval.Ns, bool) {
+	v, ok := ns.Index(name + eval.NsSuffix)
+	if !ok {
+		return nil, false
+	}
+	sub, ok := v.(*eval.Ns)
+	return sub, ok
+}
+
+func editFn(fm *eval.Frame, name string) (eval.Callable, bool) {
+	editNs, ok := nsIn(fm.Evaler.Builtin(), "edit")
+	if !ok {
+		return nil, false
+	}
+	v, ok := editNs.Index(name + eval.FnSuffix)
+	if !ok {
+		return nil, false
+	}
+	fn, ok := v.(eval.Callable)
+	return fn, ok
+}
@@ -19,6 +19,11 @@ var Ns = eval.BuildNsNamed("flag").
 		"call":         call,
 		"parse":        parse,
 		"parse-getopt": parseGetopt,
+
+		"run":                run,
+		"usage":              usage,
+		"completer":          completer,
+		"register-completer": registerCompleter,
 	}).Ns()
 
 func call(fm *eval.Frame, fn *eval.Closure, argsVal vals.List) error {
@@ -0,0 +1,110 @@
+// Package hash implements the hash: module, which provides cryptographic hash
+// functions and related utilities.
+package hash
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	gohash "hash"
+	"io"
+	"strconv"
+
+	"golang.org/x/crypto/blake2b"
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/errs"
+)
+
+// Ns is the namespace for the hash: module.
+var Ns = eval.BuildNsNamed("hash").
+	AddGoFns(map[string]any{
+		"md5":     hashFn(md5.New),
+		"sha1":    hashFn(sha1.New),
+		"sha256":  hashFn(sha256.New),
+		"sha512":  hashFn(sha512.New),
+		"blake2b": hashFn(newBlake2b),
+
+		"hmac":       hmacFn,
+		"rand-bytes": randBytes,
+	}).Ns()
+
+// Algorithms supported by hash:hmac.
+var algorithms = map[string]func() gohash.Hash{
+	"md5":     md5.New,
+	"sha1":    sha1.New,
+	"sha256":  sha256.New,
+	"sha512":  sha512.New,
+	"blake2b": newBlake2b,
+}
+
+func newBlake2b() gohash.Hash {
+	// This only returns an error for invalid keys.
+	h, _ := blake2b.New512(nil)
+	return h
+}
+
+type hashOpts struct{ Raw bool }
+
+func (*hashOpts) SetDefaultOptions() {}
+
+// Returns the implementation of a hash function command. The command hashes
+// its argument if given, or its byte input otherwise.
+func hashFn(newHash func() gohash.Hash) any {
+	return func(fm *eval.Frame, opts hashOpts, data ...string) error {
+		return sum(fm, newHash(), opts.Raw, data)
+	}
+}
+
+type hmacOpts struct {
+	Algorithm string
+	Raw       bool
+}
+
+func (o *hmacOpts) SetDefaultOptions() { o.Algorithm = "sha256" }
+
+func hmacFn(fm *eval.Frame, opts hmacOpts, key string, data ...string) error {
+	newHash, ok := algorithms[opts.Algorithm]
+	if !ok {
+		return errs.BadValue{What: "&algorithm",
+			Valid: "md5, sha1, sha256, sha512 or blake2b", Actual: opts.Algorithm}
+	}
+	return sum(fm, hmac.New(newHash, []byte(key)), opts.Raw, data)
+}
+
+// Writes data (which can have at most one element) to h, or copies the byte
+// input to h if data is empty, and outputs the sum.
+func sum(fm *eval.Frame, h gohash.Hash, raw bool, data []string) error {
+	switch len(data) {
+	case 0:
+		if _, err := io.Copy(h, fm.InputFile()); err != nil {
+			return err
+		}
+	case 1:
+		io.WriteString(h, data[0])
+	default:
+		return errs.ArityMismatch{What: "arguments",
+			ValidLow: 0, ValidHigh: 1, Actual: len(data)}
+	}
+	sum := h.Sum(nil)
+	if raw {
+		_, err := fm.ByteOutput().Write(sum)
+		return err
+	}
+	return fm.ValueOutput().Put(hex.EncodeToString(sum))
+}
+
+// Upper bound of the argument to rand-bytes, to guard against mistakes.
+const maxRandBytes = 1 << 20
+
+func randBytes(fm *eval.Frame, n int) error {
+	if n < 0 || n > maxRandBytes {
+		return errs.OutOfRange{What: "number of bytes",
+			ValidLow: "0", ValidHigh: strconv.Itoa(maxRandBytes), Actual: strconv.Itoa(n)}
+	}
+	_, err := io.CopyN(fm.ByteOutput(), rand.Reader, int64(n))
+	return err
+}
@@ -0,0 +1,333 @@
+// Package http implements the http: module, a simple HTTP client.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/errs"
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/parse"
+)
+
+// Ns is the namespace for the http: module.
+var Ns = eval.BuildNsNamed("http").
+	AddGoFns(map[string]any{
+		"request": request,
+		"get":     get,
+		"post":    post,
+	}).Ns()
+
+type requestOpts struct {
+	Method  string
+	Headers any
+	Body    any
+	Timeout any
+	Check   bool
+	Stream  bool
+}
+
+func (o *requestOpts) SetDefaultOptions() {
+	o.Method = http.MethodGet
+	o.Check = true
+}
+
+type getOpts struct {
+	Headers any
+	Timeout any
+	Check   bool
+	Stream  bool
+}
+
+func (o *getOpts) SetDefaultOptions() { o.Check = true }
+
+type postOpts struct {
+	Headers any
+	Body    any
+	Timeout any
+	Check   bool
+	Stream  bool
+}
+
+func (o *postOpts) SetDefaultOptions() { o.Check = true }
+
+func get(fm *eval.Frame, opts getOpts, u string) error {
+	return request(fm, requestOpts{http.MethodGet, opts.Headers, nil,
+		opts.Timeout, opts.Check, opts.Stream}, u)
+}
+
+func post(fm *eval.Frame, opts postOpts, u string) error {
+	return request(fm, requestOpts{http.MethodPost, opts.Headers, opts.Body,
+		opts.Timeout, opts.Check, opts.Stream}, u)
+}
+
+// The client used for all requests. The proxy is determined from the
+// environment for each request, so that changes to $E:HTTP_PROXY and friends
+// take effect immediately; [http.ProxyFromEnvironment] only reads the
+// environment once.
+var client = &http.Client{Transport: newTransport()}
+
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxyFromEnvironment
+	return t
+}
+
+// Sends a request and outputs the response.
+func request(fm *eval.Frame, opts requestOpts, u string) error {
+	method := strings.ToUpper(opts.Method)
+	var timeout time.Duration
+	if opts.Timeout != nil {
+		var err error
+		timeout, err = eval.ParsePositiveDuration("&timeout", opts.Timeout)
+		if err != nil {
+			return err
+		}
+	}
+	body, isJSON, err := makeBody(opts.Body)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := fm.Context(), context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	// When streaming, the timeout also applies to reading the body, so cancel
+	// is called when streaming is done instead.
+	streaming := false
+	defer func() {
+		if !streaming {
+			cancel()
+		}
+	}()
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	if isJSON {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := addHeaders(req.Header, opts.Headers); err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return newRequestError(fm, req, err)
+	}
+
+	r := &Response{resp.StatusCode, resp.Status, headersToMap(resp.Header),
+		resp.Request.URL.String(), nil}
+	if opts.Check && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		resp.Body.Close()
+		return StatusError{method, u, resp.StatusCode, resp.Status}
+	}
+	if opts.Stream {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			resp.Body.Close()
+			return err
+		}
+		streaming = true
+		go func() {
+			// Errors are ignored here; the reader sees a truncated body. The
+			// copy also stops when the read end is closed.
+			io.Copy(pw, resp.Body)
+			pw.Close()
+			resp.Body.Close()
+			cancel()
+		}()
+		r.body = pr
+		return fm.ValueOutput().Put(r)
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return newRequestError(fm, req, err)
+	}
+	r.body = string(content)
+	return fm.ValueOutput().Put(r)
+}
+
+// Converts the &body option to a reader. Strings are used as is, files are
+// read from, and lists and maps are encoded as JSON.
+func makeBody(v any) (body io.Reader, isJSON bool, err error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, false, nil
+	case string:
+		return strings.NewReader(v), false, nil
+	case vals.File:
+		return v, false, nil
+	case vals.List, vals.Map:
+		content, err := json.Marshal(v)
+		if err != nil {
+			return nil, false, err
+		}
+		return strings.NewReader(string(content)), true, nil
+	default:
+		return nil, false, errs.BadValue{What: "&body",
+			Valid: "nil, string, file, list or map", Actual: vals.Kind(v)}
+	}
+}
+
+// Adds headers from the &headers option, which must be nil or a map from
+// strings to strings or lists of strings.
+func addHeaders(h http.Header, v any) error {
+	if v == nil {
+		return nil
+	}
+	m, ok := v.(vals.Map)
+	if !ok {
+		return errs.BadValue{What: "&headers",
+			Valid: "nil or map", Actual: vals.Kind(v)}
+	}
+	for it := m.Iterator(); it.HasElem(); it.Next() {
+		k, v := it.Elem()
+		name, ok := k.(string)
+		if !ok {
+			return errs.BadValue{What: "header name",
+				Valid: "string", Actual: vals.Kind(k)}
+		}
+		switch v := v.(type) {
+		case string:
+			h.Set(name, v)
+		case vals.List:
+			h.Del(name)
+			for it := v.Iterator(); it.HasElem(); it.Next() {
+				s, ok := it.Elem().(string)
+				if !ok {
+					return errs.BadValue{What: "header value",
+						Valid: "string", Actual: vals.Kind(it.Elem())}
+				}
+				h.Add(name, s)
+			}
+		default:
+			return errs.BadValue{What: "header value",
+				Valid: "string or list", Actual: vals.Kind(v)}
+		}
+	}
+	return nil
+}
+
+// Converts headers to a map from canonical header names to strings. Multiple
+// values of the same header are joined with ", ".
+func headersToMap(h http.Header) vals.Map {
+	m := vals.EmptyMap
+	for name, values := range h {
+		m = m.Assoc(name, strings.Join(values, ", "))
+	}
+	return m
+}
+
+// Response is the response of an HTTP request.
+type Response struct {
+	status     int
+	statusText string
+	headers    vals.Map
+	url        string
+	body       any
+}
+
+var _ vals.PseudoMap = &Response{}
+
+func (r *Response) Kind() string           { return "http-response" }
+func (r *Response) Fields() vals.StructMap { return responseFields{r} }
+
+type responseFields struct{ r *Response }
+
+func (responseFields) IsStructMap()         {}
+func (f responseFields) Status() int        { return f.r.status }
+func (f responseFields) StatusText() string { return f.r.statusText }
+func (f responseFields) Headers() vals.Map  { return f.r.headers }
+func (f responseFields) Url() string        { return f.r.url }
+func (f responseFields) Body() any          { return f.r.body }
+
+// StatusError is thrown when a request with &check gets a response with a
+// status other than 2xx.
+type StatusError struct {
+	Method     string
+	URL        string
+	Status     int
+	StatusText string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Method, parse.Quote(e.URL), e.StatusText)
+}
+
+func (e StatusError) Kind() string           { return "http-error" }
+func (e StatusError) Fields() vals.StructMap { return statusErrorFields{e} }
+
+type statusErrorFields struct{ e StatusError }
+
+func (statusErrorFields) IsStructMap()         {}
+func (f statusErrorFields) Type() string       { return "http-status" }
+func (f statusErrorFields) Method() string     { return f.e.Method }
+func (f statusErrorFields) Url() string        { return f.e.URL }
+func (f statusErrorFields) Status() int        { return f.e.Status }
+func (f statusErrorFields) StatusText() string { return f.e.StatusText }
+
+// RequestError is thrown when a request fails without getting a response, or
+// when reading the body of the response fails.
+type RequestError struct {
+	Method   string
+	URL      string
+	TimedOut bool
+	// The underlying error.
+	Err error
+}
+
+func newRequestError(fm *eval.Frame, req *http.Request, err error) error {
+	if fm.Context().Err() != nil {
+		// Interrupted by the user rather than failed.
+		return eval.ErrInterrupted
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// The URL and method are already part of RequestError.
+		err = urlErr.Err
+	}
+	timedOut := errors.Is(err, context.DeadlineExceeded)
+	return RequestError{req.Method, req.URL.String(), timedOut, err}
+}
+
+func (e RequestError) Error() string {
+	if e.TimedOut {
+		return fmt.Sprintf("%s %s: timed out", e.Method, parse.Quote(e.URL))
+	}
+	return fmt.Sprintf("%s %s: %v", e.Method, parse.Quote(e.URL), e.Err)
+}
+
+func (e RequestError) Unwrap() error { return e.Err }
+
+func (e RequestError) Kind() string           { return "http-error" }
+func (e RequestError) Fields() vals.StructMap { return requestErrorFields{e} }
+
+type requestErrorFields struct{ e RequestError }
+
+func (requestErrorFields) IsStructMap()      {}
+func (f requestErrorFields) Type() string    { return "http-request" }
+func (f requestErrorFields) Method() string  { return f.e.Method }
+func (f requestErrorFields) Url() string     { return f.e.URL }
+func (f requestErrorFields) TimedOut() bool  { return f.e.TimedOut }
+func (f requestErrorFields) Message() string { return f.e.Err.Error() }
+
+// Determines the proxy to use for a request from the environment variables
+// HTTP_PROXY, HTTPS_PROXY, NO_PROXY and REQUEST_METHOD (or their lowercase
+// versions), using the same rules as [http.ProxyFromEnvironment], but reading
+// them every time.
+func proxyFromEnvironment(req *http.Request) (*url.URL, error) {
+	return httpproxy.FromEnvironment().ProxyFunc()(req.URL)
+}
@@ -0,0 +1,67 @@
+package http_test
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/evaltest"
+	"src.elv.sh/pkg/eval/vars"
+	"src.elv.sh/pkg/testutil"
+)
+
+//go:embed *.elvts
+var transcripts embed.FS
+
+func TestTranscripts(t *testing.T) {
+	evaltest.TestTranscriptsInFS(t, transcripts,
+		"with-server", func(t *testing.T, ev *eval.Evaler) {
+			server := httptest.NewServer(http.HandlerFunc(serve))
+			t.Cleanup(server.Close)
+			ev.ExtendGlobal(eval.BuildNs().AddVar("server", vars.NewReadOnly(server.URL)))
+		},
+		"with-proxy", func(t *testing.T) {
+			proxy := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					// Requests to proxies have absolute URLs.
+					fmt.Fprintf(w, "proxied %s", r.URL)
+				}))
+			t.Cleanup(proxy.Close)
+			testutil.Setenv(t, "HTTP_PROXY", proxy.URL)
+			testutil.Setenv(t, "NO_PROXY", "")
+		},
+	)
+}
+
+func serve(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/echo":
+		// Echoes the method, the X-Test and Content-Type headers, and the body.
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		fmt.Fprintf(w, "%s %q %q %s", r.Method, r.Header.Values("X-Test"),
+			r.Header.Get("Content-Type"), body)
+	case "/hello":
+		fmt.Fprintln(w, "hello")
+	case "/redirect":
+		http.Redirect(w, r, "/echo", http.StatusFound)
+	case "/slow":
+		select {
+		case <-time.After(testutil.Scaled(time.Second)):
+		case <-r.Context().Done():
+		}
+	default:
+		if status, err := strconv.Atoi(r.URL.Path[1:]); err == nil {
+			w.WriteHeader(status)
+			fmt.Fprintf(w, "status %d", status)
+			return
+		}
+		http.NotFound(w, r)
+	}
+}
@@ -3,18 +3,24 @@ package mods
 
 import (
 	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/mods/archive"
 	"src.elv.sh/pkg/mods/doc"
+	"src.elv.sh/pkg/mods/encoding"
 	"src.elv.sh/pkg/mods/epm"
 	"src.elv.sh/pkg/mods/file"
 	"src.elv.sh/pkg/mods/flag"
+	"src.elv.sh/pkg/mods/hash"
+	"src.elv.sh/pkg/mods/http"
 	"src.elv.sh/pkg/mods/math"
 	"src.elv.sh/pkg/mods/os"
 	"src.elv.sh/pkg/mods/path"
 	"src.elv.sh/pkg/mods/platform"
+	"src.elv.sh/pkg/mods/proc"
 	"src.elv.sh/pkg/mods/re"
 	readline_binding "src.elv.sh/pkg/mods/readline-binding"
 	"src.elv.sh/pkg/mods/runtime"
 	"src.elv.sh/pkg/mods/str"
+	"src.elv.sh/pkg/mods/test"
 	"src.elv.sh/pkg/mods/unix"
 )
 
@@ -33,6 +39,12 @@ func AddTo(ev *eval.Evaler) {
 	ev.AddModule("flag", flag.Ns)
 	ev.AddModule("doc", doc.Ns)
 	ev.AddModule("os", os.Ns)
+	ev.AddModule("proc", proc.Ns)
+	ev.AddModule("http", http.Ns)
+	ev.AddModule("hash", hash.Ns)
+	ev.AddModule("encoding", encoding.Ns)
+	ev.AddModule("archive", archive.Ns)
+	ev.AddModule("test", test.Ns)
 	if unix.ExposeUnixNs {
 		ev.AddModule("unix", unix.Ns)
 	}
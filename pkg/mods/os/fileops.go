@@ -0,0 +1,249 @@
+package os
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/errs"
+	"src.elv.sh/pkg/eval/vals"
+)
+
+// Copies the file, directory or symlink at src to dst. Directories are copied
+// recursively, and the permission bits and special modes of files and
+// directories are preserved.
+func copyPath(fm *eval.Frame, src, dst string) error {
+	if src == "" || dst == "" {
+		return ErrEmptyPath
+	}
+	if err := checkRead(fm, src); err != nil {
+		return err
+	}
+	if err := checkWrite(fm, dst); err != nil {
+		return err
+	}
+	fi, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	// Stat follows symlinks, so a dst that is a symlink to src is also caught.
+	if dstFi, err := os.Stat(dst); err == nil && os.SameFile(fi, dstFi) {
+		return &fs.PathError{Op: "copy", Path: dst, Err: errCopyToSelf}
+	}
+	if fi.IsDir() {
+		inside, err := eval.PathInside(dst, src)
+		if err != nil {
+			return err
+		}
+		if inside {
+			return &fs.PathError{Op: "copy", Path: dst, Err: errCopyIntoSelf}
+		}
+	}
+	return copyInner(fm, src, dst, fi)
+}
+
+// Copies src to dst, where the write permission of dst has been checked.
+func copyInner(fm *eval.Frame, src, dst string, fi fs.FileInfo) error {
+	switch {
+	case fi.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case fi.IsDir():
+		err := os.Mkdir(dst, 0700)
+		if err != nil && !isDir(dst) {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			entryFi, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			name := entry.Name()
+			entryDst := filepath.Join(dst, name)
+			// Existing entries in dst may be symlinks to outside the
+			// write roots of the sandbox.
+			if err := checkWrite(fm, entryDst); err != nil {
+				return err
+			}
+			err = copyInner(fm, filepath.Join(src, name), entryDst, entryFi)
+			if err != nil {
+				return err
+			}
+		}
+		// The mode is set after the content is copied, in case it doesn't
+		// allow writing.
+		return os.Chmod(dst, fi.Mode()&(fs.ModePerm|specialModeMask))
+	case fi.Mode().IsRegular():
+		return copyFile(src, dst, fi.Mode())
+	default:
+		return &fs.PathError{Op: "copy", Path: src, Err: errUnsupportedFileType}
+	}
+}
+
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dst, mode&(fs.ModePerm|specialModeMask))
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+var (
+	errUnsupportedFileType = errors.New("unsupported file type")
+	errCopyToSelf          = errors.New("destination is the same as the source")
+	errCopyIntoSelf        = errors.New("destination is inside the source directory")
+)
+
+// Outputs the stat maps of the entries in the directory at path, sorted by
+// name. Like os:stat without &follow-symlink, symlinks are not followed.
+func readDir(fm *eval.Frame, path string) error {
+	if err := checkRead(fm, path); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	out := fm.ValueOutput()
+	for _, entry := range entries {
+		fi, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		err = out.Put(statMap(fi))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type walkOpts struct {
+	Prune         eval.Callable
+	FollowSymlink bool
+}
+
+func (*walkOpts) SetDefaultOptions() {}
+
+// Walks the file tree rooted at root in lexical order, outputting the path of
+// each file and directory.
+func walk(fm *eval.Frame, opts walkOpts, root string) error {
+	if err := checkRead(fm, root); err != nil {
+		return err
+	}
+	out := fm.ValueOutput()
+	visit := func(path string, d fs.DirEntry) error {
+		if checkRead(fm, path) != nil {
+			// Skip paths outside the sandbox, which can be reached by
+			// following symlinks.
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if opts.Prune != nil {
+			prune, err := callPrune(fm, opts.Prune, path)
+			if err != nil {
+				return err
+			}
+			if prune {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		return out.Put(path)
+	}
+	if !opts.FollowSymlink {
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			return visit(path, d)
+		})
+	}
+	return walkFollow(root, visit, map[string]bool{})
+}
+
+// Like [filepath.WalkDir], but follows symlinks to directories. The visited
+// map records the real paths of directories that have been visited to avoid
+// walking into cycles. Broken symlinks are visited as themselves.
+func walkFollow(path string, visit func(string, fs.DirEntry) error, visited map[string]bool) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		var lstatErr error
+		fi, lstatErr = os.Lstat(path)
+		if lstatErr != nil || fi.Mode()&fs.ModeSymlink == 0 {
+			return err
+		}
+	}
+	d := fs.FileInfoToDirEntry(fi)
+	err = visit(path, d)
+	if err != nil || !fi.IsDir() {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	if visited[realPath] {
+		return nil
+	}
+	visited[realPath] = true
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err := walkFollow(filepath.Join(path, entry.Name()), visit, visited)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func callPrune(fm *eval.Frame, f eval.Callable, path string) (bool, error) {
+	outputs, err := fm.CaptureOutput(func(fm *eval.Frame) error {
+		return f.Call(fm, []any{path}, eval.NoOpts)
+	})
+	if err != nil {
+		return false, err
+	} else if len(outputs) != 1 {
+		return false, errs.ArityMismatch{
+			What:     "number of outputs of the &prune callback",
+			ValidLow: 1, ValidHigh: 1, Actual: len(outputs)}
+	}
+	return vals.Bool(outputs[0]), nil
+}
@@ -3,9 +3,11 @@ package os
 
 import (
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"src.elv.sh/pkg/eval"
 	"src.elv.sh/pkg/eval/errs"
@@ -27,8 +29,20 @@ var Ns = eval.BuildNsNamed("os").
 		"remove":     remove,
 		"remove-all": removeAll,
 		"chmod":      chmod,
+		"chown":      chown,
+		"chtimes":    chtimes,
 
-		"eval-symlinks": filepath.EvalSymlinks,
+		"copy":   copyPath,
+		"rename": rename,
+
+		"symlink":       symlink,
+		"readlink":      readlink,
+		"eval-symlinks": evalSymlinks,
+
+		"read-dir": readDir,
+		"walk":     walk,
+
+		"watch": watch,
 
 		"stat":       stat,
 		"exists":     exists,
@@ -49,11 +63,35 @@ func isNotExist(e eval.Exception) bool {
 	return os.IsNotExist(e.Reason())
 }
 
+// Returns the first error from checking whether each of the paths can be read
+// within the sandbox.
+func checkRead(fm *eval.Frame, paths ...string) error {
+	for _, path := range paths {
+		if err := fm.Evaler.Sandbox.CheckRead(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Like checkRead, but checks whether the paths can be written.
+func checkWrite(fm *eval.Frame, paths ...string) error {
+	for _, path := range paths {
+		if err := fm.Evaler.Sandbox.CheckWrite(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type mkdirOpts struct{ Perm int }
 
 func (opts *mkdirOpts) SetDefaultOptions() { opts.Perm = 0755 }
 
-func mkdir(opts mkdirOpts, path string) error {
+func mkdir(fm *eval.Frame, opts mkdirOpts, path string) error {
+	if err := checkWrite(fm, path); err != nil {
+		return err
+	}
 	return os.Mkdir(path, os.FileMode(opts.Perm))
 }
 
@@ -62,20 +100,26 @@ var ErrEmptyPath = errs.BadValue{
 	What: "path", Valid: "non-empty string", Actual: "empty string"}
 
 // Wraps [os.Remove] to reject empty paths.
-func remove(path string) error {
+func remove(fm *eval.Frame, path string) error {
 	if path == "" {
 		return ErrEmptyPath
 	}
+	if err := checkWrite(fm, path); err != nil {
+		return err
+	}
 	return os.Remove(path)
 }
 
 // Wraps [os.RemoveAll] to reject empty paths, and resolve relative paths to
 // absolute paths first. The latter is necessary since the working directory
 // could be changed while [os.RemoveAll] is running.
-func removeAll(path string) error {
+func removeAll(fm *eval.Frame, path string) error {
 	if path == "" {
 		return ErrEmptyPath
 	}
+	if err := checkWrite(fm, path); err != nil {
+		return err
+	}
 	if !filepath.IsAbs(path) {
 		absPath, err := filepath.Abs(path)
 		if err != nil {
@@ -92,7 +136,10 @@ type chmodOpts struct {
 
 func (*chmodOpts) SetDefaultOptions() {}
 
-func chmod(opts chmodOpts, perm int, path string) error {
+func chmod(fm *eval.Frame, opts chmodOpts, perm int, path string) error {
+	if err := checkWrite(fm, path); err != nil {
+		return err
+	}
 	if perm < 0 || perm > 0x777 {
 		return errs.OutOfRange{What: "permission bits",
 			ValidLow: "0", ValidHigh: "0o777", Actual: strconv.Itoa(perm)}
@@ -108,11 +155,99 @@ func chmod(opts chmodOpts, perm int, path string) error {
 	return os.Chmod(path, mode)
 }
 
+type chownOpts struct{ FollowSymlink bool }
+
+func (opts *chownOpts) SetDefaultOptions() { opts.FollowSymlink = true }
+
+func chown(fm *eval.Frame, opts chownOpts, uid, gid int, path string) error {
+	if err := checkWrite(fm, path); err != nil {
+		return err
+	}
+	if opts.FollowSymlink {
+		return os.Chown(path, uid, gid)
+	}
+	return os.Lchown(path, uid, gid)
+}
+
+type chtimesOpts struct {
+	Atime any
+	Mtime any
+}
+
+func (*chtimesOpts) SetDefaultOptions() {}
+
+func chtimes(fm *eval.Frame, opts chtimesOpts, path string) error {
+	if err := checkWrite(fm, path); err != nil {
+		return err
+	}
+	now := time.Now()
+	atime, err := parseTime("&atime", opts.Atime, now)
+	if err != nil {
+		return err
+	}
+	mtime, err := parseTime("&mtime", opts.Mtime, now)
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(path, atime, mtime)
+}
+
+// Parses a time given as either a number of seconds since the Unix epoch or a
+// string in RFC 3339 format. A nil value is parsed as def.
+func parseTime(what string, v any, def time.Time) (time.Time, error) {
+	if v == nil {
+		return def, nil
+	}
+	var f float64
+	if err := vals.ScanToGo(v, &f); err == nil {
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	}
+	if s, ok := v.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errs.BadValue{What: what,
+		Valid: "number of seconds or RFC 3339 time string", Actual: vals.ReprPlain(v)}
+}
+
 type statOpts struct{ FollowSymlink bool }
 
 func (opts *statOpts) SetDefaultOptions() {}
 
-func stat(opts statOpts, path string) (vals.Map, error) {
+func rename(fm *eval.Frame, oldPath, newPath string) error {
+	if err := checkWrite(fm, oldPath, newPath); err != nil {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+func symlink(fm *eval.Frame, target, path string) error {
+	if err := checkWrite(fm, path); err != nil {
+		return err
+	}
+	return os.Symlink(target, path)
+}
+
+func readlink(fm *eval.Frame, path string) (string, error) {
+	if err := checkRead(fm, path); err != nil {
+		return "", err
+	}
+	return os.Readlink(path)
+}
+
+func evalSymlinks(fm *eval.Frame, path string) (string, error) {
+	if err := checkRead(fm, path); err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+func stat(fm *eval.Frame, opts statOpts, path string) (vals.Map, error) {
+	if err := checkRead(fm, path); err != nil {
+		return nil, err
+	}
 	fi, err := statOrLstat(path, opts.FollowSymlink)
 	if err != nil {
 		return nil, err
@@ -120,23 +255,32 @@ func stat(opts statOpts, path string) (vals.Map, error) {
 	return statMap(fi), nil
 }
 
-func exists(opts statOpts, path string) bool {
+func exists(fm *eval.Frame, opts statOpts, path string) (bool, error) {
+	if err := checkRead(fm, path); err != nil {
+		return false, err
+	}
 	_, err := statOrLstat(path, opts.FollowSymlink)
-	return err == nil
+	return err == nil, nil
 }
 
 // IsDir is exported so that the implementation may be shared by the path:
 // module.
-func IsDir(opts statOpts, path string) bool {
+func IsDir(fm *eval.Frame, opts statOpts, path string) (bool, error) {
+	if err := checkRead(fm, path); err != nil {
+		return false, err
+	}
 	fi, err := statOrLstat(path, opts.FollowSymlink)
-	return err == nil && fi.Mode().IsDir()
+	return err == nil && fi.Mode().IsDir(), nil
 }
 
 // IsRegular is exported so that the implementation may be shared by the path:
 // module.
-func IsRegular(opts statOpts, path string) bool {
+func IsRegular(fm *eval.Frame, opts statOpts, path string) (bool, error) {
+	if err := checkRead(fm, path); err != nil {
+		return false, err
+	}
 	fi, err := statOrLstat(path, opts.FollowSymlink)
-	return err == nil && fi.Mode().IsRegular()
+	return err == nil && fi.Mode().IsRegular(), nil
 }
 
 func statOrLstat(path string, followSymlink bool) (os.FileInfo, error) {
@@ -153,24 +297,38 @@ func (o *mktempOpt) SetDefaultOptions() {}
 
 // TempDir is exported so that the implementation may be shared by the path:
 // module.
-func TempDir(opts mktempOpt, args ...string) (string, error) {
+func TempDir(fm *eval.Frame, opts mktempOpt, args ...string) (string, error) {
 	pattern, err := optionalTempPattern(args)
 	if err != nil {
 		return "", err
 	}
+	if err := checkWrite(fm, tempDirOf(opts)); err != nil {
+		return "", err
+	}
 	return os.MkdirTemp(opts.Dir, pattern)
 }
 
 // TempFile is exported so that the implementation may be shared by the path:
 // module.
-func TempFile(opts mktempOpt, args ...string) (*os.File, error) {
+func TempFile(fm *eval.Frame, opts mktempOpt, args ...string) (*os.File, error) {
 	pattern, err := optionalTempPattern(args)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkWrite(fm, tempDirOf(opts)); err != nil {
+		return nil, err
+	}
 	return os.CreateTemp(opts.Dir, pattern)
 }
 
+// Returns the directory in which temp-dir and temp-file create files.
+func tempDirOf(opts mktempOpt) string {
+	if opts.Dir == "" {
+		return os.TempDir()
+	}
+	return opts.Dir
+}
+
 func optionalTempPattern(args []string) (string, error) {
 	switch len(args) {
 	case 0:
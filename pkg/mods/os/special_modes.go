@@ -19,6 +19,9 @@ import (
 //
 // TODO: Use a set as the Elvish representation when Elvish has lists.
 
+// Bits of all the special modes supported.
+const specialModeMask = fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+
 func specialModesFromIterable(v any) (fs.FileMode, error) {
 	var mode fs.FileMode
 	var errElem error
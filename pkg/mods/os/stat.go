@@ -18,20 +18,27 @@ var typeNames = map[fs.FileMode]string{
 	fs.ModeIrregular:                  "irregular",
 }
 
-// Implementation of the stat function itself is in os.go.
-
-func statMap(fi fs.FileInfo) vals.Map {
-	mode := fi.Mode()
+// TypeName returns the name of the file type in mode, as used in the type
+// field of stat maps. It is exported so that the implementation may be shared
+// by the archive: module.
+func TypeName(mode fs.FileMode) string {
 	typeName, ok := typeNames[mode.Type()]
 	if !ok {
 		// This shouldn't happen, but if there is a bug this gives us a bit of
 		// information.
 		typeName = fmt.Sprintf("unknown %d", mode.Type())
 	}
+	return typeName
+}
+
+// Implementation of the stat function itself is in os.go.
+
+func statMap(fi fs.FileInfo) vals.Map {
+	mode := fi.Mode()
 	return vals.MakeMap(
 		"name", fi.Name(),
 		"size", vals.Int64ToNum(fi.Size()),
-		"type", typeName,
+		"type", TypeName(mode),
 		"perm", int(mode&fs.ModePerm),
 		"special-modes", specialModesToList(mode),
 		"sys", statSysMap(fi.Sys()))
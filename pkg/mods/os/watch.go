@@ -0,0 +1,53 @@
+package os
+
+import (
+	"time"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/errs"
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/sys/fswatch"
+)
+
+type watchOpts struct {
+	Recursive bool
+	Interval  any
+}
+
+func (*watchOpts) SetDefaultOptions() {}
+
+func watch(fm *eval.Frame, opts watchOpts, paths ...string) error {
+	if len(paths) == 0 {
+		return errs.ArityMismatch{What: "arguments",
+			ValidLow: 1, ValidHigh: -1, Actual: 0}
+	}
+	if err := checkRead(fm, paths...); err != nil {
+		return err
+	}
+	var interval time.Duration
+	if opts.Interval != nil {
+		var err error
+		interval, err = eval.ParsePositiveDuration("&interval", opts.Interval)
+		if err != nil {
+			return err
+		}
+	}
+	out := fm.ValueOutput()
+	var errPut error
+	err := fswatch.Watch(fm.Context(), paths,
+		fswatch.Opts{Recursive: opts.Recursive, Interval: interval},
+		func(e fswatch.Event) bool {
+			errPut = out.Put(vals.MakeMap("path", e.Path, "op", e.Op.String()))
+			return errPut == nil
+		})
+	switch {
+	case err != nil:
+		return err
+	case errPut != nil:
+		return errPut
+	default:
+		// Watch only returns without an error otherwise when the context is
+		// done.
+		return eval.ErrInterrupted
+	}
+}
@@ -0,0 +1,56 @@
+package os_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/mods"
+	"src.elv.sh/pkg/parse"
+	"src.elv.sh/pkg/testutil"
+)
+
+// os:watch only stops when interrupted, which can't be tested in transcripts.
+func TestWatch(t *testing.T) {
+	testutil.InTempDir(t)
+	os.Mkdir("d", 0700)
+
+	ev := eval.NewEvaler()
+	mods.AddTo(ev)
+	port, collect, err := eval.ValueCapturePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ev.Eval(parse.Source{Name: "[test]", Code: `
+			use os
+			os:watch &interval=0.01 d | each {|e| put $e[op] $e[path] }`},
+			eval.EvalCfg{Interrupts: ctx, Ports: []*eval.Port{nil, port}})
+	}()
+
+	time.Sleep(testutil.Scaled(100 * time.Millisecond))
+	os.WriteFile(filepath.Join("d", "f"), nil, 0600)
+	time.Sleep(testutil.Scaled(100 * time.Millisecond))
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if eval.Reason(err) != eval.ErrInterrupted {
+			t.Errorf("got error %v, want ErrInterrupted", err)
+		}
+	case <-time.After(testutil.Scaled(time.Second)):
+		t.Fatalf("os:watch didn't stop after interruption")
+	}
+	values := collect()
+	if want := []any{"create", filepath.Join("d", "f")}; len(values) < 2 ||
+		!reflect.DeepEqual(values[:2], want) {
+		t.Errorf("got values %v, want %v first", values, want)
+	}
+}
@@ -0,0 +1,283 @@
+// Package proc implements the proc: module for running external processes.
+package proc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/errs"
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/parse"
+	"src.elv.sh/pkg/persistent/hash"
+)
+
+// Ns is the namespace for the proc: module.
+var Ns = eval.BuildNsNamed("proc").
+	AddGoFns(map[string]any{
+		"run":   run,
+		"spawn": spawn,
+	}).Ns()
+
+type opts struct {
+	Env     any
+	Dir     string
+	Stdin   any
+	Timeout any
+	Check   bool
+	Capture bool
+}
+
+func (o *opts) SetDefaultOptions() { o.Check, o.Capture = true, true }
+
+// TimeoutError is thrown when a process with a &check option is killed
+// because it has run for longer than its &timeout.
+type TimeoutError struct {
+	CmdName string
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %v", parse.Quote(e.CmdName), e.Timeout)
+}
+
+func run(fm *eval.Frame, opts opts, name string, args ...any) (*Process, error) {
+	p, err := start(fm, fm.Context(), opts, name, args)
+	if err != nil {
+		return nil, err
+	}
+	return p, p.wait(fm)
+}
+
+func spawn(fm *eval.Frame, opts opts, name string, args ...any) (*Process, error) {
+	// A spawned process may outlive the frame, so it is not tied to the
+	// frame's context.
+	return start(fm, context.Background(), opts, name, args)
+}
+
+func start(fm *eval.Frame, ctx context.Context, opts opts, name string, argVals []any) (*Process, error) {
+	if err := fm.Evaler.Sandbox.CheckCommand(name); err != nil {
+		return nil, err
+	}
+	args := make([]string, len(argVals))
+	for i, a := range argVals {
+		args[i] = vals.ToString(a)
+	}
+
+	var timeout time.Duration
+	if opts.Timeout != nil {
+		var err error
+		timeout, err = eval.ParsePositiveDuration("&timeout", opts.Timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	env, err := makeEnv(opts.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = opts.Dir
+	cmd.Env = env
+
+	switch stdin := opts.Stdin.(type) {
+	case nil:
+		if in := fm.InputFile(); in != nil {
+			cmd.Stdin = in
+		}
+	case string:
+		cmd.Stdin = strings.NewReader(stdin)
+	case vals.File:
+		cmd.Stdin = stdin
+	default:
+		cancel()
+		return nil, errs.BadValue{What: "&stdin",
+			Valid: "nil, string or file", Actual: vals.Kind(stdin)}
+	}
+
+	p := &Process{name: name, cmd: cmd,
+		check: opts.Check, timeout: timeout, done: make(chan struct{})}
+	if opts.Capture {
+		p.stdout, p.stderr = &buffer{}, &buffer{}
+		cmd.Stdout, cmd.Stderr = p.stdout, p.stderr
+	} else {
+		cmd.Stdout, cmd.Stderr = fm.Port(1).File, fm.ErrorFile()
+	}
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		p.waitErr = cmd.Wait()
+		p.timedOut = timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded)
+		cancel()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Builds the environment of the process from the &env option, which is a map
+// of environment variables to add to or override in the current environment.
+func makeEnv(v any) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	m, ok := v.(vals.Map)
+	if !ok {
+		return nil, errs.BadValue{What: "&env",
+			Valid: "nil or map", Actual: vals.Kind(v)}
+	}
+	env := os.Environ()
+	for it := m.Iterator(); it.HasElem(); it.Next() {
+		k, v := it.Elem()
+		ks, kok := k.(string)
+		vs, vok := v.(string)
+		if !kok || !vok || ks == "" || strings.ContainsRune(ks, '=') {
+			return nil, errs.BadValue{What: "&env entry",
+				Valid:  "string to string, with keys not containing =",
+				Actual: vals.ReprPlain(k) + " => " + vals.ReprPlain(v)}
+		}
+		env = append(env, ks+"="+vs)
+	}
+	return env, nil
+}
+
+// Process is a process started by proc:run or proc:spawn.
+type Process struct {
+	name    string
+	cmd     *exec.Cmd
+	check   bool
+	timeout time.Duration
+	// Buffers for captured output; nil if output is not captured.
+	stdout, stderr *buffer
+
+	// Closed when the process has finished. The following fields are only
+	// valid afterwards.
+	done     chan struct{}
+	waitErr  error
+	timedOut bool
+}
+
+var _ vals.PseudoMap = &Process{}
+
+func (p *Process) Kind() string     { return "proc" }
+func (p *Process) Equal(a any) bool { return p == a }
+func (p *Process) Hash() uint32     { return hash.Pointer(unsafe.Pointer(p)) }
+
+func (p *Process) Fields() vals.StructMap { return procFields{p} }
+
+// Waits for the process to finish, and returns the error to throw, if any.
+func (p *Process) wait(fm *eval.Frame) error {
+	select {
+	case <-p.done:
+	case <-fm.Context().Done():
+		return eval.ErrInterrupted
+	}
+	if !p.check {
+		return nil
+	}
+	if p.timedOut {
+		return TimeoutError{p.name, p.timeout}
+	}
+	state := p.cmd.ProcessState
+	if state == nil {
+		return p.waitErr
+	}
+	return eval.NewExternalCmdExit(p.name, state.Sys().(syscall.WaitStatus), state.Pid())
+}
+
+// Returns whether the process has finished.
+func (p *Process) finished() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+type procFields struct{ p *Process }
+
+func (procFields) IsStructMap() {}
+
+func (f procFields) Pid() int { return f.p.cmd.Process.Pid }
+
+// Returns the exit status, or $nil if the process is still running. A process
+// killed by a signal has an exit status of -1.
+func (f procFields) ExitStatus() any {
+	if !f.p.finished() || f.p.cmd.ProcessState == nil {
+		return nil
+	}
+	return f.p.cmd.ProcessState.ExitCode()
+}
+
+func (f procFields) TimedOut() bool { return f.p.finished() && f.p.timedOut }
+
+func (f procFields) Stdout() any { return f.p.stdout.value() }
+func (f procFields) Stderr() any { return f.p.stderr.value() }
+
+func (f procFields) Wait() eval.Callable {
+	return eval.NewGoFn("<wait>", f.p.wait)
+}
+
+func (f procFields) Kill() eval.Callable {
+	return eval.NewGoFn("<kill>", func() error {
+		if f.p.finished() {
+			return nil
+		}
+		return f.p.cmd.Process.Kill()
+	})
+}
+
+func (f procFields) Signal() eval.Callable {
+	return eval.NewGoFn("<signal>", func(sig any) error {
+		s, err := parseSignal(sig)
+		if err != nil {
+			return err
+		}
+		if f.p.finished() {
+			return nil
+		}
+		return f.p.cmd.Process.Signal(s)
+	})
+}
+
+// A buffer for capturing output that can be read while being written to.
+type buffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+var _ io.Writer = &buffer{}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+// Returns the content captured so far, or nil if b is nil.
+func (b *buffer) value() any {
+	if b == nil {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package proc
+
+import (
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"src.elv.sh/pkg/eval/errs"
+	"src.elv.sh/pkg/eval/vals"
+)
+
+// Parses a signal, which can be a name with or without the "SIG" prefix (like
+// "SIGTERM" or "TERM"), or a number.
+func parseSignal(v any) (os.Signal, error) {
+	if name, ok := v.(string); ok {
+		if !strings.HasPrefix(name, "SIG") {
+			name = "SIG" + name
+		}
+		if sig := unix.SignalNum(name); sig != 0 {
+			return sig, nil
+		}
+	}
+	var num int
+	if err := vals.ScanToGo(v, &num); err == nil && num > 0 {
+		return syscall.Signal(num), nil
+	}
+	return nil, errs.BadValue{What: "signal",
+		Valid: "signal name or positive number", Actual: vals.ReprPlain(v)}
+}
@@ -0,0 +1,20 @@
+package proc
+
+import (
+	"os"
+
+	"src.elv.sh/pkg/eval/errs"
+	"src.elv.sh/pkg/eval/vals"
+)
+
+// Parses a signal. Only SIGKILL (which can also be written as "KILL" or 9) is
+// supported on Windows.
+func parseSignal(v any) (os.Signal, error) {
+	switch v {
+	case "SIGKILL", "KILL", "9", 9:
+		return os.Kill, nil
+	}
+	return nil, errs.BadValue{What: "signal",
+		Valid:  "SIGKILL (the only signal supported on Windows)",
+		Actual: vals.ReprPlain(v)}
+}
@@ -0,0 +1,129 @@
+package re
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/errs"
+)
+
+type grepOpts struct {
+	Posix      bool
+	Longest    bool
+	Invert     bool
+	Count      bool
+	Context    int
+	Before     int
+	After      int
+	LineNumber bool
+}
+
+// Before and After default to -1, meaning that they are not set and Context
+// is used instead.
+func (o *grepOpts) SetDefaultOptions() {
+	o.Before = -1
+	o.After = -1
+}
+
+// Separator written between non-adjacent groups of lines when there are
+// context lines, like in GNU grep.
+const grepGroupSep = "--\n"
+
+func grep(fm *eval.Frame, opts grepOpts, argPattern any) error {
+	pattern, err := makePattern(argPattern, opts.Posix, opts.Longest)
+	if err != nil {
+		return err
+	}
+	for _, o := range []struct {
+		name  string
+		value int
+		min   int
+	}{{"&context", opts.Context, 0}, {"&before", opts.Before, -1}, {"&after", opts.After, -1}} {
+		if o.value < o.min {
+			return errs.BadValue{What: o.name,
+				Valid: "non-negative integer", Actual: strconv.Itoa(o.value)}
+		}
+	}
+	before, after := opts.Context, opts.Context
+	if opts.Before >= 0 {
+		before = opts.Before
+	}
+	if opts.After >= 0 {
+		after = opts.After
+	}
+
+	out := fm.ByteOutput()
+	write := func(lineno int, sep, line string) error {
+		var sb strings.Builder
+		if opts.LineNumber {
+			sb.WriteString(strconv.Itoa(lineno))
+			sb.WriteString(sep)
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+		_, err := out.WriteString(sb.String())
+		return err
+	}
+
+	var (
+		count int
+		// Line number of the last line written, 0 if none.
+		lastWritten int
+		// Number of context lines still to be written after a match.
+		afterLeft int
+		// The lines before the current line that may be written as context.
+		beforeLines []string
+	)
+	reader := bufio.NewReader(fm.InputFile())
+	for lineno := 1; ; lineno++ {
+		line, err := reader.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		if pattern.MatchString(line) != opts.Invert {
+			count++
+			if opts.Count {
+				continue
+			}
+			first := lineno - len(beforeLines)
+			if (before > 0 || after > 0) && lastWritten > 0 && first > lastWritten+1 {
+				if _, err := out.WriteString(grepGroupSep); err != nil {
+					return err
+				}
+			}
+			for i, l := range beforeLines {
+				if err := write(first+i, "-", l); err != nil {
+					return err
+				}
+			}
+			beforeLines = beforeLines[:0]
+			if err := write(lineno, ":", line); err != nil {
+				return err
+			}
+			lastWritten, afterLeft = lineno, after
+		} else if afterLeft > 0 {
+			if err := write(lineno, "-", line); err != nil {
+				return err
+			}
+			lastWritten = lineno
+			afterLeft--
+		} else if before > 0 {
+			if len(beforeLines) == before {
+				beforeLines = beforeLines[1:]
+			}
+			beforeLines = append(beforeLines, line)
+		}
+	}
+	if opts.Count {
+		return fm.ValueOutput().Put(count)
+	}
+	return nil
+}
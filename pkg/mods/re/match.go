@@ -9,6 +9,8 @@ type matchStruct struct {
 	Start  int
 	End    int
 	Groups vals.List
+	// Submatches of named capture groups, keyed by their names.
+	Named vals.Map
 }
 
 func (matchStruct) IsStructMap() {}
@@ -2,6 +2,7 @@
 package re
 
 import (
+	"errors"
 	"regexp"
 
 	"src.elv.sh/pkg/eval"
@@ -13,18 +14,20 @@ import (
 var Ns = eval.BuildNsNamed("re").
 	AddGoFns(map[string]any{
 		"quote":   regexp.QuoteMeta,
+		"compile": compileFn,
 		"match":   match,
 		"find":    find,
 		"replace": replace,
 		"split":   split,
-		"awk":     eval.Eawk,
+		"grep":    grep,
+		"awk":     awk,
 	}).Ns()
 
 type matchOpts struct{ Posix bool }
 
 func (*matchOpts) SetDefaultOptions() {}
 
-func match(opts matchOpts, argPattern, source string) (bool, error) {
+func match(opts matchOpts, argPattern any, source string) (bool, error) {
 	pattern, err := makePattern(argPattern, opts.Posix, false)
 	if err != nil {
 		return false, err
@@ -41,7 +44,7 @@ type findOpts struct {
 
 func (o *findOpts) SetDefaultOptions() { o.Max = -1 }
 
-func find(fm *eval.Frame, opts findOpts, argPattern, source string) error {
+func find(fm *eval.Frame, opts findOpts, argPattern any, source string) error {
 	out := fm.ValueOutput()
 
 	pattern, err := makePattern(argPattern, opts.Posix, opts.Longest)
@@ -49,10 +52,12 @@ func find(fm *eval.Frame, opts findOpts, argPattern, source string) error {
 		return err
 	}
 	matches := pattern.FindAllSubmatchIndex([]byte(source), opts.Max)
+	names := pattern.SubexpNames()
 
 	for _, match := range matches {
 		start, end := match[0], match[1]
 		groups := vals.EmptyList
+		named := vals.EmptyMap
 		for i := 0; i < len(match); i += 2 {
 			start, end := match[i], match[i+1]
 			text := ""
@@ -61,9 +66,13 @@ func find(fm *eval.Frame, opts findOpts, argPattern, source string) error {
 			if start >= 0 && end >= 0 {
 				text = source[start:end]
 			}
-			groups = groups.Conj(submatchStruct{text, start, end})
+			submatch := submatchStruct{text, start, end}
+			groups = groups.Conj(submatch)
+			if name := names[i/2]; name != "" {
+				named = named.Assoc(name, submatch)
+			}
 		}
-		err := out.Put(matchStruct{source[start:end], start, end, groups})
+		err := out.Put(matchStruct{source[start:end], start, end, groups, named})
 		if err != nil {
 			return err
 		}
@@ -79,7 +88,7 @@ type replaceOpts struct {
 
 func (*replaceOpts) SetDefaultOptions() {}
 
-func replace(fm *eval.Frame, opts replaceOpts, argPattern string, argRepl any, source string) (string, error) {
+func replace(fm *eval.Frame, opts replaceOpts, argPattern, argRepl any, source string) (string, error) {
 
 	pattern, err := makePattern(argPattern, opts.Posix, opts.Longest)
 	if err != nil {
@@ -130,7 +139,7 @@ func replace(fm *eval.Frame, opts replaceOpts, argPattern string, argRepl any, s
 	}
 }
 
-func split(fm *eval.Frame, opts findOpts, argPattern, source string) error {
+func split(fm *eval.Frame, opts findOpts, argPattern any, source string) error {
 	out := fm.ValueOutput()
 
 	pattern, err := makePattern(argPattern, opts.Posix, opts.Longest)
@@ -148,20 +157,60 @@ func split(fm *eval.Frame, opts findOpts, argPattern, source string) error {
 	return nil
 }
 
-func makePattern(p string, posix, longest bool) (*regexp.Regexp, error) {
-	pattern, err := compile(p, posix)
-	if err != nil {
-		return nil, err
+type awkOpts struct {
+	Sep        any
+	SepPosix   bool
+	SepLongest bool
+}
+
+func (o *awkOpts) SetDefaultOptions() { o.Sep = "[ \t]+" }
+
+var errSepOptionsWithCompiled = errors.New(
+	"&sep-posix and &sep-longest can't be used with a compiled pattern; pass &posix and &longest to re:compile instead")
+
+func awk(fm *eval.Frame, opts awkOpts, f eval.Callable, inputs eval.Inputs) error {
+	sep, err := makePattern(opts.Sep, opts.SepPosix, opts.SepLongest)
+	if err == errOptionsWithCompiled {
+		return errSepOptionsWithCompiled
+	} else if err != nil {
+		return err
 	}
-	if longest {
-		pattern.Longest()
+	return eval.EawkWithSep(fm, sep, f, inputs)
+}
+
+var errOptionsWithCompiled = errors.New(
+	"&posix and &longest can't be used with a compiled pattern; pass them to re:compile instead")
+
+// Returns the regexp to use for a pattern argument, which may be a string or a
+// value returned by re:compile.
+func makePattern(p any, posix, longest bool) (*regexp.Regexp, error) {
+	switch p := p.(type) {
+	case string:
+		return compile(p, posix, longest)
+	case *Regexp:
+		if posix || longest {
+			return nil, errOptionsWithCompiled
+		}
+		return p.re, nil
+	default:
+		return nil, errs.BadValue{What: "pattern",
+			Valid: "string or compiled pattern", Actual: vals.Kind(p)}
 	}
-	return pattern, nil
 }
 
-func compile(pattern string, posix bool) (*regexp.Regexp, error) {
+func compile(pattern string, posix, longest bool) (*regexp.Regexp, error) {
+	var re *regexp.Regexp
+	var err error
 	if posix {
-		return regexp.CompilePOSIX(pattern)
+		re, err = regexp.CompilePOSIX(pattern)
+	} else {
+		re, err = regexp.Compile(pattern)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if longest {
+		re.Longest()
 	}
-	return regexp.Compile(pattern)
+	return re, nil
 }
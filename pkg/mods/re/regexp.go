@@ -0,0 +1,61 @@
+package re
+
+import (
+	"regexp"
+
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/persistent/hash"
+)
+
+// Regexp is a compiled pattern, created by re:compile. It can be used in place
+// of a string pattern in all the functions of the re: module.
+type Regexp struct {
+	re      *regexp.Regexp
+	posix   bool
+	longest bool
+}
+
+var _ vals.PseudoMap = &Regexp{}
+
+type compileOpts struct {
+	Posix   bool
+	Longest bool
+}
+
+func (*compileOpts) SetDefaultOptions() {}
+
+func compileFn(opts compileOpts, pattern string) (*Regexp, error) {
+	re, err := compile(pattern, opts.Posix, opts.Longest)
+	if err != nil {
+		return nil, err
+	}
+	return &Regexp{re, opts.Posix, opts.Longest}, nil
+}
+
+func (*Regexp) Kind() string { return "re:regexp" }
+
+// String returns the source of the pattern, so that it can be used where a
+// string pattern is expected.
+func (r *Regexp) String() string { return r.re.String() }
+
+func (r *Regexp) Equal(a any) bool {
+	r2, ok := a.(*Regexp)
+	return ok && r.re.String() == r2.re.String() &&
+		r.posix == r2.posix && r.longest == r2.longest
+}
+
+func (r *Regexp) Hash() uint32 { return hash.String(r.re.String()) }
+
+func (r *Regexp) Fields() vals.StructMap { return regexpFields{r} }
+
+type regexpFields struct{ r *Regexp }
+
+func (regexpFields) IsStructMap() {}
+
+func (f regexpFields) Pattern() string { return f.r.re.String() }
+func (f regexpFields) Posix() bool     { return f.r.posix }
+func (f regexpFields) Longest() bool   { return f.r.longest }
+
+// Names of the capture groups, with an empty string for unnamed groups. The
+// first element corresponds to the entire pattern.
+func (f regexpFields) GroupNames() vals.List { return vals.MakeListSlice(f.r.re.SubexpNames()) }
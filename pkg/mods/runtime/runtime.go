@@ -27,9 +27,27 @@ func Ns(ev *eval.Evaler) *eval.Ns {
 			"lib-dirs":          vars.NewReadOnly(vals.MakeListSlice(ev.LibDirs)),
 			"rc-path":           vars.NewReadOnly(nonEmptyOrNil(ev.RcPath)),
 			"effective-rc-path": vars.NewReadOnly(nonEmptyOrNil(ev.EffectiveRcPath)),
+		}).
+		AddGoFns(map[string]any{
+			"manifest": manifest,
 		}).Ns()
 }
 
+func manifest(fm *eval.Frame) (any, error) {
+	m, err := fm.Manifest()
+	if m == nil || err != nil {
+		return nil, err
+	}
+	packages := vals.EmptyMap
+	for name, dir := range m.Packages {
+		packages = packages.Assoc(name, dir)
+	}
+	return vals.MakeMap(
+		"path", m.Path,
+		"lib-dirs", vals.MakeListSlice(m.LibDirs),
+		"packages", packages), nil
+}
+
 func nonEmptyOrNil(s string) any {
 	if s == "" {
 		return nil
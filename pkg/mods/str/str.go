@@ -13,6 +13,7 @@ import (
 	"src.elv.sh/pkg/eval"
 	"src.elv.sh/pkg/eval/errs"
 	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/wcwidth"
 )
 
 var Ns = eval.BuildNsNamed("str").
@@ -33,9 +34,15 @@ var Ns = eval.BuildNsNamed("str").
 		// TODO: IndexFunc
 		"join":       join,
 		"last-index": strings.LastIndex,
+
+		"pad-left":  padLeft,
+		"pad-right": padRight,
+		"center":    center,
 		// TODO: LastIndexFunc, Map, Repeat
 		"replace": replace,
 		"split":   split,
+
+		"template": template,
 		// TODO: SplitAfter
 		//lint:ignore SA1019 Elvish builtins need to be formally deprecated
 		// before removal
@@ -127,6 +134,64 @@ func join(sep string, inputs eval.Inputs) (string, error) {
 	return buf.String(), errJoin
 }
 
+type padOpts struct{ Fill string }
+
+func (o *padOpts) SetDefaultOptions() { o.Fill = " " }
+
+func padLeft(opts padOpts, s string, width int) (string, error) {
+	return pad(opts.Fill, s, width, alignRight)
+}
+
+func padRight(opts padOpts, s string, width int) (string, error) {
+	return pad(opts.Fill, s, width, alignLeft)
+}
+
+func center(opts padOpts, s string, width int) (string, error) {
+	return pad(opts.Fill, s, width, alignCenter)
+}
+
+func pad(fill, s string, width int, a align) (string, error) {
+	r, err := fillRune(fill)
+	if err != nil {
+		return "", err
+	}
+	return padRunes(r, s, width, a), nil
+}
+
+func fillRune(fill string) (rune, error) {
+	r, size := utf8.DecodeRuneInString(fill)
+	if size == 0 || size != len(fill) || wcwidth.OfRune(r) != 1 {
+		return 0, errs.BadValue{What: "&fill",
+			Valid: "a single character of width 1", Actual: vals.ReprPlain(fill)}
+	}
+	return r, nil
+}
+
+type align int
+
+const (
+	alignLeft align = iota
+	alignRight
+	alignCenter
+)
+
+// Pads s with r so that it is at least width columns wide. When centering,
+// any odd column of padding goes to the right.
+func padRunes(r rune, s string, width int, a align) string {
+	n := width - wcwidth.Of(s)
+	if n <= 0 {
+		return s
+	}
+	switch a {
+	case alignLeft:
+		return s + strings.Repeat(string(r), n)
+	case alignRight:
+		return strings.Repeat(string(r), n) + s
+	default:
+		return strings.Repeat(string(r), n/2) + s + strings.Repeat(string(r), n-n/2)
+	}
+}
+
 type maxOpt struct{ Max int }
 
 func (o *maxOpt) SetDefaultOptions() { o.Max = -1 }
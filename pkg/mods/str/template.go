@@ -0,0 +1,388 @@
+package str
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/wcwidth"
+)
+
+// TemplateError is thrown by str:template when the template is malformed, or
+// a field can't be found or formatted.
+type TemplateError struct {
+	// Byte offset of the offending tag in the template.
+	Pos int
+	Msg string
+}
+
+func (e TemplateError) Error() string {
+	return fmt.Sprintf("template error at byte %d: %s", e.Pos, e.Msg)
+}
+
+func template(tmpl string, values any) (string, error) {
+	nodes, err := parseTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+	r := &tmplRenderer{scopes: []any{values}}
+	if err := r.render(nodes); err != nil {
+		return "", err
+	}
+	return r.sb.String(), nil
+}
+
+type tmplNode interface{}
+
+type tmplText string
+
+type tmplField struct {
+	pos  int
+	path string
+	spec fieldSpec
+}
+
+type tmplEach struct {
+	pos  int
+	path string
+	body []tmplNode
+}
+
+type tmplIf struct {
+	pos       int
+	path      string
+	then, els []tmplNode
+}
+
+// Format spec of a field, in the form of [[fill]align][width][.precision][verb].
+type fieldSpec struct {
+	fill     rune
+	align    align
+	hasAlign bool
+	// Whether the width starts with 0, which pads numbers with zeros.
+	zero  bool
+	width int
+	// -1 if not specified.
+	prec int
+	// 0 if not specified.
+	verb byte
+}
+
+type tmplParser struct {
+	src string
+	pos int
+	// Position of the last tag returned by parseNodes.
+	tagPos int
+}
+
+func parseTemplate(src string) ([]tmplNode, error) {
+	p := &tmplParser{src: src}
+	nodes, tag, err := p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+	if tag != "" {
+		return nil, p.errorf(p.tagPos, "unexpected {%s}", tag)
+	}
+	return nodes, nil
+}
+
+// Parses nodes until the end of the template, or a {#else}, {/each} or {/if}
+// tag, which is returned.
+func (p *tmplParser) parseNodes() ([]tmplNode, string, error) {
+	var nodes []tmplNode
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, tmplText(text.String()))
+			text.Reset()
+		}
+	}
+	for p.pos < len(p.src) {
+		rest := p.src[p.pos:]
+		switch {
+		case strings.HasPrefix(rest, "{{"):
+			text.WriteByte('{')
+			p.pos += 2
+		case strings.HasPrefix(rest, "}}"):
+			text.WriteByte('}')
+			p.pos += 2
+		case rest[0] == '}':
+			return nil, "", p.errorf(p.pos, "unmatched }; use }} for a literal }")
+		case rest[0] == '{':
+			end := strings.IndexByte(rest, '}')
+			if end == -1 {
+				return nil, "", p.errorf(p.pos, "unclosed {; use {{ for a literal {")
+			}
+			pos, tag := p.pos, strings.TrimSpace(rest[1:end])
+			p.pos += end + 1
+			flush()
+			switch {
+			case tag == "#else" || tag == "/each" || tag == "/if":
+				p.tagPos = pos
+				return nodes, tag, nil
+			case strings.HasPrefix(tag, "#each "):
+				body, closing, err := p.parseNodes()
+				if err != nil {
+					return nil, "", err
+				}
+				if closing != "/each" {
+					return nil, "", p.unclosed(pos, "#each", "/each", closing)
+				}
+				path := strings.TrimSpace(tag[len("#each "):])
+				nodes = append(nodes, tmplEach{pos, path, body})
+			case strings.HasPrefix(tag, "#if "):
+				then, closing, err := p.parseNodes()
+				if err != nil {
+					return nil, "", err
+				}
+				var els []tmplNode
+				if closing == "#else" {
+					els, closing, err = p.parseNodes()
+					if err != nil {
+						return nil, "", err
+					}
+				}
+				if closing != "/if" {
+					return nil, "", p.unclosed(pos, "#if", "/if", closing)
+				}
+				path := strings.TrimSpace(tag[len("#if "):])
+				nodes = append(nodes, tmplIf{pos, path, then, els})
+			case strings.HasPrefix(tag, "#") || strings.HasPrefix(tag, "/"):
+				return nil, "", p.errorf(pos, "unknown tag {%s}", tag)
+			default:
+				path, specText, _ := strings.Cut(tag, ":")
+				path = strings.TrimSpace(path)
+				if path == "" {
+					return nil, "", p.errorf(pos, "empty field name")
+				}
+				spec, ok := parseSpec(specText)
+				if !ok {
+					return nil, "", p.errorf(pos, "bad format spec %q", specText)
+				}
+				nodes = append(nodes, tmplField{pos, path, spec})
+			}
+		default:
+			i := strings.IndexAny(rest, "{}")
+			if i == -1 {
+				i = len(rest)
+			}
+			text.WriteString(rest[:i])
+			p.pos += i
+		}
+	}
+	flush()
+	return nodes, "", nil
+}
+
+func (p *tmplParser) unclosed(pos int, open, close, actual string) error {
+	if actual == "" {
+		return p.errorf(pos, "{%s} without {%s}", open, close)
+	}
+	return p.errorf(p.tagPos, "unexpected {%s}", actual)
+}
+
+func (p *tmplParser) errorf(pos int, format string, args ...any) error {
+	return TemplateError{pos, fmt.Sprintf(format, args...)}
+}
+
+func parseSpec(s string) (fieldSpec, bool) {
+	spec := fieldSpec{fill: ' ', prec: -1}
+	if r, size := utf8.DecodeRuneInString(s); size > 0 && size < len(s) && isAlign(s[size]) {
+		spec.fill, spec.align, spec.hasAlign = r, toAlign(s[size]), true
+		s = s[size+1:]
+	} else if s != "" && isAlign(s[0]) {
+		spec.align, spec.hasAlign = toAlign(s[0]), true
+		s = s[1:]
+	}
+	digits := func() (int, bool) {
+		i := 0
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(s[:i])
+		s = s[i:]
+		return n, err == nil
+	}
+	if s != "" && '0' <= s[0] && s[0] <= '9' {
+		spec.zero = s[0] == '0' && !spec.hasAlign
+		spec.width, _ = digits()
+	}
+	if strings.HasPrefix(s, ".") {
+		s = s[1:]
+		var ok bool
+		spec.prec, ok = digits()
+		if !ok {
+			return spec, false
+		}
+	}
+	if len(s) == 1 && strings.IndexByte("sqdboxXeEfFgG", s[0]) != -1 {
+		spec.verb = s[0]
+	} else if s != "" {
+		return spec, false
+	}
+	return spec, true
+}
+
+func isAlign(b byte) bool { return b == '<' || b == '>' || b == '^' }
+
+func toAlign(b byte) align {
+	switch b {
+	case '<':
+		return alignLeft
+	case '>':
+		return alignRight
+	default:
+		return alignCenter
+	}
+}
+
+type tmplRenderer struct {
+	sb strings.Builder
+	// Values that fields are looked up in, innermost last. The first one is
+	// the argument to str:template, and each {#each} pushes its element.
+	scopes []any
+	// Indices of the enclosing {#each} tags, innermost last.
+	indices []int
+}
+
+func (r *tmplRenderer) render(nodes []tmplNode) error {
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case tmplText:
+			r.sb.WriteString(string(node))
+		case tmplField:
+			v, err := r.lookup(node.path)
+			if err != nil {
+				return TemplateError{node.pos, err.Error()}
+			}
+			s, err := node.spec.format(v)
+			if err != nil {
+				return TemplateError{node.pos, err.Error()}
+			}
+			r.sb.WriteString(s)
+		case tmplEach:
+			v, err := r.lookup(node.path)
+			if err != nil {
+				return TemplateError{node.pos, err.Error()}
+			}
+			var errBody error
+			i := 0
+			errIterate := vals.Iterate(v, func(elem any) bool {
+				r.scopes = append(r.scopes, elem)
+				r.indices = append(r.indices, i)
+				errBody = r.render(node.body)
+				r.scopes = r.scopes[:len(r.scopes)-1]
+				r.indices = r.indices[:len(r.indices)-1]
+				i++
+				return errBody == nil
+			})
+			if errIterate != nil {
+				return TemplateError{node.pos, errIterate.Error()}
+			}
+			if errBody != nil {
+				return errBody
+			}
+		case tmplIf:
+			// Fields that can't be found are treated as false.
+			v, err := r.lookup(node.path)
+			body := node.els
+			if err == nil && vals.Bool(v) {
+				body = node.then
+			}
+			if err := r.render(body); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Looks up a field, which is either ".", "@index", or a sequence of keys
+// separated by ".". If the path starts with ".", the keys are looked up in the
+// current element of the innermost {#each}; otherwise the first key is looked
+// up from the innermost scope outwards.
+func (r *tmplRenderer) lookup(path string) (any, error) {
+	switch path {
+	case ".":
+		return r.scopes[len(r.scopes)-1], nil
+	case "@index":
+		if len(r.indices) == 0 {
+			return nil, fmt.Errorf("@index used outside {#each}")
+		}
+		return r.indices[len(r.indices)-1], nil
+	}
+	var v any
+	var keys []string
+	if strings.HasPrefix(path, ".") {
+		v, keys = r.scopes[len(r.scopes)-1], strings.Split(path[1:], ".")
+	} else {
+		keys = strings.Split(path, ".")
+		found := false
+		for i := len(r.scopes) - 1; i >= 0; i-- {
+			if vals.HasKey(r.scopes[i], keys[0]) {
+				v, found = r.scopes[i], true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no such key: %s", keys[0])
+		}
+	}
+	for _, key := range keys {
+		var err error
+		v, err = vals.Index(v, key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return v, nil
+}
+
+func (spec fieldSpec) format(v any) (string, error) {
+	var s string
+	numeric := false
+	switch spec.verb {
+	case 0, 's':
+		s = vals.ToString(v)
+		if spec.prec >= 0 {
+			s = wcwidth.Trim(s, spec.prec)
+		}
+	case 'q':
+		s = vals.ReprPlain(v)
+	case 'd', 'b', 'o', 'x', 'X':
+		var i int
+		if err := vals.ScanToGo(v, &i); err != nil {
+			return "", err
+		}
+		s = fmt.Sprintf(spec.numPrefix()+string(spec.verb), i)
+		numeric = true
+	default:
+		var f float64
+		if err := vals.ScanToGo(v, &f); err != nil {
+			return "", err
+		}
+		prefix := spec.numPrefix()
+		if spec.prec >= 0 {
+			prefix += "." + strconv.Itoa(spec.prec)
+		}
+		s = fmt.Sprintf(prefix+string(spec.verb), f)
+		numeric = true
+	}
+	a := spec.align
+	if !spec.hasAlign && numeric {
+		a = alignRight
+	}
+	return padRunes(spec.fill, s, spec.width, a), nil
+}
+
+// Returns the start of the fmt verb for formatting a number, which includes the
+// width when zero-padding, so that the zeros go after any sign.
+func (spec fieldSpec) numPrefix() string {
+	if spec.zero {
+		return "%0" + strconv.Itoa(spec.width)
+	}
+	return "%"
+}
@@ -0,0 +1,103 @@
+// Package test implements the test: module for writing unit tests in Elvish.
+package test
+
+import (
+	"fmt"
+	"strings"
+
+	"src.elv.sh/pkg/diff"
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/errs"
+	"src.elv.sh/pkg/eval/vals"
+)
+
+// Ns is the namespace for the test: module.
+var Ns = eval.BuildNsNamed("test").
+	AddGoFns(map[string]any{
+		"assert":        assert,
+		"assert-eq":     assertEq,
+		"assert-throws": assertThrows,
+		"skip":          skip,
+	}).Ns()
+
+// AssertionError is thrown when an assertion fails.
+type AssertionError struct {
+	Msg string
+	// A diff between the representations of the expected and actual values;
+	// only set by test:assert-eq.
+	Diff string
+}
+
+func (e AssertionError) Error() string {
+	if e.Diff == "" {
+		return "assertion failed: " + e.Msg
+	}
+	return fmt.Sprintf("assertion failed: %s\n-expected +actual:\n%s",
+		e.Msg, strings.TrimSuffix(e.Diff, "\n"))
+}
+
+// SkipError is thrown by test:skip. Test runners should treat a test that
+// throws it as skipped rather than failed.
+type SkipError struct {
+	Reason string
+}
+
+func (e SkipError) Error() string {
+	if e.Reason == "" {
+		return "test skipped"
+	}
+	return "test skipped: " + e.Reason
+}
+
+type msgOpts struct{ Msg string }
+
+func (*msgOpts) SetDefaultOptions() {}
+
+// Returns the message from the &msg option, or the default message.
+func (o msgOpts) or(def string) string {
+	if o.Msg != "" {
+		return o.Msg
+	}
+	return def
+}
+
+func assert(opts msgOpts, v any) error {
+	if vals.Bool(v) {
+		return nil
+	}
+	return AssertionError{Msg: opts.or(vals.ReprPlain(v) + " is not truthy")}
+}
+
+func assertEq(opts msgOpts, actual, expected any) error {
+	if vals.Equal(actual, expected) {
+		return nil
+	}
+	d := diff.DiffNoHeader(vals.Repr(expected, 0)+"\n", vals.Repr(actual, 0)+"\n")
+	return AssertionError{Msg: opts.or("values are not equal"), Diff: string(d)}
+}
+
+func assertThrows(fm *eval.Frame, opts msgOpts, f eval.Callable) (eval.Exception, error) {
+	err := f.Call(fm.Fork("test:assert-throws"), eval.NoArgs, eval.NoOpts)
+	if err == nil {
+		return nil, AssertionError{Msg: opts.or("no exception was thrown")}
+	}
+	if _, ok := eval.Reason(err).(SkipError); ok {
+		return nil, err
+	}
+	if exc, ok := err.(eval.Exception); ok {
+		return exc, nil
+	}
+	return eval.NewException(err, nil), nil
+}
+
+func skip(reasons ...string) error {
+	switch len(reasons) {
+	case 0:
+		return SkipError{}
+	case 1:
+		return SkipError{reasons[0]}
+	default:
+		return errs.ArityMismatch{What: "arguments",
+			ValidLow: 0, ValidHigh: 1, Actual: len(reasons)}
+	}
+}
@@ -3,6 +3,7 @@ package cmpd
 
 import (
 	"fmt"
+	"strings"
 
 	"src.elv.sh/pkg/parse"
 )
@@ -64,3 +65,43 @@ func Shape(n *parse.Compound) string {
 	pn := in.Head
 	return "primary expression of type " + pn.Type.String()
 }
+
+// NameList returns the variable names denoted by a list literal of names,
+// such as "[f $x ns:]", if that's the only child of the compound node:
+//
+//   - A bareword like f denotes the function f, named "f~".
+//   - A variable like $x denotes the variable x, named "x".
+//   - A bareword ending in ":" like ns: denotes the namespace ns, named "ns:".
+//
+// Otherwise it returns an error suitable as a compiler error.
+func NameList(n *parse.Compound, what string) ([]string, error) {
+	pn, ok := Primary(n)
+	if !ok || pn.Type != parse.List {
+		return nil, fmt.Errorf("%s must be list literal, found %s", what, Shape(n))
+	}
+	names := make([]string, 0, len(pn.Elements))
+	for _, elem := range pn.Elements {
+		name, ok := nameInList(elem)
+		if !ok {
+			return nil, fmt.Errorf("%s must contain barewords or variables, found %s", what, Shape(elem))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func nameInList(n *parse.Compound) (string, bool) {
+	pn, ok := Primary(n)
+	if !ok {
+		return "", false
+	}
+	switch {
+	case pn.Type == parse.Bareword && strings.HasSuffix(pn.Value, ":"):
+		return pn.Value, true
+	case pn.Type == parse.Bareword:
+		return strings.TrimSuffix(pn.Value, "~") + "~", true
+	case pn.Type == parse.Variable && pn.Value != "" && pn.Value[0] != '@':
+		return pn.Value, true
+	}
+	return "", false
+}
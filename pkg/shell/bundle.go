@@ -0,0 +1,303 @@
+package shell
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"src.elv.sh/pkg/diag"
+	"src.elv.sh/pkg/env"
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/mods"
+	"src.elv.sh/pkg/parse"
+	"src.elv.sh/pkg/parse/cmpd"
+	"src.elv.sh/pkg/prog"
+	"src.elv.sh/pkg/ui"
+)
+
+// Support for bundling a script and the modules it uses into a copy of the
+// Elvish executable.
+//
+// A bundled executable consists of the original executable, followed by a zip
+// archive, followed by a trailer. The zip archive contains the source files,
+// and a bundle.json file containing the entry script and how module specs are
+// resolved (see [eval.Bundle]). The trailer consists of bundleMagic and the
+// size of the zip archive as a little-endian uint64.
+
+const (
+	bundleMagic       = "\x00elvbndl"
+	bundleTrailerSize = len(bundleMagic) + 8
+	bundleJSON        = "bundle.json"
+)
+
+func makeBundle(ev *eval.Evaler, entry, out string) error {
+	if out == "" {
+		return prog.BadUsage("-o must be specified with -bundle")
+	}
+	b, files, err := resolveBundle(ev, entry)
+	if err != nil {
+		return err
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exe, err := os.ReadFile(exePath)
+	if err != nil {
+		return err
+	}
+	if len(exe) >= bundleTrailerSize {
+		_, _, ok := parseBundleTrailer(exe[len(exe)-bundleTrailerSize:], int64(len(exe)))
+		if ok {
+			return fmt.Errorf("%s is already a bundled executable", exePath)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(exe)
+	zw := zip.NewWriter(&buf)
+	zw.SetOffset(int64(len(exe)))
+	writeFile := func(name string, content []byte) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	}
+	for _, name := range sortedKeys(files) {
+		if err := writeFile(name, []byte(files[name])); err != nil {
+			return err
+		}
+	}
+	bundleJSONContent, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(bundleJSON, bundleJSONContent); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	buf.WriteString(bundleMagic)
+	binary.Write(&buf, binary.LittleEndian, uint64(buf.Len()-len(bundleMagic)-len(exe)))
+	return os.WriteFile(out, buf.Bytes(), 0o755)
+}
+
+// Finds the entry script and all the modules it uses transitively, resolving
+// module specs in the same way as use. It returns the bundle without FS, and
+// the content of the source files, indexed by their paths in the bundle.
+//
+// Paths in the bundle are relative to the deepest directory containing all the
+// source files.
+func resolveBundle(ev *eval.Evaler, entry string) (*eval.Bundle, map[string]string, error) {
+	entry, err := filepath.Abs(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	sources := make(map[string]string)
+	uses := make(map[string]map[string]string)
+	queue := []string{entry}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		if _, seen := sources[path]; seen {
+			continue
+		}
+		code, err := readFileUTF8(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources[path] = code
+		tree, err := parse.Parse(parse.Source{Name: path, Code: code, IsFile: true}, parse.Config{})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, spec := range useSpecs(tree.Root) {
+			if ev.IsPredefinedModule(spec) {
+				continue
+			}
+			file, err := eval.FindModuleFile(spec, filepath.Dir(path), ev.LibDirs)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if uses[path] == nil {
+				uses[path] = make(map[string]string)
+			}
+			uses[path][spec] = file
+			queue = append(queue, file)
+		}
+	}
+
+	root := filepath.Dir(entry)
+	for path := range sources {
+		for !strings.HasPrefix(path, root+string(filepath.Separator)) && filepath.Dir(root) != root {
+			root = filepath.Dir(root)
+		}
+	}
+	rel := func(path string) string {
+		r, _ := filepath.Rel(root, path)
+		return filepath.ToSlash(r)
+	}
+
+	b := &eval.Bundle{Main: rel(entry), Uses: make(map[string]map[string]string)}
+	for path, fileUses := range uses {
+		relUses := make(map[string]string)
+		for spec, file := range fileUses {
+			relUses[spec] = rel(file)
+		}
+		b.Uses[rel(path)] = relUses
+	}
+	files := make(map[string]string)
+	for path, code := range sources {
+		files[rel(path)] = code
+	}
+	return b, files, nil
+}
+
+// Returns the specs of all the use forms in the tree, in order.
+func useSpecs(n parse.Node) []string {
+	var specs []string
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		if form, ok := n.(*parse.Form); ok && form.Head != nil && len(form.Args) > 0 {
+			if head, _ := cmpd.StringLiteral(form.Head); head == "use" {
+				if spec, ok := cmpd.StringLiteral(form.Args[0]); ok {
+					specs = append(specs, spec)
+				}
+			}
+		}
+		for _, child := range parse.Children(n) {
+			walk(child)
+		}
+	}
+	walk(n)
+	return specs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Parses the trailer of a file with the given size, returning the offset and
+// size of the zip archive in it, and whether the file is a bundled executable.
+func parseBundleTrailer(trailer []byte, fileSize int64) (offset, size int64, ok bool) {
+	if len(trailer) != bundleTrailerSize || string(trailer[:len(bundleMagic)]) != bundleMagic {
+		return 0, 0, false
+	}
+	size = int64(binary.LittleEndian.Uint64(trailer[len(bundleMagic):]))
+	offset = fileSize - int64(bundleTrailerSize) - size
+	if offset < 0 {
+		return 0, 0, false
+	}
+	return offset, size, true
+}
+
+// RunBundle runs the program bundled into the current executable with
+// "elvish -bundle", passing all of args except the first as $args without
+// parsing any flags. It returns the exit status and true if the executable
+// has a bundle, and 0 and false otherwise.
+//
+// Main programs of Elvish should call this before [prog.Run].
+func RunBundle(fds [3]*os.File, args []string) (int, bool) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return 0, false
+	}
+	b, closer, err := openBundle(exePath)
+	if b == nil && err == nil {
+		return 0, false
+	}
+	if err != nil {
+		fmt.Fprintln(fds[2], "cannot open bundle:", err)
+		return 2, true
+	}
+	defer closer.Close()
+	return runBundle(fds, b, args), true
+}
+
+// Opens the bundle in an executable. It returns nil and no error if the
+// executable doesn't have a bundle.
+func openBundle(exePath string) (*eval.Bundle, io.Closer, error) {
+	f, err := os.Open(exePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.Size() < int64(bundleTrailerSize) {
+		f.Close()
+		return nil, nil, nil
+	}
+	trailer := make([]byte, bundleTrailerSize)
+	if _, err := f.ReadAt(trailer, info.Size()-int64(bundleTrailerSize)); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	offset, size, ok := parseBundleTrailer(trailer, info.Size())
+	if !ok {
+		f.Close()
+		return nil, nil, nil
+	}
+	zr, err := zip.NewReader(io.NewSectionReader(f, offset, size), size)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	content, err := fs.ReadFile(zr, bundleJSON)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	b := &eval.Bundle{FS: zr}
+	if err := json.Unmarshal(content, b); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return b, f, nil
+}
+
+func runBundle(fds [3]*os.File, b *eval.Bundle, args []string) int {
+	cleanup1 := incSHLVL()
+	defer cleanup1()
+	cleanup2 := initSignal(fds)
+	defer cleanup2()
+	ui.NoColor = os.Getenv(env.NO_COLOR) != ""
+
+	ev := eval.NewEvaler()
+	mods.AddTo(ev)
+	ev.Bundle = b
+	ev.Args = vals.MakeListSlice(args[1:])
+	defer ev.PreExit()
+
+	code, err := fs.ReadFile(b.FS, b.Main)
+	if err != nil {
+		fmt.Fprintln(fds[2], "cannot read entry script of bundle:", err)
+		return 2
+	}
+	src := parse.Source{Name: b.Main, Code: string(code), IsFile: true}
+	if err := evalInTTY(fds, ev, nil, src); err != nil {
+		diag.ShowError(fds[2], err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"src.elv.sh/pkg/env"
+	"src.elv.sh/pkg/must"
+	. "src.elv.sh/pkg/prog/progtest"
+	"src.elv.sh/pkg/testutil"
+)
+
+func TestBundle(t *testing.T) {
+	setupCleanHomePaths(t)
+	dir := testutil.InTempDir(t)
+	testutil.Setenv(t, env.XDG_DATA_HOME, filepath.Join(dir, "data"))
+	testutil.ApplyDir(testutil.Dir{
+		"proj": testutil.Dir{
+			"main.elv": testutil.Dedent(`
+				use str
+				use ./lib/greet
+				use util
+				echo (str:to-upper (greet:greet $args[0])) (util:bang)
+				`),
+			"lib": testutil.Dir{
+				"greet.elv": "use ../fmt\nfn greet {|name| fmt:fmt $name }\n",
+			},
+			"fmt.elv": "fn fmt {|name| put 'hello '$name }\n",
+			"bad.elv": "use ./non-existent\n",
+		},
+		"data": testutil.Dir{
+			"elvish": testutil.Dir{
+				"lib": testutil.Dir{
+					"util.elv": "fn bang { put '!' }\n",
+				},
+			},
+		},
+	})
+
+	Test(t, &Program{},
+		ThatElvish("-bundle", "proj/main.elv", "-o", "tool").DoesNothing(),
+		ThatElvish("-bundle", "proj/main.elv").
+			ExitsWith(2).
+			WritesStderrContaining("-o must be specified with -bundle"),
+		ThatElvish("-bundle", "proj/main.elv", "-o", "tool2", "foo").
+			ExitsWith(2).
+			WritesStderrContaining("-bundle doesn't take arguments"),
+		ThatElvish("-bundle", "proj/bad.elv", "-o", "tool2").
+			ExitsWith(2).
+			WritesStderrContaining("no such module: ./non-existent"),
+		ThatElvish("-bundle", "proj/non-existent.elv", "-o", "tool2").
+			ExitsWith(2).
+			WritesStderrContaining("non-existent.elv"),
+		ThatElvish("-bundle", "proj/main.elv", "-gendoc", "proj", "-o", "tool2").
+			ExitsWith(2).
+			WritesStderrContaining("-gendoc and -bundle can't be used together"),
+	)
+
+	b, closer, err := openBundle("tool")
+	if err != nil {
+		t.Fatalf("openBundle: %v", err)
+	}
+	defer closer.Close()
+	if b.Main != "proj/main.elv" {
+		t.Errorf("got Main %q, want %q", b.Main, "proj/main.elv")
+	}
+	wantUses := map[string]map[string]string{
+		"proj/main.elv":      {"./lib/greet": "proj/lib/greet.elv", "util": "data/elvish/lib/util.elv"},
+		"proj/lib/greet.elv": {"../fmt": "proj/fmt.elv"},
+	}
+	if !reflect.DeepEqual(b.Uses, wantUses) {
+		t.Errorf("got Uses %v, want %v", b.Uses, wantUses)
+	}
+
+	// Remove the source files to make sure that the bundle doesn't depend on
+	// them.
+	must.OK(os.RemoveAll("proj"))
+	must.OK(os.RemoveAll("data"))
+
+	stdout, readStdout := capturedFile(t)
+	exit := runBundle([3]*os.File{os.Stdin, stdout, os.Stderr}, b, []string{"tool", "world"})
+	if exit != 0 {
+		t.Errorf("got exit %v, want 0", exit)
+	}
+	if got, want := readStdout(), "HELLO WORLD !\n"; got != want {
+		t.Errorf("got output %q, want %q", got, want)
+	}
+
+	// An executable without a bundle
+	must.WriteFile("not-bundled", "foo")
+	b, _, err = openBundle("not-bundled")
+	if b != nil || err != nil {
+		t.Errorf("openBundle on file without bundle returned %v, %v", b, err)
+	}
+}
+
+func capturedFile(t *testing.T) (*os.File, func() string) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f, func() string { return must.ReadFileString(f.Name()) }
+}
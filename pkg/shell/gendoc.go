@@ -0,0 +1,422 @@
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"src.elv.sh/pkg/elvdoc"
+	"src.elv.sh/pkg/md"
+	"src.elv.sh/pkg/prog"
+	"src.elv.sh/pkg/ui"
+)
+
+// Support for generating a static HTML site from the elvdocs of the modules in
+// a directory.
+//
+// The generated site has the following files:
+//
+//   - index.html: a list of all the modules.
+//   - mod/$spec.html for each module: the documentation of the module. These
+//     are put in a subdirectory so that they can't clash with the other
+//     files, for example when there is a module called "index".
+//   - search-index.js: the search index, loaded by search.js.
+//   - search.js: the client-side search, used on every page.
+//   - style.css: the stylesheet, used on every page.
+
+// A module to generate documentation for.
+type gendocModule struct {
+	// Module spec, like "a/b".
+	spec string
+	docs elvdoc.Docs
+}
+
+// An entry in the search index.
+type searchEntry struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Summary string `json:"summary"`
+}
+
+func genDoc(dir, outDir string) error {
+	if outDir == "" {
+		return prog.BadUsage("-o must be specified with -gendoc")
+	}
+	modules, err := gendocModules(dir)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("no modules found in %s", dir)
+	}
+
+	// URLs of all documented symbols, relative to the root of the site.
+	symbolURLs := make(map[string]string)
+	var searchIndex []searchEntry
+	for _, m := range modules {
+		forEachEntry(m, func(symbol, anchor string, entry elvdoc.Entry) {
+			url := modulePage(m.spec) + "#" + anchor
+			for _, s := range strings.Fields(symbol) {
+				symbolURLs[s] = url
+			}
+			searchIndex = append(searchIndex,
+				searchEntry{symbol, url, summary(entry.Content)})
+		})
+	}
+
+	write := func(name, content string) error {
+		path := filepath.Join(outDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(content), 0o644)
+	}
+
+	for _, m := range modules {
+		path := modulePage(m.spec)
+		page, err := gendocPage(path, m.spec, modulePageContent(m, symbolURLs))
+		if err != nil {
+			return err
+		}
+		if err := write(path, page); err != nil {
+			return err
+		}
+	}
+	index, err := gendocPage("index.html", "Modules", indexPageContent(modules))
+	if err != nil {
+		return err
+	}
+	searchIndexJSON, err := json.Marshal(searchIndex)
+	if err != nil {
+		return err
+	}
+	for _, file := range []struct{ name, content string }{
+		{"index.html", index},
+		{"search-index.js", "var searchIndex = " + string(searchIndexJSON) + ";\n"},
+		{"search.js", gendocSearchJS},
+		{"style.css", gendocCSS},
+	} {
+		if err := write(file.name, file.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finds all the modules in dir, sorted by their specs. Hidden files and
+// directories, and unit test files are skipped.
+func gendocModules(dir string) ([]gendocModule, error) {
+	var modules []gendocModule
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || filepath.Ext(path) != ".elv" || strings.HasSuffix(path, "_test.elv") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		spec := filepath.ToSlash(strings.TrimSuffix(rel, ".elv"))
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		docs, err := elvdoc.Extract(file, spec+":")
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		modules = append(modules, gendocModule{spec, docs})
+		return nil
+	})
+	sort.Slice(modules, func(i, j int) bool { return modules[i].spec < modules[j].spec })
+	return modules, err
+}
+
+// Calls f with the symbol and anchor of each entry in the module, variables
+// first.
+func forEachEntry(m gendocModule, f func(symbol, anchor string, entry elvdoc.Entry)) {
+	call := func(prefix string, entry elvdoc.Entry) {
+		symbol := prefix + m.spec + ":" + entry.Name
+		anchor := entry.ID
+		if anchor == "" {
+			anchor = strings.ReplaceAll(symbol, " ", "-")
+		}
+		f(symbol, anchor, entry)
+	}
+	for _, entry := range sortedEntries(m.docs.Vars) {
+		call("$", entry)
+	}
+	for _, entry := range sortedEntries(m.docs.Fns) {
+		call("", entry)
+	}
+}
+
+func sortedEntries(entries []elvdoc.Entry) []elvdoc.Entry {
+	sorted := append([]elvdoc.Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// Returns the first paragraph of a piece of Markdown as plain text.
+func summary(markdown string) string {
+	var codec md.TextCodec
+	md.Render(markdown, &codec)
+	for _, block := range codec.Blocks() {
+		if !block.Code {
+			return block.Text
+		}
+	}
+	return ""
+}
+
+func indexPageContent(modules []gendocModule) string {
+	var sb strings.Builder
+	sb.WriteString("<ul class=\"modules\">\n")
+	for _, m := range modules {
+		fmt.Fprintf(&sb, "<li><a href=\"%s\"><code>%s</code></a></li>\n",
+			html.EscapeString(modulePage(m.spec)), html.EscapeString(m.spec))
+	}
+	sb.WriteString("</ul>\n")
+	return sb.String()
+}
+
+func modulePageContent(m gendocModule, symbolURLs map[string]string) string {
+	root := rootPrefix(modulePage(m.spec))
+	codec := &gendocCodec{resolve: func(symbol string) string {
+		return symbolTarget(symbol, m.spec, root, symbolURLs)
+	}}
+	codec.ConvertCodeBlock = func(info, code string) string {
+		return textToHTML(elvdoc.HighlightCodeBlock(info, code))
+	}
+
+	lastHeading := ""
+	forEachEntry(m, func(symbol, anchor string, entry elvdoc.Entry) {
+		heading := "Functions"
+		if strings.HasPrefix(symbol, "$") {
+			heading = "Variables"
+		}
+		if heading != lastHeading {
+			fmt.Fprintf(codec, "<h2>%s</h2>\n", heading)
+			lastHeading = heading
+		}
+		anchorHTML := html.EscapeString(anchor)
+		fmt.Fprintf(codec,
+			"<h3 id=\"%s\"><code>%s</code><a href=\"#%s\" class=\"anchor\" aria-hidden=\"true\">#</a></h3>\n",
+			anchorHTML, html.EscapeString(symbol), anchorHTML)
+		md.Render(entry.Content, md.SmartPunctsCodec{Inner: codec})
+	})
+	if lastHeading == "" {
+		codec.WriteString("<p>This module has no documented symbols.</p>\n")
+	}
+	return codec.String()
+}
+
+// A wrapper of [md.HTMLCodec] that fills in the implicit destinations of
+// links like [`foo`]().
+type gendocCodec struct {
+	md.HTMLCodec
+	resolve func(symbol string) string
+}
+
+func (c *gendocCodec) Do(op md.Op) {
+	ops := op.Content
+	for i := range ops {
+		if i+2 < len(ops) &&
+			ops[i].Type == md.OpLinkStart && ops[i].Dest == "" &&
+			ops[i+1].Type == md.OpCodeSpan && ops[i+2].Type == md.OpLinkEnd {
+
+			dest := c.resolve(ops[i+1].Text)
+			ops[i].Dest, ops[i+2].Dest = dest, dest
+		}
+	}
+	c.HTMLCodec.Do(op)
+}
+
+// Returns the link destination of a symbol referenced from the page of the
+// module currentModule, whose root prefix is root.
+//
+// Symbols documented in the site link to their pages. An unqualified symbol
+// is looked up in the current module first. Other symbols are assumed to be
+// from the builtin modules, and link to the reference docs on elv.sh:
+//
+//	foo -> https://elv.sh/ref/builtin.html#foo
+//	mod:foo -> https://elv.sh/ref/mod.html#mod:foo
+func symbolTarget(symbol, currentModule, root string, symbolURLs map[string]string) string {
+	name := strings.TrimPrefix(symbol, "$")
+	sigil := symbol[:len(symbol)-len(name)]
+	i := strings.IndexByte(name, ':')
+	if i == -1 {
+		if url, ok := symbolURLs[sigil+currentModule+":"+name]; ok {
+			return relativeURL(url, currentModule, root)
+		}
+		return "https://elv.sh/ref/builtin.html#" + symbol
+	}
+	if url, ok := symbolURLs[symbol]; ok {
+		return relativeURL(url, currentModule, root)
+	}
+	return "https://elv.sh/ref/" + name[:i] + ".html#" + symbol
+}
+
+func relativeURL(url, currentModule, root string) string {
+	if page := modulePage(currentModule); strings.HasPrefix(url, page+"#") {
+		return url[len(page):]
+	}
+	return root + url
+}
+
+// Returns the path of the page of a module, relative to the root of the site.
+func modulePage(spec string) string {
+	return "mod/" + spec + ".html"
+}
+
+// Returns the relative path from a page to the root of the site, like "../../"
+// for "mod/a/b.html".
+func rootPrefix(page string) string {
+	return strings.Repeat("../", strings.Count(page, "/"))
+}
+
+// Converts highlighted text to HTML, using CSS classes like "sgr-31" for the
+// styles.
+func textToHTML(t ui.Text) string {
+	var sb strings.Builder
+	for _, seg := range t {
+		var classes []string
+		for _, sgrCode := range seg.Style.SGRValues() {
+			classes = append(classes, "sgr-"+sgrCode)
+		}
+		jointClass := strings.Join(classes, " ")
+		if len(jointClass) > 0 {
+			fmt.Fprintf(&sb, `<span class="%s">`, jointClass)
+		}
+		sb.WriteString(html.EscapeString(seg.Text))
+		if len(jointClass) > 0 {
+			sb.WriteString("</span>")
+		}
+	}
+	return sb.String()
+}
+
+var gendocTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body data-root="{{.Root}}">
+<nav>
+<a href="{{.Root}}index.html">Modules</a>
+<input type="search" id="search" placeholder="Search" autocomplete="off">
+<ul id="search-results"></ul>
+</nav>
+<main>
+<h1>{{.Title}}</h1>
+{{.Content}}
+</main>
+<script src="{{.Root}}search-index.js"></script>
+<script src="{{.Root}}search.js"></script>
+</body>
+</html>
+`))
+
+// Generates a page at the given path relative to the root of the site.
+func gendocPage(path, title, content string) (string, error) {
+	var sb strings.Builder
+	err := gendocTemplate.Execute(&sb, struct {
+		Title, Root string
+		Content     template.HTML
+	}{title, rootPrefix(path), template.HTML(content)})
+	return sb.String(), err
+}
+
+const gendocSearchJS = `(function() {
+  var root = document.body.getAttribute('data-root'),
+      input = document.getElementById('search'),
+      results = document.getElementById('search-results');
+  input.oninput = function() {
+    results.innerHTML = '';
+    var words = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    if (words.length === 0) {
+      return;
+    }
+    searchIndex.forEach(function(entry) {
+      var text = (entry.name + ' ' + entry.summary).toLowerCase();
+      if (!words.every(function(w) { return text.indexOf(w) !== -1; })) {
+        return;
+      }
+      var li = document.createElement('li'),
+          a = document.createElement('a'),
+          code = document.createElement('code');
+      a.href = root + entry.url;
+      code.textContent = entry.name;
+      a.appendChild(code);
+      li.appendChild(a);
+      li.appendChild(document.createTextNode(' ' + entry.summary));
+      results.appendChild(li);
+    });
+  };
+})();
+`
+
+const gendocCSS = `body {
+  font-family: sans-serif;
+  line-height: 1.5;
+  max-width: 50rem;
+  margin: 0 auto;
+  padding: 0 1rem;
+}
+nav {
+  padding: 1rem 0;
+  border-bottom: 1px solid #ddd;
+}
+#search {
+  float: right;
+}
+#search-results:empty {
+  display: none;
+}
+pre {
+  padding: 0.5rem;
+  overflow: auto;
+  background-color: #f0f0f0;
+}
+code {
+  font-family: monospace;
+}
+.anchor {
+  margin-left: 0.5rem;
+  color: #bbb;
+  text-decoration: none;
+}
+.sgr-1 { font-weight: bold; }
+.sgr-4 { text-decoration: underline; }
+.sgr-31 { color: maroon; }
+.sgr-32 { color: green; }
+.sgr-33 { color: goldenrod; }
+.sgr-34 { color: navy; }
+.sgr-35 { color: darkorchid; }
+.sgr-36 { color: darkcyan; }
+.sgr-90 { color: grey; }
+.sgr-91 { color: red; }
+.sgr-92 { color: lime; }
+.sgr-93 { color: yellow; }
+.sgr-94 { color: blue; }
+.sgr-95 { color: fuchsia; }
+.sgr-96 { color: aqua; }
+`
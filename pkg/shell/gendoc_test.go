@@ -0,0 +1,112 @@
+package shell
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"src.elv.sh/pkg/must"
+	. "src.elv.sh/pkg/prog/progtest"
+	"src.elv.sh/pkg/testutil"
+)
+
+func TestGendoc(t *testing.T) {
+	setupCleanHomePaths(t)
+	testutil.InTempDir(t)
+	testutil.ApplyDir(testutil.Dir{
+		"lib": testutil.Dir{
+			"a": testutil.Dir{
+				"greeter.elv": testutil.Dedent(`
+					# The greeting used by [` + "`greet`" + `]().
+					var greeting = hello
+
+					# Greets $name, using [` + "`$greeting`" + `]() and [` + "`str:join`" + `]().
+					#
+					# ~~~elvish-transcript
+					# ~> use a/greeter
+					# ~> a/greeter:greet world
+					# hello world
+					# ~~~
+					fn greet {|name| echo $greeting $name }
+					`),
+			},
+			"util.elv": testutil.Dedent(`
+				# Calls [` + "`a/greeter:greet`" + `]().
+				fn hi { }
+				`),
+			// The page of this module must not clash with the index page.
+			"index.elv":     "# Index of things.\nfn find { }\n",
+			"util_test.elv": "# Not documented.\nfn test-hi { }\n",
+			".hidden":       testutil.Dir{"x.elv": "# Not documented.\nfn x { }\n"},
+		},
+		"empty": testutil.Dir{},
+	})
+
+	Test(t, &Program{},
+		ThatElvish("-gendoc", "lib", "-o", "site").DoesNothing(),
+		ThatElvish("-gendoc", "empty", "-o", "site2").
+			ExitsWith(2).
+			WritesStderr("no modules found in empty\n"),
+		ThatElvish("-gendoc", "lib").
+			ExitsWith(2).
+			WritesStderrContaining("-o must be specified with -gendoc"),
+		ThatElvish("-o", "site").
+			ExitsWith(2).
+			WritesStderrContaining("-o can only be used with -gendoc or -bundle"),
+		ThatElvish("-gendoc", "lib", "-o", "site", "foo").
+			ExitsWith(2).
+			WritesStderrContaining("-gendoc doesn't take arguments"),
+	)
+
+	for _, name := range []string{"index.html", "mod/a/greeter.html", "mod/index.html", "mod/util.html", "search-index.js", "search.js", "style.css"} {
+		if _, err := os.Stat("site/" + name); err != nil {
+			t.Errorf("site/%s not generated: %v", name, err)
+		}
+	}
+	for _, name := range []string{"mod/util_test.html", "mod/.hidden/x.html"} {
+		if _, err := os.Stat("site/" + name); err == nil {
+			t.Errorf("site/%s generated, want not generated", name)
+		}
+	}
+
+	greeter := must.ReadFileString("site/mod/a/greeter.html")
+	for _, want := range []string{
+		// Links to the root of the site
+		`<link rel="stylesheet" href="../../style.css">`,
+		// Anchors
+		`<h3 id="$a/greeter:greeting">`,
+		`<h3 id="a/greeter:greet">`,
+		// Links to symbols in the same module, including unqualified ones
+		`<a href="#a/greeter:greet"><code>greet</code></a>`,
+		`<a href="#$a/greeter:greeting"><code>$greeting</code></a>`,
+		// Links to builtin modules
+		`<a href="https://elv.sh/ref/str.html#str:join"><code>str:join</code></a>`,
+		// Highlighted transcript
+		`~&gt; <span class="sgr-32">use</span> a/greeter`,
+	} {
+		if !strings.Contains(greeter, want) {
+			t.Errorf("site/mod/a/greeter.html doesn't contain %q", want)
+		}
+	}
+
+	util := must.ReadFileString("site/mod/util.html")
+	// Links to symbols in other modules
+	if want := `<a href="../mod/a/greeter.html#a/greeter:greet">`; !strings.Contains(util, want) {
+		t.Errorf("site/mod/util.html doesn't contain %q", want)
+	}
+
+	index := must.ReadFileString("site/index.html")
+	for _, want := range []string{
+		`<a href="mod/a/greeter.html"><code>a/greeter</code></a>`,
+		`<a href="mod/index.html"><code>index</code></a>`,
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("site/index.html doesn't contain %q", want)
+		}
+	}
+
+	searchIndex := must.ReadFileString("site/search-index.js")
+	if want := `{"name":"util:hi","url":"mod/util.html#util:hi","summary":"Calls a/greeter:greet."}`; !strings.Contains(searchIndex, want) {
+		t.Errorf("site/search-index.js doesn't contain %q", want)
+	}
+}
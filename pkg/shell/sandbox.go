@@ -0,0 +1,81 @@
+package shell
+
+import (
+	"path/filepath"
+	"strings"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/prog"
+)
+
+// Flags for running scripts in a sandbox. Any of the -sandbox-* flags implies
+// -sandbox.
+type sandboxFlags struct {
+	on      bool
+	cmds    string
+	read    string
+	write   string
+	env     bool
+	denyUse string
+}
+
+func (f *sandboxFlags) register(fs *prog.FlagSet) {
+	fs.BoolVar(&f.on, "sandbox", false,
+		"Run the script in a sandbox, configured with the -sandbox-* flags")
+	fs.StringVar(&f.cmds, "sandbox-cmds", "",
+		"Comma-separated external commands the script can run in the sandbox")
+	fs.StringVar(&f.read, "sandbox-read", "",
+		"Directories the script can read in the sandbox, separated by the path list separator")
+	fs.StringVar(&f.write, "sandbox-write", "",
+		"Directories the script can read and write in the sandbox, separated by the path list separator")
+	fs.BoolVar(&f.env, "sandbox-env", false,
+		"Allow the script to change environment variables in the sandbox")
+	fs.StringVar(&f.denyUse, "sandbox-deny-use", "",
+		"Comma-separated modules the script can't use in the sandbox")
+}
+
+func (f *sandboxFlags) enabled() bool {
+	return f.on || f.cmds != "" || f.read != "" || f.write != "" || f.env || f.denyUse != ""
+}
+
+func (f *sandboxFlags) sandbox() (*eval.Sandbox, error) {
+	readRoots, err := absPathList(f.read)
+	if err != nil {
+		return nil, err
+	}
+	writeRoots, err := absPathList(f.write)
+	if err != nil {
+		return nil, err
+	}
+	return &eval.Sandbox{
+		AllowedCommands:  splitComma(f.cmds),
+		ReadRoots:        readRoots,
+		WriteRoots:       writeRoots,
+		AllowEnvMutation: f.env,
+		DeniedModules:    splitComma(f.denyUse),
+	}, nil
+}
+
+// Splits a list of paths joined by the path list separator, and makes them
+// absolute so that they are not affected by changes of the working directory.
+func absPathList(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	paths := filepath.SplitList(s)
+	for i, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = abs
+	}
+	return paths, nil
+}
+
+func splitComma(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,56 @@
+package shell
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "src.elv.sh/pkg/prog/progtest"
+	"src.elv.sh/pkg/testutil"
+)
+
+func TestSandbox(t *testing.T) {
+	setupCleanHomePaths(t)
+	testutil.InTempDir(t)
+	testutil.ApplyDir(testutil.Dir{
+		"ro":  testutil.Dir{"a": "content of a\n"},
+		"rw":  testutil.Dir{},
+		"out": testutil.Dir{"secret": "secret\n"},
+	})
+	// Changed by one of the tests.
+	testutil.SaveEnv(t, "X")
+
+	Test(t, &Program{},
+		ThatElvish("-sandbox", "-c", "echo (slurp < ro/a)").
+			ExitsWith(2).
+			WritesStderrContaining("sandbox: reading ro/a is not allowed"),
+		ThatElvish("-sandbox-read", strings.Join([]string{"ro", "rw"}, string(os.PathListSeparator)), "-c", "print (slurp < ro/a)").
+			WritesStdout("content of a\n"),
+		ThatElvish("-sandbox-read", "ro", "-c", "echo (slurp < out/secret)").
+			ExitsWith(2).
+			WritesStderrContaining("sandbox: reading out/secret is not allowed"),
+		ThatElvish("-sandbox-write", "rw", "-c", "echo foo > rw/b; print (slurp < rw/b)").
+			WritesStdout("foo\n"),
+		ThatElvish("-sandbox-read", "ro", "-c", "echo foo > ro/b").
+			ExitsWith(2).
+			WritesStderrContaining("sandbox: writing ro/b is not allowed"),
+		ThatElvish("-sandbox", "-c", "set-env X foo").
+			ExitsWith(2).
+			WritesStderrContaining("sandbox: changing environment variable X is not allowed"),
+		ThatElvish("-sandbox-env", "-c", "set-env X foo; echo $E:X").
+			WritesStdout("foo\n"),
+		ThatElvish("-sandbox-deny-use", "re,os", "-c", "use os").
+			ExitsWith(2).
+			WritesStderrContaining("sandbox: using module os is not allowed"),
+		ThatElvish("-sandbox", "-c", "nonexistent-cmd").
+			ExitsWith(2).
+			WritesStderrContaining("sandbox: running external command nonexistent-cmd is not allowed"),
+
+		ThatElvish("-sandbox").
+			ExitsWith(2).
+			WritesStderrContaining("-sandbox can only be used when running a script"),
+		ThatElvish("-sandbox-cmds", "ls", "-test", ".").
+			ExitsWith(2).
+			WritesStderrContaining("-sandbox can only be used when running a script"),
+	)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -28,12 +29,22 @@ var logger = logutil.GetLogger("[shell] ")
 type Program struct {
 	ActivateDaemon daemondefs.ActivateFunc
 
-	codeInArg   bool
-	compileOnly bool
-	noRC        bool
-	rc          string
-	json        *bool
-	daemonPaths *prog.DaemonPaths
+	codeInArg    bool
+	compileOnly  bool
+	noRC         bool
+	rc           string
+	test         bool
+	update       bool
+	testRun      string
+	testTimeout  time.Duration
+	testParallel int
+	testFormat   string
+	gendoc       string
+	bundle       string
+	out          string
+	sandbox      sandboxFlags
+	json         *bool
+	daemonPaths  *prog.DaemonPaths
 }
 
 func (p *Program) RegisterFlags(fs *prog.FlagSet) {
@@ -49,6 +60,25 @@ func (p *Program) RegisterFlags(fs *prog.FlagSet) {
 		"Don't read the RC file when running interactively")
 	fs.StringVar(&p.rc, "rc", "",
 		"Path to the RC file when running interactively")
+	fs.BoolVar(&p.test, "test", false,
+		"Run the transcripts in .elvts files and .elv comments in the given directories")
+	fs.BoolVar(&p.update, "update", false,
+		"Update the expected outputs of transcripts when used with -test")
+	fs.StringVar(&p.testRun, "test-run", "",
+		"Only run tests whose names match the regular expression when used with -test")
+	fs.DurationVar(&p.testTimeout, "test-timeout", 0,
+		"Timeout of each unit test when used with -test; 0 means no timeout")
+	fs.IntVar(&p.testParallel, "test-parallel", 1,
+		"Maximal number of unit tests to run in parallel when used with -test")
+	fs.StringVar(&p.testFormat, "test-format", "text",
+		"Output format of -test: text, tap or junit")
+	fs.StringVar(&p.gendoc, "gendoc", "",
+		"Generate HTML documentation for the modules in the given directory")
+	fs.StringVar(&p.bundle, "bundle", "",
+		"Bundle the given script and the modules it uses into an executable")
+	fs.StringVar(&p.out, "o", "",
+		"Output path of -gendoc or -bundle")
+	p.sandbox.register(fs)
 
 	p.json = fs.JSON()
 	if p.ActivateDaemon != nil {
@@ -57,6 +87,43 @@ func (p *Program) RegisterFlags(fs *prog.FlagSet) {
 }
 
 func (p *Program) Run(fds [3]*os.File, args []string) error {
+	if p.update && !p.test {
+		return prog.BadUsage("-update can only be used with -test")
+	}
+	if p.out != "" && p.gendoc == "" && p.bundle == "" {
+		return prog.BadUsage("-o can only be used with -gendoc or -bundle")
+	}
+	if p.sandbox.enabled() && (p.gendoc != "" || p.bundle != "" || p.test || len(args) == 0) {
+		return prog.BadUsage("-sandbox can only be used when running a script")
+	}
+	if p.gendoc != "" && p.bundle != "" {
+		return prog.BadUsage("-gendoc and -bundle can't be used together")
+	}
+	if p.gendoc != "" {
+		if len(args) > 0 {
+			return prog.BadUsage("-gendoc doesn't take arguments")
+		}
+		return genDoc(p.gendoc, p.out)
+	}
+	if p.bundle != "" {
+		if len(args) > 0 {
+			return prog.BadUsage("-bundle doesn't take arguments")
+		}
+		return makeBundle(p.makeEvaler(fds[2], false), p.bundle, p.out)
+	}
+	if p.test {
+		cfg := &testCfg{Update: p.update, Timeout: p.testTimeout,
+			Parallel: p.testParallel, Format: p.testFormat}
+		if p.testRun != "" {
+			var err error
+			cfg.Run, err = regexp.Compile(p.testRun)
+			if err != nil {
+				return prog.BadUsage("bad -test-run: " + err.Error())
+			}
+		}
+		return runTests(fds, args, cfg)
+	}
+
 	cleanup1 := incSHLVL()
 	defer cleanup1()
 	cleanup2 := initSignal(fds)
@@ -69,6 +136,13 @@ func (p *Program) Run(fds [3]*os.File, args []string) error {
 	defer ev.PreExit()
 
 	if !interactive {
+		if p.sandbox.enabled() {
+			sb, err := p.sandbox.sandbox()
+			if err != nil {
+				return err
+			}
+			ev.Sandbox = sb
+		}
 		exit := script(
 			ev, fds, args, &scriptCfg{
 				Cmd: p.codeInArg, CompileOnly: p.compileOnly, JSON: *p.json})
@@ -0,0 +1,278 @@
+package shell
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/mods"
+	"src.elv.sh/pkg/prog"
+)
+
+// Implementation of "elvish -test", which runs transcript tests (see
+// transcripts.go) and unit tests (see test_fns.go).
+
+type testCfg struct {
+	// Whether to rewrite the expected outputs of transcripts instead of
+	// reporting failures.
+	Update bool
+	// If non-nil, only run tests whose names match it.
+	Run *regexp.Regexp
+	// Timeout of each unit test; 0 means no timeout.
+	Timeout time.Duration
+	// Maximal number of unit tests to run in parallel.
+	Parallel int
+	// Output format: "text", "tap" or "junit".
+	Format string
+}
+
+type testStatus int
+
+const (
+	testPassed testStatus = iota
+	testFailed
+	testSkipped
+)
+
+type testResult struct {
+	// The file containing the test.
+	file   string
+	name   string
+	status testStatus
+	// A one-line summary of a failure, or the reason for skipping.
+	summary string
+	// Details of a failure.
+	message  string
+	duration time.Duration
+}
+
+func runTests(fds [3]*os.File, args []string, cfg *testCfg) error {
+	switch cfg.Format {
+	case "text", "tap", "junit":
+	default:
+		return prog.BadUsage("-test-format must be text, tap or junit")
+	}
+	if cfg.Parallel < 1 {
+		return prog.BadUsage("-test-parallel must be at least 1")
+	}
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	libs, err := libPaths(fds[2])
+	if err != nil {
+		fmt.Fprintln(fds[2], "Warning: resolving lib paths:", err)
+	}
+	r := &testRunner{fds: fds, cfg: cfg}
+	for _, root := range args {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+		r.libDirs = append([]string{absRoot}, libs...)
+		var testFiles []string
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if strings.HasSuffix(path, "_test.elv") {
+				testFiles = append(testFiles, path)
+			}
+			switch filepath.Ext(path) {
+			case ".elvts", ".elv":
+				return r.testTranscriptFile(path)
+			}
+			return nil
+		})
+		if err == nil {
+			err = r.testFnsInFiles(testFiles)
+		}
+		if err != nil {
+			fmt.Fprintln(fds[2], err)
+			return prog.Exit(2)
+		}
+	}
+
+	var writeErr error
+	switch cfg.Format {
+	case "text":
+		writeErr = writeTestSummary(fds[1], r.results)
+	case "tap":
+		writeErr = writeTestTAP(fds[1], r.results)
+	case "junit":
+		writeErr = writeTestJUnit(fds[1], r.results)
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	for _, result := range r.results {
+		if result.status == testFailed {
+			return prog.Exit(1)
+		}
+	}
+	return nil
+}
+
+type testRunner struct {
+	fds     [3]*os.File
+	cfg     *testCfg
+	libDirs []string
+	results []testResult
+}
+
+func (r *testRunner) shouldRun(name string) bool {
+	return r.cfg.Run == nil || r.cfg.Run.MatchString(name)
+}
+
+func (r *testRunner) newEvaler() *eval.Evaler {
+	ev := eval.NewEvaler()
+	ev.LibDirs = r.libDirs
+	mods.AddTo(ev)
+	return ev
+}
+
+// Records the result of a test. In the text format, failures are also shown
+// immediately.
+func (r *testRunner) report(result testResult) {
+	r.results = append(r.results, result)
+	if r.cfg.Format == "text" && result.status == testFailed {
+		fmt.Fprintf(r.fds[1], "FAIL %s\n%s", result.name, result.message)
+	}
+}
+
+// Writes a message about the progress of the run. Messages go to stdout in
+// the text format, and stderr otherwise to keep the report machine-readable.
+func (r *testRunner) notef(format string, args ...any) {
+	w := r.fds[1]
+	if r.cfg.Format != "text" {
+		w = r.fds[2]
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+func countTestResults(results []testResult) (passed, failed, skipped int) {
+	for _, result := range results {
+		switch result.status {
+		case testPassed:
+			passed++
+		case testFailed:
+			failed++
+		case testSkipped:
+			skipped++
+		}
+	}
+	return
+}
+
+func writeTestSummary(w io.Writer, results []testResult) error {
+	passed, failed, skipped := countTestResults(results)
+	_, err := fmt.Fprintf(w, "%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	return err
+}
+
+// Writes the results in the Test Anything Protocol, version 13
+// (https://testanything.org/tap-version-13-specification.html).
+func writeTestTAP(w io.Writer, results []testResult) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "TAP version 13\n1..%d\n", len(results))
+	for i, result := range results {
+		name := strings.ReplaceAll(result.name, "#", `\#`)
+		switch result.status {
+		case testPassed:
+			fmt.Fprintf(&sb, "ok %d - %s\n", i+1, name)
+		case testSkipped:
+			fmt.Fprintf(&sb, "ok %d - %s # SKIP %s\n", i+1, name, result.summary)
+		case testFailed:
+			fmt.Fprintf(&sb, "not ok %d - %s\n", i+1, name)
+			sb.WriteString("  ---\n  message: |\n")
+			for _, line := range strings.Split(strings.TrimSuffix(result.message, "\n"), "\n") {
+				sb.WriteString("    " + line + "\n")
+			}
+			sb.WriteString("  ...\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Skipped   *junitMessage `xml:"skipped"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Writes the results in the JUnit XML format, with one test suite for each
+// file.
+func writeTestJUnit(w io.Writer, results []testResult) error {
+	var suites junitTestSuites
+	suites.Tests = len(results)
+	_, suites.Failures, suites.Skipped = countTestResults(results)
+	suiteIndex := make(map[string]int)
+	var suiteDurations []time.Duration
+	for _, result := range results {
+		i, ok := suiteIndex[result.file]
+		if !ok {
+			i = len(suites.Suites)
+			suiteIndex[result.file] = i
+			suites.Suites = append(suites.Suites, junitTestSuite{Name: result.file})
+			suiteDurations = append(suiteDurations, 0)
+		}
+		suite := &suites.Suites[i]
+		suiteDurations[i] += result.duration
+		testCase := junitTestCase{Name: result.name, Classname: result.file,
+			Time: formatJUnitTime(result.duration)}
+		switch result.status {
+		case testFailed:
+			suite.Failures++
+			testCase.Failure = &junitMessage{result.summary, result.message}
+		case testSkipped:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: result.summary}
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	for i := range suites.Suites {
+		suites.Suites[i].Time = formatJUnitTime(suiteDurations[i])
+	}
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+	return err
+}
+
+func formatJUnitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
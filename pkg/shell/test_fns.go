@@ -0,0 +1,156 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"src.elv.sh/pkg/diag"
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/mods/test"
+	"src.elv.sh/pkg/parse"
+)
+
+// Support for running unit tests, which are functions whose names start with
+// "test-" in *_test.elv files.
+
+const testFnPrefix = "test-"
+
+// A unit test to run.
+type testFn struct {
+	file string
+	src  string
+	name string
+}
+
+// Discovers the unit tests in the given files, and runs them with up to
+// cfg.Parallel tests at the same time. Each test is run in a fresh Evaler,
+// in which the file is evaluated before the test function is called.
+func (r *testRunner) testFnsInFiles(files []string) error {
+	var fns []testFn
+	for _, file := range files {
+		src, err := readFileUTF8(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		names, err := r.discoverTestFns(file, src)
+		if err != nil {
+			r.report(testResult{file: file, name: file, status: testFailed,
+				summary: err.Error(), message: showTestError(err)})
+			continue
+		}
+		for _, name := range names {
+			if fullName := file + "/" + name; r.shouldRun(fullName) {
+				fns = append(fns, testFn{file, src, name})
+			}
+		}
+	}
+
+	results := make([]testResult, len(fns))
+	sem := make(chan struct{}, r.cfg.Parallel)
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, fn testFn) {
+			defer wg.Done()
+			results[i] = r.runTestFn(fn)
+			<-sem
+		}(i, fn)
+	}
+	wg.Wait()
+	for _, result := range results {
+		r.report(result)
+	}
+	return nil
+}
+
+// Evaluates a test file and returns the names of the test functions it
+// defines, in the order they are defined.
+func (r *testRunner) discoverTestFns(file, src string) ([]string, error) {
+	ev := r.newEvaler()
+	err := ev.Eval(
+		parse.Source{Name: file, Code: src, IsFile: true},
+		eval.EvalCfg{Ports: eval.DummyPorts})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	ev.Global().IterateKeysString(func(name string) {
+		if strings.HasPrefix(name, testFnPrefix) && strings.HasSuffix(name, eval.FnSuffix) {
+			names = append(names, strings.TrimSuffix(name, eval.FnSuffix))
+		}
+	})
+	return names, nil
+}
+
+func (r *testRunner) runTestFn(fn testFn) testResult {
+	start := time.Now()
+	result := testResult{file: fn.file, name: fn.file + "/" + fn.name}
+
+	port1, collect1, err := eval.CapturePort()
+	if err != nil {
+		result.status, result.summary = testFailed, err.Error()
+		result.message = err.Error() + "\n"
+		return result
+	}
+	port2, collect2, err := eval.CapturePort()
+	if err != nil {
+		result.status, result.summary = testFailed, err.Error()
+		result.message = err.Error() + "\n"
+		return result
+	}
+	ctx := context.Background()
+	if r.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.Timeout)
+		defer cancel()
+	}
+	evalCfg := eval.EvalCfg{
+		Ports: []*eval.Port{eval.DummyInputPort, port1, port2}, Interrupts: ctx}
+
+	ev := r.newEvaler()
+	err = ev.Eval(parse.Source{Name: fn.file, Code: fn.src, IsFile: true}, evalCfg)
+	if err == nil {
+		f, _ := ev.Global().Index(fn.name + eval.FnSuffix)
+		err = ev.Call(f.(eval.Callable), eval.CallCfg{From: "[test]"}, evalCfg)
+	}
+	result.duration = time.Since(start)
+
+	values, stdout := collect1()
+	_, stderr := collect2()
+	var skip test.SkipError
+	switch {
+	case err == nil:
+		result.status = testPassed
+	case errors.As(eval.Reason(err), &skip):
+		result.status, result.summary = testSkipped, skip.Reason
+	default:
+		var sb strings.Builder
+		for _, value := range values {
+			sb.WriteString("▶ " + vals.ReprPlain(value) + "\n")
+		}
+		sb.Write(normalizeOutput(stdout))
+		sb.Write(normalizeOutput(stderr))
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.summary = fmt.Sprintf("timed out after %v", r.cfg.Timeout)
+			sb.WriteString(result.summary + "\n")
+		} else {
+			result.summary = err.Error()
+			sb.WriteString(showTestError(err))
+		}
+		result.status, result.message = testFailed, sb.String()
+	}
+	return result
+}
+
+func showTestError(err error) string {
+	if shower, ok := err.(diag.Shower); ok {
+		return string(normalizeOutput([]byte(shower.Show("")))) + "\n"
+	}
+	return err.Error() + "\n"
+}
@@ -0,0 +1,118 @@
+package shell
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	. "src.elv.sh/pkg/prog/progtest"
+	"src.elv.sh/pkg/testutil"
+)
+
+var Dedent = testutil.Dedent
+
+func TestTestFns(t *testing.T) {
+	setupCleanHomePaths(t)
+	testutil.InTempDir(t)
+	testutil.ApplyDir(testutil.Dir{
+		"fns": testutil.Dir{
+			"a_test.elv": testutil.Dedent(`
+				use test
+				fn test-pass { test:assert-eq (+ 1 1) (num 2) }
+				fn test-fail { echo some output; test:assert $false }
+				fn test-skip { test:skip 'not today' }
+				fn helper { }
+				`),
+			// Not a test file, so the functions are not run.
+			"b.elv": "fn test-not-run { fail foo }",
+		},
+		"slow": testutil.Dir{
+			"a_test.elv": "fn test-slow { sleep 10 }",
+		},
+		"bad": testutil.Dir{
+			"a_test.elv": "fail bad",
+		},
+	})
+
+	failOutput := "FAIL fns/a_test.elv/test-fail\n" +
+		"some output\n" +
+		"Exception: assertion failed: $false is not truthy\n" +
+		"  fns/a_test.elv:3:34-52: fn test-fail { echo some output; test:assert $false }\n"
+
+	Test(t, &Program{},
+		ThatElvish("-test", "fns").
+			ExitsWith(1).
+			WritesStdout(failOutput+"1 passed, 1 failed, 1 skipped\n"),
+		ThatElvish("-test", "-test-parallel", "3", "fns").
+			ExitsWith(1).
+			WritesStdout(failOutput+"1 passed, 1 failed, 1 skipped\n"),
+		ThatElvish("-test", "-test-run", "pass|skip", "fns").
+			WritesStdout("1 passed, 0 failed, 1 skipped\n"),
+		ThatElvish("-test", "-test-timeout", "10ms", "slow").
+			ExitsWith(1).
+			WritesStdout("FAIL slow/a_test.elv/test-slow\ntimed out after 10ms\n"+
+				"0 passed, 1 failed, 0 skipped\n"),
+		ThatElvish("-test", "bad").
+			ExitsWith(1).
+			WritesStdoutContaining("FAIL bad/a_test.elv\nException: bad\n"),
+
+		ThatElvish("-test", "-test-format", "tap", "fns").
+			ExitsWith(1).
+			WritesStdout(Dedent(`
+				TAP version 13
+				1..3
+				ok 1 - fns/a_test.elv/test-pass
+				not ok 2 - fns/a_test.elv/test-fail
+				  ---
+				  message: |
+				    some output
+				    Exception: assertion failed: $false is not truthy
+				      fns/a_test.elv:3:34-52: fn test-fail { echo some output; test:assert $false }
+				  ...
+				ok 3 - fns/a_test.elv/test-skip # SKIP not today
+				`)),
+		ThatElvish("-test", "-test-format", "junit", "fns").
+			ExitsWith(1).
+			WritesStdoutContaining(`<testsuites tests="3" failures="1" skipped="1">`),
+		ThatElvish("-test", "-test-format", "junit", "fns").
+			ExitsWith(1).
+			WritesStdoutContaining(`<failure message="assertion failed: $false is not truthy">`),
+		ThatElvish("-test", "-test-format", "junit", "fns").
+			ExitsWith(1).
+			WritesStdoutContaining(`<skipped message="not today"></skipped>`),
+
+		ThatElvish("-test", "-test-format", "xml").
+			ExitsWith(2).
+			WritesStderrContaining("-test-format must be text, tap or junit"),
+		ThatElvish("-test", "-test-parallel", "0").
+			ExitsWith(2).
+			WritesStderrContaining("-test-parallel must be at least 1"),
+		ThatElvish("-test", "-test-run", "[").
+			ExitsWith(2).
+			WritesStderrContaining("bad -test-run"),
+	)
+}
+
+func TestTestFns_TimeoutKillsExternalCommands(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("no sleep command")
+	}
+	setupCleanHomePaths(t)
+	testutil.InTempDir(t)
+	testutil.ApplyDir(testutil.Dir{
+		"slow": testutil.Dir{
+			"a_test.elv": "fn test-slow { e:sleep 10 }",
+		},
+	})
+
+	start := time.Now()
+	Test(t, &Program{},
+		ThatElvish("-test", "-test-timeout", "10ms", "slow").
+			ExitsWith(1).
+			WritesStdout("FAIL slow/a_test.elv/test-slow\ntimed out after 10ms\n"+
+				"0 passed, 1 failed, 0 skipped\n"),
+	)
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("took %v, e:sleep was not killed", d)
+	}
+}
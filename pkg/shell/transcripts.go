@@ -0,0 +1,360 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"go/build/constraint"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"src.elv.sh/pkg/diff"
+	"src.elv.sh/pkg/eval"
+	"src.elv.sh/pkg/eval/vals"
+	"src.elv.sh/pkg/parse"
+	"src.elv.sh/pkg/transcript"
+)
+
+// Support for running transcripts in .elvts files and elvish-transcript code
+// blocks in the comments of .elv files as tests, in the same way as
+// [src.elv.sh/pkg/eval/evaltest.TestTranscriptsInFS].
+
+// A block of transcript: either an entire .elvts file, or an
+// elvish-transcript code block in the comments of an .elv file.
+type transcriptBlock struct {
+	name string
+	// Content of the block, with comment markers stripped in .elv files.
+	lines []string
+	// Directory of the file containing the block.
+	dir string
+	// Indices of the block in the lines of the file, and the prefix of each
+	// line up to the opening fence, like "# ". Only used for .elv files.
+	from, to int
+	prefix   string
+}
+
+func (r *testRunner) testTranscriptFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// Setup files are resolved relative to the directory of the transcript,
+	// which must be absolute since the session may change the working
+	// directory.
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	fileLines := strings.Split(string(content), "\n")
+	var blocks []transcriptBlock
+	if filepath.Ext(path) == ".elvts" {
+		blocks = []transcriptBlock{{name: path, lines: fileLines, dir: dir}}
+	} else {
+		blocks, err = transcriptBlocksInElv(path, dir, fileLines)
+		if err != nil {
+			return err
+		}
+	}
+
+	modified := false
+	// Iterate backwards, so that updating a block doesn't change the indices
+	// of the blocks before it.
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		outputs, err := r.testTranscriptBlock(path, block)
+		if err != nil {
+			return err
+		}
+		if !r.cfg.Update || len(outputs) == 0 {
+			continue
+		}
+		lines := transcript.UpdateOutputs(block.lines, outputs)
+		if filepath.Ext(path) == ".elvts" {
+			fileLines = lines
+		} else {
+			newLines := append([]string{}, fileLines[:block.from]...)
+			newLines = append(newLines, commentLines(lines, block.prefix)...)
+			fileLines = append(newLines, fileLines[block.to:]...)
+		}
+		modified = true
+	}
+	if !modified {
+		return nil
+	}
+	r.notef("updated %s\n", path)
+	return os.WriteFile(path, []byte(strings.Join(fileLines, "\n")), 0644)
+}
+
+// Runs all the sessions in a block. In update mode, it returns the actual
+// outputs of the interactions that didn't match the expected outputs, indexed
+// by their position in the block.
+func (r *testRunner) testTranscriptBlock(path string, block transcriptBlock) (map[int]string, error) {
+	sessions, err := transcript.ParseSessionsInBlock(
+		block.name, strings.NewReader(strings.Join(block.lines, "\n")))
+	if err != nil {
+		return nil, err
+	}
+	var outputs map[int]string
+	n := 0
+	for _, session := range sessions {
+		first := n
+		n += len(session.Interactions)
+		if !r.shouldRun(session.Name) {
+			continue
+		}
+		start := time.Now()
+		result := testResult{file: path, name: session.Name}
+		ev, cleanup, err := r.setupSession(block.dir, session.Directives)
+		if skip, ok := err.(skipSessionError); ok {
+			result.status, result.summary = testSkipped, skip.reason
+		} else if err != nil {
+			result.status, result.summary = testFailed, "setup: "+err.Error()
+			result.message = result.summary + "\n"
+		} else {
+			var failures strings.Builder
+			for i, interaction := range session.Interactions {
+				want := interaction.Output
+				got := evalAndCollectOutput(ev, interaction.Code)
+				if want == got {
+					continue
+				}
+				if r.cfg.Update {
+					if outputs == nil {
+						outputs = make(map[int]string)
+					}
+					outputs[first+i] = got
+				} else {
+					fmt.Fprintf(&failures, "%s\n-want +got:\n%s",
+						interaction.PromptAndCode(), diff.DiffNoHeader(want, got))
+				}
+			}
+			if failures.Len() > 0 {
+				result.status, result.summary = testFailed, "output mismatch"
+				result.message = failures.String()
+			}
+		}
+		cleanup()
+		result.duration = time.Since(start)
+		r.report(result)
+	}
+	return outputs, nil
+}
+
+type skipSessionError struct{ reason string }
+
+func (e skipSessionError) Error() string { return "session skipped: " + e.reason }
+
+// Creates an Evaler for a session and applies the directives. The returned
+// cleanup function must be called even when there is an error.
+//
+// The supported directives are the same as the predefined ones of
+// [src.elv.sh/pkg/eval/evaltest.TestTranscriptsInFS], plus "source $file",
+// which evaluates $file (relative to the directory containing the transcript)
+// in the session.
+func (r *testRunner) setupSession(dir string, directives []string) (*eval.Evaler, func(), error) {
+	var cleanups []func()
+	cleanup := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+
+	ev := r.newEvaler()
+	for _, directive := range directives {
+		name, arg, _ := strings.Cut(directive, " ")
+		switch name {
+		case "in-temp-dir":
+			tmp, err := os.MkdirTemp("", "elvish-test")
+			if err != nil {
+				return nil, cleanup, err
+			}
+			cleanups = append(cleanups, func() { os.RemoveAll(tmp) })
+			restore, err := chdir(tmp)
+			if err != nil {
+				return nil, cleanup, err
+			}
+			cleanups = append(cleanups, restore)
+		case "set-env", "unset-env":
+			envName, value, _ := strings.Cut(arg, " ")
+			oldValue, existed := os.LookupEnv(envName)
+			if name == "set-env" {
+				os.Setenv(envName, value)
+			} else {
+				os.Unsetenv(envName)
+			}
+			cleanups = append(cleanups, func() {
+				if existed {
+					os.Setenv(envName, oldValue)
+				} else {
+					os.Unsetenv(envName)
+				}
+			})
+		case "eval":
+			err := ev.Eval(
+				parse.Source{Name: "[setup]", Code: arg},
+				eval.EvalCfg{Ports: eval.DummyPorts})
+			if err != nil {
+				return nil, cleanup, err
+			}
+		case "source":
+			path := arg
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			src, err := readFileUTF8(path)
+			if err != nil {
+				return nil, cleanup, err
+			}
+			err = ev.Eval(
+				parse.Source{Name: path, Code: src, IsFile: true},
+				eval.EvalCfg{Ports: eval.DummyPorts})
+			if err != nil {
+				return nil, cleanup, err
+			}
+		case "only-on":
+			expr, err := constraint.Parse("//go:build " + arg)
+			if err != nil {
+				return nil, cleanup, err
+			}
+			if !expr.Eval(matchBuildTag) {
+				return nil, cleanup, skipSessionError{"constraint not satisfied: " + arg}
+			}
+		default:
+			return nil, cleanup, fmt.Errorf("unknown directive: %s", name)
+		}
+	}
+	return ev, cleanup, nil
+}
+
+// Changes the working directory, and returns a function to restore it.
+func chdir(dir string) (func(), error) {
+	oldWd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, err
+	}
+	return func() { os.Chdir(oldWd) }, nil
+}
+
+func matchBuildTag(tag string) bool {
+	if tag == "unix" {
+		// This package only supports Unix and Windows.
+		return runtime.GOOS != "windows"
+	}
+	return tag == runtime.GOOS || tag == runtime.GOARCH
+}
+
+// Pattern for the opening fence of an elvish-transcript code block in an .elv
+// file, capturing the comment prefix, the fence and the rest of the info
+// string.
+var elvTranscriptFencePattern = regexp.MustCompile(
+	"^([ \t]*# *)(```+|~~~+)elvish-transcript(.*)$")
+
+// Finds all the elvish-transcript code blocks in the comments of an .elv file.
+// Blocks are named $filename:$lineno, followed by /$name if there are
+// additional words after "elvish-transcript".
+func transcriptBlocksInElv(path, dir string, lines []string) ([]transcriptBlock, error) {
+	var blocks []transcriptBlock
+	for i := 0; i < len(lines); i++ {
+		m := elvTranscriptFencePattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		prefix, fence := m[1], m[2]
+		name := fmt.Sprintf("%s:%d", path, i+1)
+		if fields := strings.Fields(m[3]); len(fields) > 0 {
+			name += "/" + strings.Join(fields, " ")
+		}
+		block := transcriptBlock{name: name, dir: dir, from: i + 1, prefix: prefix}
+		for j := i + 1; ; j++ {
+			if j == len(lines) {
+				return nil, fmt.Errorf("%s: unclosed elvish-transcript block", name)
+			}
+			line, ok := uncommentLine(lines[j], prefix)
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: elvish-transcript block interrupted by non-comment line", path, j+1)
+			}
+			if trimmed := strings.TrimSpace(line); len(trimmed) >= len(fence) &&
+				strings.Trim(trimmed, fence[:1]) == "" {
+				block.to = j
+				break
+			}
+			block.lines = append(block.lines, line)
+		}
+		blocks = append(blocks, block)
+		i = block.to
+	}
+	return blocks, nil
+}
+
+// Strips the comment prefix from a line in a code block. A line consisting of
+// the prefix with trailing spaces removed, like "#", is an empty line.
+func uncommentLine(line, prefix string) (string, bool) {
+	if strings.HasPrefix(line, prefix) {
+		return line[len(prefix):], true
+	}
+	if strings.TrimRight(line, " \t") == strings.TrimRight(prefix, " ") {
+		return "", true
+	}
+	return "", false
+}
+
+// The inverse of uncommentLine.
+func commentLines(lines []string, prefix string) []string {
+	commented := make([]string, len(lines))
+	for i, line := range lines {
+		if line == "" {
+			commented[i] = strings.TrimRight(prefix, " ")
+		} else {
+			commented[i] = prefix + line
+		}
+	}
+	return commented
+}
+
+// Evaluates code and returns the output in the format used by transcripts,
+// with values, bytes written to stdout and stderr and the error, if any.
+func evalAndCollectOutput(ev *eval.Evaler, code string) string {
+	port1, collect1, err := eval.CapturePort()
+	if err != nil {
+		return err.Error() + "\n"
+	}
+	port2, collect2, err := eval.CapturePort()
+	if err != nil {
+		return err.Error() + "\n"
+	}
+	ports := []*eval.Port{eval.DummyInputPort, port1, port2}
+
+	ctx, done := eval.ListenInterrupts()
+	err = ev.Eval(
+		parse.Source{Name: "[tty]", Code: code},
+		eval.EvalCfg{Ports: ports, Interrupts: ctx})
+	done()
+
+	values, stdout := collect1()
+	_, stderr := collect2()
+
+	var sb strings.Builder
+	for _, value := range values {
+		sb.WriteString("▶ " + vals.ReprPlain(value) + "\n")
+	}
+	sb.Write(normalizeOutput(stdout))
+	sb.Write(normalizeOutput(stderr))
+
+	if err != nil {
+		sb.WriteString(showTestError(err))
+	}
+	return sb.String()
+}
+
+var sgrPattern = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// Strips SGR sequences and normalizes line endings.
+func normalizeOutput(bs []byte) []byte {
+	return bytes.ReplaceAll(sgrPattern.ReplaceAllLiteral(bs, nil), []byte("\r\n"), []byte("\n"))
+}
@@ -0,0 +1,128 @@
+package shell
+
+import (
+	"testing"
+
+	"src.elv.sh/pkg/must"
+	. "src.elv.sh/pkg/prog/progtest"
+	"src.elv.sh/pkg/testutil"
+)
+
+func TestTranscripts(t *testing.T) {
+	setupCleanHomePaths(t)
+	testutil.InTempDir(t)
+	testutil.ApplyDir(testutil.Dir{
+		"pass": testutil.Dir{
+			"a.elvts": "~> echo foo\nfoo\n",
+			"lib.elv": testutil.Dedent(`
+				# Outputs its argument.
+				#
+				# ~~~elvish-transcript
+				# ~> use lib
+				# ~> lib:f foo
+				# ▶ foo
+				# ~~~
+				fn f {|x| put $x }
+				`),
+			"setup.elvts": testutil.Dedent(`
+				//source setup.elv
+				//eval var y = bar
+				~> put $x $y
+				▶ foo
+				▶ bar
+				`),
+			"setup.elv": "var x = foo",
+			"temp.elvts": testutil.Dedent(`
+				//in-temp-dir
+				~> echo foo > file; slurp < file
+				▶ "foo\n"
+				`),
+			"skip.elvts": testutil.Dedent(`
+				//only-on nonexistent
+				~> echo foo
+				bar
+				`),
+		},
+		"fail": testutil.Dir{
+			"a.elvts": "~> echo foo\nbar\n",
+		},
+		"bad-directive": testutil.Dir{
+			"a.elvts": "//foo\n~> echo foo\nfoo\n",
+		},
+	})
+
+	Test(t, &Program{},
+		ThatElvish("-test", "pass").
+			WritesStdout("4 passed, 0 failed, 1 skipped\n"),
+		ThatElvish("-test", "fail").
+			ExitsWith(1).
+			WritesStdout("FAIL fail/a.elvts\n~> echo foo\n-want +got:\n@@ -1,1 +1,1 @@\n-bar\n+foo\n"+
+				"0 passed, 1 failed, 0 skipped\n"),
+		ThatElvish("-test", "bad-directive").
+			ExitsWith(1).
+			WritesStdout("FAIL bad-directive/a.elvts\nsetup: unknown directive: foo\n"+
+				"0 passed, 1 failed, 0 skipped\n"),
+		ThatElvish("-update").
+			ExitsWith(2).
+			WritesStderrContaining("-update can only be used with -test"),
+	)
+}
+
+func TestTranscripts_Update(t *testing.T) {
+	setupCleanHomePaths(t)
+	testutil.InTempDir(t)
+	testutil.ApplyDir(testutil.Dir{
+		"a.elvts": testutil.Dedent(`
+			// comment
+			~> echo foo
+			old
+			~> echo bar
+			bar
+
+			# section #
+			~> put lorem
+			`),
+		"b.elv": testutil.Dedent(`
+			# Example:
+			#
+			#   ~~~elvish-transcript
+			#   ~> echo foo
+			#   old
+			#   ~~~
+			fn f { }
+			`),
+	})
+
+	Test(t, &Program{},
+		ThatElvish("-test", "-update").
+			WritesStdout("updated a.elvts\nupdated b.elv\n"+
+				"3 passed, 0 failed, 0 skipped\n"),
+	)
+
+	wantA := testutil.Dedent(`
+		// comment
+		~> echo foo
+		foo
+		~> echo bar
+		bar
+
+		# section #
+		~> put lorem
+		▶ lorem
+		`)
+	if got := must.ReadFileString("a.elvts"); got != wantA {
+		t.Errorf("got a.elvts:\n%s\nwant:\n%s", got, wantA)
+	}
+	wantB := testutil.Dedent(`
+		# Example:
+		#
+		#   ~~~elvish-transcript
+		#   ~> echo foo
+		#   foo
+		#   ~~~
+		fn f { }
+		`)
+	if got := must.ReadFileString("b.elv"); got != wantB {
+		t.Errorf("got b.elv:\n%s\nwant:\n%s", got, wantB)
+	}
+}
@@ -0,0 +1,64 @@
+// Package fswatch watches the filesystem for changes.
+//
+// On Linux, it uses inotify. On other platforms, it falls back to polling the
+// filesystem periodically.
+package fswatch
+
+import (
+	"context"
+	"time"
+)
+
+// Op is the type of a filesystem change.
+type Op uint8
+
+// Possible values of Op.
+const (
+	// A file or directory was created, or moved into a watched directory.
+	Create Op = iota
+	// The content of a file was written to.
+	Write
+	// A file or directory was removed.
+	Remove
+	// A file or directory was moved away; the path of an event with this Op
+	// is the old path. When the new path is watched, a separate Create event
+	// is generated for it.
+	Rename
+)
+
+var opNames = [...]string{Create: "create", Write: "write", Remove: "remove", Rename: "rename"}
+
+func (op Op) String() string {
+	if int(op) < len(opNames) {
+		return opNames[op]
+	}
+	return "unknown"
+}
+
+// Event is a filesystem change.
+type Event struct {
+	Path string
+	Op   Op
+}
+
+// Opts keeps options to Watch.
+type Opts struct {
+	// Whether to also watch the descendants of directories, instead of just
+	// their direct children.
+	Recursive bool
+	// The interval between two scans when polling the filesystem. Defaults to
+	// DefaultInterval. Not used when inotify is available.
+	Interval time.Duration
+}
+
+// DefaultInterval is the default value of Opts.Interval.
+const DefaultInterval = 500 * time.Millisecond
+
+// Watch watches the given paths, calling f with each change, until ctx is done
+// or f returns false. Directories are watched for changes to their children.
+//
+// It returns an error if any of the paths can't be watched; otherwise it
+// returns nil.
+func Watch(ctx context.Context, paths []string, opts Opts, f func(Event) bool) error {
+	return watch(ctx, paths, opts, f)
+}
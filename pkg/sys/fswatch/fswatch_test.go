@@ -0,0 +1,175 @@
+package fswatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"src.elv.sh/pkg/testutil"
+)
+
+var implementations = []struct {
+	name  string
+	watch func(context.Context, []string, Opts, func(Event) bool) error
+}{
+	{"Watch", Watch},
+	{"poll", poll},
+}
+
+const testInterval = 10 * time.Millisecond
+
+// Starts watching, and returns a function that waits for the given event,
+// failing the test if it doesn't arrive in time. Since the exact sequence of
+// events may differ (for example, writing a file with inotify may result in
+// multiple Write events), other events before it are skipped, but returned.
+func startWatch(t *testing.T, watch func(context.Context, []string, Opts, func(Event) bool) error, paths []string, opts Opts) func(want Event) []Event {
+	t.Helper()
+	opts.Interval = testInterval
+	ctx, cancel := context.WithCancel(context.Background())
+	eventCh := make(chan Event, 100)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- watch(ctx, paths, opts, func(e Event) bool {
+			eventCh <- e
+			return true
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-errCh:
+			if err != nil {
+				t.Errorf("watch returned error: %v", err)
+			}
+		case <-time.After(testutil.Scaled(time.Second)):
+			t.Errorf("watch didn't return after cancellation")
+		}
+	})
+	// Give the watcher time to set up watches or take the initial snapshot.
+	time.Sleep(3 * testInterval)
+	return func(want Event) []Event {
+		t.Helper()
+		var skipped []Event
+		for {
+			select {
+			case e := <-eventCh:
+				if e == want {
+					return skipped
+				}
+				skipped = append(skipped, e)
+			case <-time.After(testutil.Scaled(time.Second)):
+				t.Fatalf("timed out waiting for %v, got %v", want, skipped)
+				return skipped
+			}
+		}
+	}
+}
+
+func TestWatch_DirectoryChildren(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			dir := testutil.TempDir(t)
+			f := filepath.Join(dir, "f")
+			wait := startWatch(t, impl.watch, []string{dir}, Opts{})
+
+			os.WriteFile(f, []byte("foo"), 0600)
+			if skipped := wait(Event{f, Create}); len(skipped) > 0 {
+				t.Errorf("got %v before create event", skipped)
+			}
+			// Make sure the modification time changes when polling.
+			time.Sleep(2 * testInterval)
+			os.WriteFile(f, []byte("foobar"), 0600)
+			wait(Event{f, Write})
+			os.Remove(f)
+			wait(Event{f, Remove})
+		})
+	}
+}
+
+func TestWatch_Recursive(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			dir := testutil.TempDir(t)
+			os.Mkdir(filepath.Join(dir, "d"), 0700)
+			wait := startWatch(t, impl.watch, []string{dir}, Opts{Recursive: true})
+
+			f := filepath.Join(dir, "d", "f")
+			os.WriteFile(f, nil, 0600)
+			wait(Event{f, Create})
+
+			// Directories created after the watch starts are watched too.
+			e := filepath.Join(dir, "e")
+			os.Mkdir(e, 0700)
+			wait(Event{e, Create})
+			g := filepath.Join(e, "g")
+			os.WriteFile(g, nil, 0600)
+			wait(Event{g, Create})
+		})
+	}
+}
+
+func TestWatch_NonRecursive(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			dir := testutil.TempDir(t)
+			os.Mkdir(filepath.Join(dir, "d"), 0700)
+			wait := startWatch(t, impl.watch, []string{dir}, Opts{})
+
+			// Changes in subdirectories are not reported.
+			os.WriteFile(filepath.Join(dir, "d", "f"), nil, 0600)
+			time.Sleep(3 * testInterval)
+			g := filepath.Join(dir, "g")
+			os.WriteFile(g, nil, 0600)
+			if skipped := wait(Event{g, Create}); len(skipped) > 0 {
+				t.Errorf("got unexpected events %v", skipped)
+			}
+		})
+	}
+}
+
+func TestWatch_StopsWhenCallbackReturnsFalse(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			dir := testutil.TempDir(t)
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- impl.watch(context.Background(), []string{dir},
+					Opts{Interval: testInterval}, func(Event) bool { return false })
+			}()
+			time.Sleep(3 * testInterval)
+			os.WriteFile(filepath.Join(dir, "f"), nil, 0600)
+			select {
+			case err := <-errCh:
+				if err != nil {
+					t.Errorf("got error %v", err)
+				}
+			case <-time.After(testutil.Scaled(time.Second)):
+				t.Errorf("watch didn't return")
+			}
+		})
+	}
+}
+
+func TestWatch_NonExistentPath(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			dir := testutil.TempDir(t)
+			err := impl.watch(context.Background(),
+				[]string{filepath.Join(dir, "bad")}, Opts{}, nil)
+			if !os.IsNotExist(err) {
+				t.Errorf("got error %v, want not-exist error", err)
+			}
+		})
+	}
+}
+
+func TestOp_String(t *testing.T) {
+	for op, want := range map[Op]string{
+		Create: "create", Write: "write", Remove: "remove", Rename: "rename", 10: "unknown"} {
+		if got := op.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", op, got, want)
+		}
+	}
+}
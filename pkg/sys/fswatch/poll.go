@@ -0,0 +1,99 @@
+package fswatch
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// State of a file that is tracked when polling.
+type fileState struct {
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+// Watches the paths by scanning them every opts.Interval and comparing
+// snapshots. This is less precise than inotify: multiple writes between two
+// scans are reported as one event, and renames are reported as a removal and
+// a creation.
+func poll(ctx context.Context, paths []string, opts Opts, f func(Event) bool) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	old := map[string]fileState{}
+	for _, path := range paths {
+		if err := scan(path, opts.Recursive, old); err != nil {
+			return err
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		new := map[string]fileState{}
+		for _, path := range paths {
+			// The root may have been removed, which will show up as a Remove
+			// event.
+			scan(path, opts.Recursive, new)
+		}
+		for _, event := range diff(old, new) {
+			if !f(event) {
+				return nil
+			}
+		}
+		old = new
+	}
+}
+
+// Records the states of path and the files under it in m. If recursive is
+// false, only direct children of path are recorded.
+func scan(path string, recursive bool, m map[string]fileState) error {
+	return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == path {
+				return err
+			}
+			// Files may disappear while walking; ignore them.
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		m[p] = fileState{info.IsDir(), info.Size(), info.ModTime()}
+		if d.IsDir() && p != path && !recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// Computes the events between two snapshots, sorted by path.
+func diff(old, new map[string]fileState) []Event {
+	var events []Event
+	for path, newState := range new {
+		oldState, ok := old[path]
+		switch {
+		case !ok:
+			events = append(events, Event{path, Create})
+		case !newState.isDir && (newState.size != oldState.size ||
+			!newState.modTime.Equal(oldState.modTime)):
+			events = append(events, Event{path, Write})
+		}
+	}
+	for path := range old {
+		if _, ok := new[path]; !ok {
+			events = append(events, Event{path, Remove})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events
+}
@@ -0,0 +1,164 @@
+package fswatch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_CREATE | unix.IN_MODIFY | unix.IN_DELETE |
+	unix.IN_DELETE_SELF | unix.IN_MOVED_FROM | unix.IN_MOVED_TO |
+	unix.IN_MOVE_SELF
+
+func watch(ctx context.Context, paths []string, opts Opts, f func(Event) bool) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return os.NewSyscallError("inotify_init1", err)
+	}
+	// Since fd is non-blocking, reading from file uses the runtime's poller, so
+	// closing file interrupts any pending read.
+	file := os.NewFile(uintptr(fd), "inotify")
+	defer file.Close()
+
+	w := &inotifyWatcher{fd: fd, recursive: opts.Recursive, paths: map[int]string{}}
+	for _, path := range paths {
+		if err := w.add(path); err != nil {
+			return err
+		}
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			file.Close()
+		case <-stop:
+		}
+	}()
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, os.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		for _, event := range w.parse(buf[:n]) {
+			if !f(event) {
+				return nil
+			}
+		}
+	}
+}
+
+type inotifyWatcher struct {
+	fd        int
+	recursive bool
+	// Paths corresponding to watch descriptors.
+	paths map[int]string
+}
+
+// Adds a watch for path. If w.recursive is true, all the subdirectories of
+// path are also watched.
+func (w *inotifyWatcher) add(path string) error {
+	if !w.recursive {
+		return w.addOne(path)
+	}
+	return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == path {
+				return err
+			}
+			return nil
+		}
+		if p != path && !d.IsDir() {
+			return nil
+		}
+		return w.addOne(p)
+	})
+}
+
+func (w *inotifyWatcher) addOne(path string) error {
+	wd, err := unix.InotifyAddWatch(w.fd, path, inotifyMask)
+	if err != nil {
+		return &fs.PathError{Op: "watch", Path: path, Err: err}
+	}
+	w.paths[wd] = path
+	return nil
+}
+
+func (w *inotifyWatcher) parse(buf []byte) []Event {
+	var events []Event
+	for len(buf) >= unix.SizeofInotifyEvent {
+		raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[0]))
+		nameBytes := buf[unix.SizeofInotifyEvent : unix.SizeofInotifyEvent+raw.Len]
+		buf = buf[unix.SizeofInotifyEvent+raw.Len:]
+
+		dir, ok := w.paths[int(raw.Wd)]
+		if !ok {
+			continue
+		}
+		if raw.Mask&unix.IN_IGNORED != 0 {
+			delete(w.paths, int(raw.Wd))
+			continue
+		}
+		path := dir
+		if name := cString(nameBytes); name != "" {
+			path = filepath.Join(dir, name)
+		} else if raw.Mask&(unix.IN_DELETE_SELF|unix.IN_MOVE_SELF) != 0 &&
+			raw.Mask&unix.IN_ISDIR != 0 {
+			// The removal or renaming of a subdirectory is already reported
+			// via the watch on its parent, unless it is one of the roots.
+			if w.isWatchedChild(dir) {
+				continue
+			}
+		}
+
+		mask := raw.Mask
+		switch {
+		case mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+			events = append(events, Event{path, Create})
+			if w.recursive && mask&unix.IN_ISDIR != 0 {
+				// Errors are ignored, since the directory may have already
+				// been removed.
+				w.add(path)
+			}
+		case mask&unix.IN_MODIFY != 0:
+			events = append(events, Event{path, Write})
+		case mask&(unix.IN_DELETE|unix.IN_DELETE_SELF) != 0:
+			events = append(events, Event{path, Remove})
+		case mask&(unix.IN_MOVED_FROM|unix.IN_MOVE_SELF) != 0:
+			events = append(events, Event{path, Rename})
+		}
+	}
+	return events
+}
+
+// Returns whether the parent of path is also watched.
+func (w *inotifyWatcher) isWatchedChild(path string) bool {
+	parent := filepath.Dir(path)
+	for _, p := range w.paths {
+		if p == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the content of b up to the first NUL byte.
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
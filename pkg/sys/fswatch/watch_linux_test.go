@@ -0,0 +1,33 @@
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"src.elv.sh/pkg/testutil"
+)
+
+func TestWatch_Rename(t *testing.T) {
+	dir := testutil.TempDir(t)
+	f := filepath.Join(dir, "f")
+	os.WriteFile(f, nil, 0600)
+	wait := startWatch(t, Watch, []string{dir}, Opts{})
+
+	g := filepath.Join(dir, "g")
+	os.Rename(f, g)
+	wait(Event{f, Rename})
+	wait(Event{g, Create})
+}
+
+func TestWatch_File(t *testing.T) {
+	dir := testutil.TempDir(t)
+	f := filepath.Join(dir, "f")
+	os.WriteFile(f, nil, 0600)
+	wait := startWatch(t, Watch, []string{f}, Opts{})
+
+	os.WriteFile(f, []byte("foo"), 0600)
+	wait(Event{f, Write})
+	os.Remove(f)
+	wait(Event{f, Remove})
+}
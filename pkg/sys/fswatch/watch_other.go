@@ -0,0 +1,9 @@
+//go:build !linux
+
+package fswatch
+
+import "context"
+
+func watch(ctx context.Context, paths []string, opts Opts, f func(Event) bool) error {
+	return poll(ctx, paths, opts, f)
+}
@@ -431,6 +431,71 @@ func parseSession(lines []string, name string, linenoOffset int) (Session, error
 	return Session{name, directives, interactions}, nil
 }
 
+// UpdateOutputs takes the lines of a block of transcript, and returns a copy of
+// it where the output of the i-th interaction is replaced with outputs[i], for
+// each i in outputs. Interactions are numbered in the order they appear in the
+// block, across all its sessions, matching the order they are returned by
+// [ParseSessionsInBlock].
+//
+// Comments within replaced outputs are removed. Other comments, as well as
+// headings, directives and empty lines at the end of sessions, are kept
+// intact.
+func UpdateOutputs(lines []string, outputs map[int]string) []string {
+	var updated []string
+	n := 0
+	for i := 0; i < len(lines); {
+		prompt := PromptPattern.FindString(lines[i])
+		if prompt == "" {
+			updated = append(updated, lines[i])
+			i++
+			continue
+		}
+		// Keep the code lines.
+		updated = append(updated, lines[i])
+		i++
+		continuation := strings.Repeat(" ", len(prompt))
+		for i < len(lines) && strings.HasPrefix(lines[i], continuation) {
+			updated = append(updated, lines[i])
+			i++
+		}
+		// Find the output lines, which extend to the next prompt or heading.
+		// Like in parseSession, empty lines and comment lines at the end of a
+		// session are not part of the output.
+		end := i
+		for end < len(lines) && !PromptPattern.MatchString(lines[end]) && !isHeading(lines[end]) {
+			end++
+		}
+		outputEnd := end
+		if end == len(lines) || isHeading(lines[end]) {
+			for outputEnd > i && (lines[outputEnd-1] == "" || isComment(lines[outputEnd-1])) {
+				outputEnd--
+			}
+		}
+		if output, ok := outputs[n]; ok {
+			updated = append(updated, splitLines(output)...)
+		} else {
+			updated = append(updated, lines[i:outputEnd]...)
+		}
+		updated = append(updated, lines[outputEnd:end]...)
+		i = end
+		n++
+	}
+	return updated
+}
+
+func isHeading(line string) bool {
+	return (strings.HasPrefix(line, "# ") && strings.HasSuffix(line, " #")) ||
+		(strings.HasPrefix(line, "## ") && strings.HasSuffix(line, " ##"))
+}
+
+// The inverse of joinLines.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
 var slashOnlyCommentPattern = regexp.MustCompile(`^///*$`)
 
 func isComment(line string) bool {
@@ -1,6 +1,7 @@
 package transcript_test
 
 import (
+	"strings"
 	"testing"
 
 	"src.elv.sh/pkg/testutil"
@@ -303,6 +304,74 @@ func TestParseSessionsInFS(t *testing.T) {
 	)
 }
 
+func TestUpdateOutputs(t *testing.T) {
+	updateOutputs := func(block string, outputs map[int]string) string {
+		lines := strings.Split(block, "\n")
+		return strings.Join(UpdateOutputs(lines, outputs), "\n")
+	}
+	tt.Test(t, tt.Fn(updateOutputs).Named("updateOutputs"),
+		It("replaces outputs of the given interactions").
+			Args(Dedent(`
+				~> echo foo
+				   echo bar
+				old
+				~> echo lorem
+				lorem
+				`), map[int]string{0: "foo\nbar\n"}).
+			Rets(Dedent(`
+				~> echo foo
+				   echo bar
+				foo
+				bar
+				~> echo lorem
+				lorem
+				`)),
+
+		It("numbers interactions across sessions, keeping headings and directives").
+			Args(Dedent(`
+				//top
+
+				# h1 #
+				//h1
+				~> echo foo
+				old
+
+				// comment at end of session
+
+				# h2 #
+				~> echo bar
+				old
+				`), map[int]string{0: "foo\n", 1: "bar\n"}).
+			Rets(Dedent(`
+				//top
+
+				# h1 #
+				//h1
+				~> echo foo
+				foo
+
+				// comment at end of session
+
+				# h2 #
+				~> echo bar
+				bar
+				`)),
+
+		It("removes comments within replaced outputs").
+			Args(Dedent(`
+				~> echo foo
+				// comment
+				old
+				~> echo bar
+				`), map[int]string{0: "foo\n", 1: ""}).
+			Rets(Dedent(`
+				~> echo foo
+				foo
+				~> echo bar
+				`)),
+	)
+}
+
 func oneFile(name, content string) Dir { return Dir{name: Dedent(content)} }
 
 func oneSession(name string, interactions ...Interaction) ([]Session, error) {